@@ -670,6 +670,10 @@ func ParseCallToolResult(rawMessage *json.RawMessage) (*CallToolResult, error) {
 		result.Content = append(result.Content, content)
 	}
 
+	if structured, ok := jsonContent["structuredContent"]; ok {
+		result.StructuredContent = structured
+	}
+
 	return &result, nil
 }
 
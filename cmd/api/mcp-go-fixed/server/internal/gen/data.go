@@ -67,6 +67,26 @@ var MCPRequestTypes = []MCPRequestType{
 		HookName:       "ReadResource",
 		UnmarshalError: "invalid read resource request",
 		HandlerFunc:    "handleReadResource",
+	}, {
+		MethodName:     "MethodResourcesSubscribe",
+		ParamType:      "SubscribeRequest",
+		ResultType:     "EmptyResult",
+		Group:          "resources",
+		GroupName:      "Resources",
+		GroupHookName:  "Resource",
+		HookName:       "Subscribe",
+		UnmarshalError: "invalid subscribe request",
+		HandlerFunc:    "handleSubscribe",
+	}, {
+		MethodName:     "MethodResourcesUnsubscribe",
+		ParamType:      "UnsubscribeRequest",
+		ResultType:     "EmptyResult",
+		Group:          "resources",
+		GroupName:      "Resources",
+		GroupHookName:  "Resource",
+		HookName:       "Unsubscribe",
+		UnmarshalError: "invalid unsubscribe request",
+		HandlerFunc:    "handleUnsubscribe",
 	}, {
 		MethodName:     "MethodPromptsList",
 		ParamType:      "ListPromptsRequest",
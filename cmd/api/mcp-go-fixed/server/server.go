@@ -165,6 +165,7 @@ type MCPServer struct {
 	capabilities           serverCapabilities
 	paginationLimit        *int
 	sessions               sync.Map
+	subscriptions          sync.Map // session ID -> *resourceSubscriptions
 	hooks                  *Hooks
 }
 
@@ -213,6 +213,7 @@ func (s *MCPServer) UnregisterSession(
 	if !ok {
 		return
 	}
+	s.subscriptions.Delete(sessionID)
 	if session, ok := sessionValue.(ClientSession); ok {
 		s.hooks.UnregisterSession(ctx, session)
 	}
@@ -45,6 +45,11 @@ type SSEContextFunc func(ctx context.Context, r *http.Request) context.Context
 // function should return the base path (e.g., "/mcp/tenant123").
 type DynamicBasePathFunc func(r *http.Request, sessionID string) string
 
+// DynamicBaseURLFunc returns the base URL (e.g., "https://example.com") for a
+// request. It lets the message endpoint follow the host a client connected to,
+// such as localhost in development and the public host behind a proxy.
+type DynamicBaseURLFunc func(r *http.Request) string
+
 func (s *sseSession) SessionID() string {
 	return s.sessionID
 }
@@ -143,6 +148,7 @@ type SSEServer struct {
 	srv                          *http.Server
 	contextFunc                  SSEContextFunc
 	dynamicBasePathFunc          DynamicBasePathFunc
+	dynamicBaseURLFunc           DynamicBaseURLFunc
 
 	keepAlive         bool
 	keepAliveInterval time.Duration
@@ -207,6 +213,15 @@ func WithDynamicBasePath(fn DynamicBasePathFunc) SSEOption {
 	}
 }
 
+// WithDynamicBaseURL sets a function computing the base URL per request. It
+// takes precedence over WithBaseURL when building the message endpoint sent to
+// clients with WithUseFullURLForMessageEndpoint.
+func WithDynamicBaseURL(fn DynamicBaseURLFunc) SSEOption {
+	return func(s *SSEServer) {
+		s.dynamicBaseURLFunc = fn
+	}
+}
+
 // WithMessageEndpoint sets the message endpoint path
 func WithMessageEndpoint(endpoint string) SSEOption {
 	return func(s *SSEServer) {
@@ -470,8 +485,12 @@ func (s *SSEServer) GetMessageEndpointForClient(r *http.Request, sessionID strin
 	}
 
 	endpointPath := normalizeURLPath(basePath, s.messageEndpoint)
-	if s.useFullURLForMessageEndpoint && s.baseURL != "" {
-		endpointPath = s.baseURL + endpointPath
+	baseURL := s.baseURL
+	if s.dynamicBaseURLFunc != nil {
+		baseURL = strings.TrimSuffix(s.dynamicBaseURLFunc(r), "/")
+	}
+	if s.useFullURLForMessageEndpoint && baseURL != "" {
+		endpointPath = baseURL + endpointPath
 	}
 
 	return fmt.Sprintf("%s?sessionId=%s", endpointPath, sessionID)
@@ -1629,3 +1629,18 @@ func addHeaderVerificationTool(mcpServer *MCPServer) {
 		},
 	)
 }
+
+func TestSSEServer_DynamicBaseURL(t *testing.T) {
+	sseServer := NewSSEServer(
+		NewMCPServer("test", "1.0.0"),
+		WithBaseURL("https://example.com"),
+		WithStaticBasePath("/mcp"),
+		WithUseFullURLForMessageEndpoint(true),
+		WithDynamicBaseURL(func(r *http.Request) string { return "http://" + r.Host + "/" }),
+	)
+	req := httptest.NewRequest("GET", "http://localhost:8080/mcp/sse", nil)
+	got := sseServer.GetMessageEndpointForClient(req, "abc")
+	if want := "http://localhost:8080/mcp/message?sessionId=abc"; got != want {
+		t.Errorf("GetMessageEndpointForClient() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// resourceSubscriptions holds the URIs one session subscribed to.
+type resourceSubscriptions struct {
+	mu   sync.Mutex
+	uris map[string]struct{}
+}
+
+func (s *MCPServer) handleSubscribe(
+	ctx context.Context,
+	id any,
+	request mcp.SubscribeRequest,
+) (*mcp.EmptyResult, *requestError) {
+	sessionID, err := s.subscriptionSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.resourceExists(request.Params.URI) {
+		return nil, &requestError{
+			id:   id,
+			code: mcp.RESOURCE_NOT_FOUND,
+			err: fmt.Errorf(
+				"handler not found for resource URI '%s': %w",
+				request.Params.URI,
+				ErrResourceNotFound,
+			),
+		}
+	}
+	value, _ := s.subscriptions.LoadOrStore(sessionID, &resourceSubscriptions{uris: map[string]struct{}{}})
+	subs := value.(*resourceSubscriptions)
+	subs.mu.Lock()
+	subs.uris[request.Params.URI] = struct{}{}
+	subs.mu.Unlock()
+	return &mcp.EmptyResult{}, nil
+}
+
+func (s *MCPServer) handleUnsubscribe(
+	ctx context.Context,
+	id any,
+	request mcp.UnsubscribeRequest,
+) (*mcp.EmptyResult, *requestError) {
+	sessionID, err := s.subscriptionSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := s.subscriptions.Load(sessionID); ok {
+		subs := value.(*resourceSubscriptions)
+		subs.mu.Lock()
+		delete(subs.uris, request.Params.URI)
+		subs.mu.Unlock()
+	}
+	return &mcp.EmptyResult{}, nil
+}
+
+// subscriptionSession returns the ID of the registered session behind ctx. Subscriptions
+// outlive the request, so stateless requests cannot subscribe.
+func (s *MCPServer) subscriptionSession(ctx context.Context, id any) (string, *requestError) {
+	s.capabilitiesMu.RLock()
+	enabled := s.capabilities.resources != nil && s.capabilities.resources.subscribe
+	s.capabilitiesMu.RUnlock()
+	if !enabled {
+		return "", &requestError{
+			id:   id,
+			code: mcp.METHOD_NOT_FOUND,
+			err:  fmt.Errorf("resource subscriptions %w", ErrUnsupported),
+		}
+	}
+	session := ClientSessionFromContext(ctx)
+	if session == nil {
+		return "", &requestError{id: id, code: mcp.INVALID_REQUEST, err: ErrSessionNotFound}
+	}
+	if _, ok := s.sessions.Load(session.SessionID()); !ok {
+		return "", &requestError{id: id, code: mcp.INVALID_REQUEST, err: ErrSessionNotFound}
+	}
+	return session.SessionID(), nil
+}
+
+// resourceExists reports whether uri is a registered resource or matches a resource template.
+func (s *MCPServer) resourceExists(uri string) bool {
+	s.resourcesMu.RLock()
+	defer s.resourcesMu.RUnlock()
+	if _, ok := s.resources[uri]; ok {
+		return true
+	}
+	for _, entry := range s.resourceTemplates {
+		if matchesTemplate(uri, entry.template.URITemplate) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribedResources returns the URIs any session is subscribed to, sorted.
+func (s *MCPServer) SubscribedResources() []string {
+	seen := map[string]struct{}{}
+	s.subscriptions.Range(func(_, value any) bool {
+		subs := value.(*resourceSubscriptions)
+		subs.mu.Lock()
+		for uri := range subs.uris {
+			seen[uri] = struct{}{}
+		}
+		subs.mu.Unlock()
+		return true
+	})
+	uris := make([]string, 0, len(seen))
+	for uri := range seen {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated for uri to every session
+// subscribed to it.
+func (s *MCPServer) NotifyResourceUpdated(uri string) {
+	s.subscriptions.Range(func(key, value any) bool {
+		subs := value.(*resourceSubscriptions)
+		subs.mu.Lock()
+		_, ok := subs.uris[uri]
+		subs.mu.Unlock()
+		if ok {
+			_ = s.SendNotificationToSpecificClient(key.(string), mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		}
+		return true
+	})
+}
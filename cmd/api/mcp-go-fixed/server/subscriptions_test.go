@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPServer_ResourceSubscriptions(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithResourceCapabilities(true, false))
+	server.AddResourceTemplate(
+		mcp.NewResourceTemplate("test://items/{id}", "Item"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return nil, nil
+		},
+	)
+
+	session := fakeSession{sessionID: "s1", notificationChannel: make(chan mcp.JSONRPCNotification, 10), initialized: true}
+	require.NoError(t, server.RegisterSession(context.Background(), session))
+	ctx := server.WithContext(context.Background(), session)
+
+	call := func(method, uri string) mcp.JSONRPCMessage {
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": map[string]any{"uri": uri}})
+		return server.HandleMessage(ctx, msg)
+	}
+
+	resp := call("resources/subscribe", "test://items/1")
+	assert.IsType(t, mcp.JSONRPCResponse{}, resp)
+	resp = call("resources/subscribe", "test://other/1")
+	if assert.IsType(t, mcp.JSONRPCError{}, resp) {
+		assert.Equal(t, mcp.RESOURCE_NOT_FOUND, resp.(mcp.JSONRPCError).Error.Code)
+	}
+	assert.Equal(t, []string{"test://items/1"}, server.SubscribedResources())
+
+	server.NotifyResourceUpdated("test://items/2")
+	server.NotifyResourceUpdated("test://items/1")
+	require.Len(t, session.notificationChannel, 1)
+	n := <-session.notificationChannel
+	assert.Equal(t, mcp.MethodNotificationResourceUpdated, n.Method)
+	assert.Equal(t, "test://items/1", n.Params.AdditionalFields["uri"])
+
+	call("resources/unsubscribe", "test://items/1")
+	server.NotifyResourceUpdated("test://items/1")
+	assert.Len(t, session.notificationChannel, 0)
+
+	call("resources/subscribe", "test://items/1")
+	server.UnregisterSession(context.Background(), "s1")
+	assert.Empty(t, server.SubscribedResources())
+}
+
+func TestMCPServer_ResourceSubscriptions_Unsupported(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithResourceCapabilities(false, false))
+	msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/subscribe", "params": map[string]any{"uri": "test://items/1"}})
+	resp := server.HandleMessage(context.Background(), msg)
+	if assert.IsType(t, mcp.JSONRPCError{}, resp) {
+		assert.Equal(t, mcp.METHOD_NOT_FOUND, resp.(mcp.JSONRPCError).Error.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// featuredCard is the part of the API's /gpus/featured cards the blog uses.
+type featuredCard struct {
+	Label  string `json:"label"`
+	Reason string `json:"reason"`
+	Offer  struct {
+		Name        string  `json:"name"`
+		Source      string  `json:"source"`
+		TotalCostPH float64 `json:"total_cost_ph"`
+	} `json:"offer"`
+}
+
+// featuredDeals lists the front page picks, one per line, so posts can cite live prices.
+// Returns "" when the API is unreachable; a post without deals is still a post.
+func featuredDeals() string {
+	endpoint := os.Getenv("FEATURED_URL")
+	if endpoint == "" {
+		endpoint = "https://gpufindr.com/gpus/featured"
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		fmt.Println("featured deals unavailable:", err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var cards []featuredCard
+	if resp.StatusCode >= 300 || json.NewDecoder(resp.Body).Decode(&cards) != nil {
+		fmt.Println("featured deals unavailable:", resp.Status)
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range cards {
+		fmt.Fprintf(&b, "- %s: %s on %s at $%.2f/hour (%s)\n", c.Label, c.Offer.Name, c.Offer.Source, c.Offer.TotalCostPH, c.Reason)
+	}
+	return b.String()
+}
@@ -11,7 +11,11 @@ import (
 )
 
 func getWritePrompt(topic string) string {
-	return fmt.Sprintf("You are an expert blog writer who is creative, entertaining, and writes intruiging blog posts which viewers care about. You are focusing on the ML landscape with a focus on cheap GPUs. A few rules: Don't use em dashes. Don't writeArticle fluff. Ensure you hit important keywords revolving the topic. Everything is in the context of ML/AI and GPUs. Write it well and ensure it is interesting to read with accurate facts. Only return the blog in markdown form without a getTitle.\n\nTopic: %s", topic)
+	prompt := fmt.Sprintf("You are an expert blog writer who is creative, entertaining, and writes intruiging blog posts which viewers care about. You are focusing on the ML landscape with a focus on cheap GPUs. A few rules: Don't use em dashes. Don't writeArticle fluff. Ensure you hit important keywords revolving the topic. Everything is in the context of ML/AI and GPUs. Write it well and ensure it is interesting to read with accurate facts. Only return the blog in markdown form without a getTitle.\n\nTopic: %s", topic)
+	if deals := featuredDeals(); deals != "" {
+		prompt += "\n\nToday's featured deals on gpufindr.com. Mention them only where they fit the topic:\n" + deals
+	}
+	return prompt
 }
 
 func getTitlePrompt(data string) string {
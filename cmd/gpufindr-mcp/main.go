@@ -0,0 +1,40 @@
+// cmd/gpufindr-mcp/main.go
+
+// gpufindr-mcp serves the gpufindr MCP tools, resources and prompts over stdio, for agents that
+// run their MCP servers locally. The catalogue is read from CATALOGUE_FILE when it is set, and
+// otherwise from the API at GPUFINDR_API_URL (default https://gpufindr.com). stdout carries the
+// protocol, so logs go to stderr.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shaymanor/gpuscanner/internal/api"
+)
+
+const defaultAPIURL = "https://gpufindr.com"
+
+func main() {
+	log.SetOutput(os.Stderr)
+
+	var st api.Store
+	if path := os.Getenv("CATALOGUE_FILE"); path != "" {
+		st = api.NewFileStore(path)
+	} else {
+		base := os.Getenv("GPUFINDR_API_URL")
+		if base == "" {
+			base = defaultAPIURL
+		}
+		st = api.NewAPIStore(base)
+	}
+	api.Setup(st)
+	s := api.NewMCPServer()
+	go api.Run(context.Background())
+
+	if err := server.ServeStdio(s, server.WithErrorLogger(log.New(os.Stderr, "gpufindr-mcp: ", log.LstdFlags))); err != nil {
+		log.Fatal(err)
+	}
+}
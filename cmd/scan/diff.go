@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// scanRow summarises one run in the scans table.
+type scanRow struct {
+	ScanID            string     `json:"scan_id"`
+	ScannedAt         time.Time  `json:"scanned_at"`
+	PreviousScannedAt *time.Time `json:"previous_scanned_at"`
+	Offers            int        `json:"offers"`
+	Added             int        `json:"added"`
+	Removed           int        `json:"removed"`
+	PriceUp           int        `json:"price_up"`
+	PriceDown         int        `json:"price_down"`
+	Availability      int        `json:"availability"`
+}
+
+// diffRow is one scandiff event in scan_diffs.
+type diffRow struct {
+	ScanID    string    `json:"scan_id"`
+	ScannedAt time.Time `json:"scanned_at"`
+	scandiff.Event
+	Model string `json:"model"`
+	// NewModel marks an added offer of a model no listing had in the previous scan.
+	NewModel bool `json:"new_model"`
+	// OfferID is the key's cheapest listing in this scan, or in the previous one for removed.
+	OfferID string `json:"offer_id"`
+}
+
+// previousScan reads the gpus table as the last run left it. Call it before the replace.
+func previousScan() ([]GPU, error) {
+	base := os.Getenv("SUPABASE_URL") + "/rest/v1/gpus"
+	key := os.Getenv("SUPABASE_SERVICE_KEY")
+
+	var all []GPU
+	for off := 0; ; off += 1000 {
+		q := url.Values{}
+		q.Set("select", "id,source,name,location,num_gpus,total_cost_ph,updated_at")
+		q.Set("order", "id.asc")
+		q.Set("limit", "1000")
+		q.Set("offset", strconv.Itoa(off))
+		req, _ := http.NewRequest("GET", base+"?"+q.Encode(), nil)
+		req.Header.Set("apikey", key)
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page []GPU
+		if resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s", resp.Status, string(b))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < 1000 {
+			return all, nil
+		}
+	}
+}
+
+func diffOffers(rows []GPU) []scandiff.Offer {
+	out := make([]scandiff.Offer, len(rows))
+	for i, g := range rows {
+		out[i] = scandiff.Offer{Source: g.Source, Name: g.Name, Location: g.Location, NumGPUs: g.NumGPUs, TotalCostPH: g.TotalCostPH}
+	}
+	return out
+}
+
+// recordDiff stores what changed since the previous scan in scans and scan_diffs. Rows age out
+// with gpu_history's retention.
+func recordDiff(scanID string, scannedAt time.Time, prev, rows []GPU) error {
+	base := os.Getenv("SUPABASE_URL") + "/rest/v1/"
+	key := os.Getenv("SUPABASE_SERVICE_KEY")
+
+	events := scandiff.Diff(diffOffers(prev), diffOffers(rows))
+	counts := scandiff.Counts(events)
+	scan := scanRow{
+		ScanID:       scanID,
+		ScannedAt:    scannedAt,
+		Offers:       len(rows),
+		Added:        counts[scandiff.Added],
+		Removed:      counts[scandiff.Removed],
+		PriceUp:      counts[scandiff.PriceUp],
+		PriceDown:    counts[scandiff.PriceDown],
+		Availability: counts[scandiff.Availability],
+	}
+	for _, g := range prev {
+		if scan.PreviousScannedAt == nil || g.UpdatedAt.After(*scan.PreviousScannedAt) {
+			at := g.UpdatedAt
+			scan.PreviousScannedAt = &at
+		}
+	}
+	body, _ := json.Marshal(scan)
+	if err := supabaseDo(key, "POST", base+"scans", body); err != nil {
+		return fmt.Errorf("insert scan: %w", err)
+	}
+
+	listed := map[string]bool{}
+	for _, g := range prev {
+		listed[gpumodel.Canonical(g.Name)] = true
+	}
+	diffs := make([]diffRow, len(events))
+	for i, e := range events {
+		g := prev[max(e.Prev, 0)]
+		if e.Cur >= 0 {
+			g = rows[e.Cur]
+		}
+		model := gpumodel.Canonical(e.Name)
+		diffs[i] = diffRow{
+			ScanID:    scanID,
+			ScannedAt: scannedAt,
+			Event:     e,
+			Model:     model,
+			// With no previous scan everything would be new.
+			NewModel: e.Type == scandiff.Added && model != "" && len(prev) > 0 && !listed[model],
+			OfferID:  g.Id,
+		}
+	}
+	if len(diffs) > 0 {
+		body, _ = json.Marshal(diffs)
+		if err := supabaseDo(key, "POST", base+"scan_diffs", body); err != nil {
+			return fmt.Errorf("insert diff: %w", err)
+		}
+	}
+
+	q := url.Values{}
+	q.Set("scanned_at", "lt."+scannedAt.Add(-historyRetention).Format(time.RFC3339))
+	if err := supabaseDo(key, "DELETE", base+"scans?"+q.Encode(), nil); err != nil {
+		return fmt.Errorf("prune: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// historyRetention is how long gpu_history keeps a scan.
+const historyRetention = 90 * 24 * time.Hour
+
+// historyRow is one offer as seen by one scan.
+type historyRow struct {
+	ScanID      string    `json:"scan_id"`
+	ScannedAt   time.Time `json:"scanned_at"`
+	OfferID     string    `json:"offer_id"`
+	Source      string    `json:"source"`
+	Location    string    `json:"location"`
+	Name        string    `json:"name"`
+	Model       string    `json:"model"`
+	NumGPUs     int       `json:"num_gpus"`
+	Vram        int       `json:"vram_mb"`
+	TotalCostPH float64   `json:"total_cost_ph"`
+}
+
+// recordHistory appends this scan to gpu_history and drops scans past the retention window.
+func recordHistory(scanID string, rows []GPU, scannedAt time.Time) error {
+	base := os.Getenv("SUPABASE_URL") + "/rest/v1/gpu_history"
+	key := os.Getenv("SUPABASE_SERVICE_KEY")
+
+	hist := make([]historyRow, len(rows))
+	for i, g := range rows {
+		hist[i] = historyRow{
+			ScanID:      scanID,
+			ScannedAt:   scannedAt,
+			OfferID:     g.Id,
+			Source:      g.Source,
+			Location:    g.Location,
+			Name:        g.Name,
+			Model:       gpumodel.Canonical(g.Name),
+			NumGPUs:     g.NumGPUs,
+			Vram:        g.Vram,
+			TotalCostPH: g.TotalCostPH,
+		}
+	}
+	body, _ := json.Marshal(hist)
+	if err := supabaseDo(key, "POST", base, body); err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("scanned_at", "lt."+scannedAt.Add(-historyRetention).Format(time.RFC3339))
+	if err := supabaseDo(key, "DELETE", base+"?"+q.Encode(), nil); err != nil {
+		return fmt.Errorf("prune: %w", err)
+	}
+	return nil
+}
+
+func supabaseDo(key, method, endpoint string, body []byte) error {
+	req, _ := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", key)
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s", resp.Status, string(b))
+	}
+	return nil
+}
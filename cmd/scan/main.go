@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,6 +23,7 @@ func main() {
 		rows = append(rows, scan(getter)...)
 	}
 
+	scannedAt := time.Now().UTC()
 	for idx, _ := range rows {
 		rows[idx].Id = uuid.New().String()
 	}
@@ -29,6 +31,10 @@ func main() {
 	base := os.Getenv("SUPABASE_URL") + "/rest/v1/gpus"
 	key := os.Getenv("SUPABASE_SERVICE_KEY")
 
+	// 0) Remember what the last run left, to diff against once this one is live.
+	scanID := uuid.New().String()
+	prev, prevErr := previousScan()
+
 	// 1) DELETE only rows we manage: source in (lambda, vast, tensordock, runpod)
 	sources := []string{"lambda", "vast", "tensordock", "runpod"}
 	q := url.Values{}
@@ -66,4 +72,45 @@ func main() {
 		log.Fatalf("insert failed: %s %s", insResp.Status, string(b))
 	}
 	fmt.Println("replace OK")
+
+	// 3) Keep the scan for price history; the catalogue itself is already live.
+	if err := recordHistory(scanID, rows, scannedAt); err != nil {
+		log.Printf("history failed: %v", err)
+	} else {
+		fmt.Println("history OK")
+	}
+
+	// 4) Record what changed since the last run.
+	if prevErr != nil {
+		log.Printf("diff skipped, previous scan unreadable: %v", prevErr)
+	} else if err := recordDiff(scanID, scannedAt, prev, rows); err != nil {
+		log.Printf("diff failed: %v", err)
+	} else {
+		fmt.Println("diff OK")
+	}
+
+	notifyAPI()
+}
+
+// notifyAPI asks the API to reload its catalogue cache so the new scan is served straight away.
+func notifyAPI() {
+	endpoint := os.Getenv("API_REFRESH_URL")
+	if endpoint == "" {
+		return
+	}
+	req, _ := http.NewRequest("POST", endpoint, nil)
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("REFRESH_TOKEN"))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("refresh failed: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		log.Printf("refresh failed: %s %s", resp.Status, string(b))
+		return
+	}
+	fmt.Println("refresh OK")
 }
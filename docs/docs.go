@@ -15,237 +15,2521 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/gpus": {
+        "/admin/mcp/metrics": {
+            "get": {
+                "description": "The usage report as Prometheus metrics (gpufindr_mcp_*), for scraping.\nRequires Authorization: Bearer $ADMIN_TOKEN; disabled when ADMIN_TOKEN is unset.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "MCP usage metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/mcp/usage": {
             "get": {
-                "description": "Returns a JSON array of GPU offers (read-only).",
+                "description": "What MCP clients asked for since the API started: requests and errors by method, tool calls\nwith latency, errors and argument shapes (names and JSON types only), resource reads by kind,\nprompts, tool calls per authenticated client and session lifetimes.\nRequires Authorization: Bearer $ADMIN_TOKEN; disabled when ADMIN_TOKEN is unset.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "gpus"
+                    "admin"
                 ],
-                "summary": "List GPUs",
+                "summary": "MCP usage report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MCPUsage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalogue/refresh": {
+            "post": {
+                "description": "Called by the scanner once a scan has been written so the API serves it straight away, and\nevaluates saved searches against it.\nRequires Authorization: Bearer $REFRESH_TOKEN; disabled when REFRESH_TOKEN is unset.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload the catalogue cache",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/compare": {
+            "get": {
+                "description": "Side-by-side table of 2-5 offers: every field's values aligned with subjects, the winning\nsubject(s) and each value as a ratio of the first subject's. Subjects carry their model's\ndatasheet and what each provider charges for that model per GPU-hour.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "compare"
+                ],
+                "summary": "Compare offers",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Provider (e.g., vastai, tensordock, runpod)",
-                        "name": "source",
-                        "in": "query"
+                        "description": "Comma-separated offer ids",
+                        "name": "ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Comparison"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Unknown offer",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/compare/models": {
+            "get": {
+                "description": "Datasheet and market comparison of 2-5 GPU models (name, slug or alias, e.g. h200,h100-sxm):\nFP32, VRAM and bandwidth, current cheapest and median price per GPU-hour, and TFLOPs, GB/s and\nGB of VRAM per dollar at the cheapest price, with winners, ratios to the first model and\nper-provider price ranges.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "compare"
+                ],
+                "summary": "Compare GPU models",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Case-insensitive substring match",
-                        "name": "location",
-                        "in": "query"
+                        "description": "Comma-separated GPU models",
+                        "name": "models",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Comparison"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Unknown model",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/confirm": {
+            "get": {
+                "description": "The page behind the link in the confirmation email sent when a search is saved with an email\naddress. It only asks for confirmation, so mail scanners that follow links confirm nothing.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Confirm alert emails",
+                "parameters": [
                     {
-                        "type": "number",
-                        "description": "Max total_cost_ph",
-                        "name": "max_price",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Confirmation token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
                     },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            },
+            "post": {
+                "description": "Confirms the email address of the search the token belongs to. Until then the search emails\nnothing, test alerts included.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Start alert emails",
+                "parameters": [
                     {
-                        "type": "number",
-                        "description": "Min flops_per_dollar_ph",
-                        "name": "min_flopsd",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Confirmation token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
                     },
+                    "502": {
+                        "description": "Bad Gateway"
+                    }
+                }
+            }
+        },
+        "/feeds/blog.{format}": {
+            "get": {
+                "description": "The latest blog posts as Atom or JSON Feed. Content is the post's markdown.",
+                "produces": [
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Blog feed",
+                "parameters": [
                     {
+                        "enum": [
+                            "atom",
+                            "json"
+                        ],
                         "type": "string",
-                        "description": "Name of GPU (e.g. A100 SXM, RTX 4090)",
-                        "name": "name",
-                        "in": "query"
+                        "description": "atom or json",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Atom or JSON Feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feeds/deals.{format}": {
+            "get": {
+                "description": "Price drops of at least 10% on an offer's cheapest listing, and models listed for the first time,\nfrom the last week of scans, as Atom or JSON Feed.",
+                "produces": [
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Deals feed",
+                "parameters": [
                     {
+                        "enum": [
+                            "atom",
+                            "json"
+                        ],
                         "type": "string",
-                        "default": "updated_at.desc",
-                        "description": "Column.direction (e.g., updated_at.desc)",
-                        "name": "sort",
-                        "in": "query"
+                        "description": "atom or json",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Atom or JSON Feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feeds/models/{model}/deals.{format}": {
+            "get": {
+                "description": "The deals feed restricted to one GPU model.",
+                "produces": [
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Deals feed for a model",
+                "parameters": [
                     {
-                        "maximum": 1000,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 200,
-                        "description": "Limit (1-1000)",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "GPU model, e.g. h100-sxm",
+                        "name": "model",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Offset for pagination",
-                        "name": "offset",
-                        "in": "query"
+                        "enum": [
+                            "atom",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "atom or json",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Atom or JSON Feed document",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/cmd_api.GPU"
-                            }
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "404": {
+                        "description": "Unknown format or model",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "502": {
                         "description": "Upstream error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/gpus/count": {
+        "/feeds/providers/{source}/deals.{format}": {
             "get": {
-                "description": "Returns the total number of GPUs",
+                "description": "The deals feed restricted to one source.",
                 "produces": [
-                    "application/json"
+                    "application/atom+xml",
+                    "application/feed+json"
                 ],
                 "tags": [
-                    "gpus"
+                    "feeds"
                 ],
-                "summary": "Count number of GPUs",
+                "summary": "Deals feed for a provider",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Provider (e.g., vastai, tensordock, runpod)",
+                        "description": "Source, e.g. runpod",
                         "name": "source",
-                        "in": "query"
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "atom",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "atom or json",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Atom or JSON Feed document",
                         "schema": {
-                            "type": "number"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "404": {
+                        "description": "Unknown format",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "502": {
                         "description": "Upstream error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "cmd_api.GPU": {
-            "type": "object",
-            "properties": {
-                "cpu_arch": {
-                    "type": "string"
-                },
-                "cpu_cores": {
-                    "description": "CPU specs",
-                    "type": "number"
-                },
-                "cpu_ghz": {
+        },
+        "/gpus": {
+            "get": {
+                "description": "Returns a JSON array of GPU offers (read-only), served from an in-memory copy of the catalogue.\nWhen fit_model or fit_params is set, only offers whose vram_mb × num_gpus hold the\nestimated memory are returned (cheapest first unless sort is given) and the estimate is\nreported in the X-Required-Vram-Mb header.\nAll filters are combined with AND. Unknown parameters, unknown columns and malformed values\nreturn 400 with an ErrorResponse; limit is clamped to 1000.\nX-Total-Count carries the number of matching offers. When there are more, X-Next-Cursor and a\nLink rel=\"next\" header point at the next page of the same scan; cursors outlive a few scans and\nthen return 410.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "List GPUs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider, comma-separated for any of (e.g. vast,runpod)",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring match, comma-separated for any of",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Max total_cost_ph (alias of max_total_cost_ph)",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min flops_per_dollar_ph (alias of min_flops_per_dollar_ph)",
+                        "name": "min_flopsd",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GPU model or alias, comma-separated for any of (e.g. h100, A100 SXM, 4090)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CPU architecture, comma-separated for any of (x86_64/amd64, arm64/aarch64)",
+                        "name": "cpu_arch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min vram_mb per GPU. Every numeric column takes min_\u003ccolumn\u003e and max_\u003ccolumn\u003e",
+                        "name": "min_vram_mb",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Max vram_mb per GPU",
+                        "name": "max_vram_mb",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min num_gpus",
+                        "name": "min_num_gpus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Max num_gpus",
+                        "name": "max_num_gpus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min score",
+                        "name": "min_score",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min reliability (0-1)",
+                        "name": "min_reliability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min ram_mb",
+                        "name": "min_ram_mb",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min disk_space_gb",
+                        "name": "min_disk_space_gb",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min download_mbps",
+                        "name": "min_download_mbps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Min upload_mbps",
+                        "name": "min_upload_mbps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Boolean expression, e.g. (source:vast OR model:h100) AND vram_mb\u003e=81920 AND NOT cpu_arch:arm64. Operators : = != \u003c \u003c= \u003e \u003e= ~",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "updated_at.desc",
+                        "description": "Column.direction (e.g., updated_at.desc)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 200,
+                        "description": "Limit (1-1000, larger values are clamped)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque X-Next-Cursor from the previous page; replaces offset and keeps paging on the same scan",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Model preset to fit (e.g. llama-3-70b, mixtral-8x7b)",
+                        "name": "fit_model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Model size in billions of parameters (overrides preset)",
+                        "name": "fit_params",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Transformer layers (overrides preset)",
+                        "name": "fit_layers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Hidden size (overrides preset)",
+                        "name": "fit_hidden",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attention heads (overrides preset)",
+                        "name": "fit_heads",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "KV heads for grouped-query attention (overrides preset)",
+                        "name": "fit_kv_heads",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "fp16",
+                        "description": "Weight precision: fp32, fp16, bf16, fp8, int8, int4",
+                        "name": "fit_precision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "KV cache precision (defaults to weight precision, fp16 for int8/int4)",
+                        "name": "fit_kv_precision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Batch size",
+                        "name": "fit_batch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 4096,
+                        "description": "Context length in tokens",
+                        "name": "fit_context",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "inference",
+                        "description": "inference or training",
+                        "name": "fit_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Activation checkpointing (training only)",
+                        "name": "fit_checkpointing",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.GPU"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "rel=next URL for the next page"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor for the next page, absent on the last page"
+                            },
+                            "X-Snapshot-Version": {
+                                "type": "string",
+                                "description": "Scan the page was served from"
+                            },
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "Offers matching the filters"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Cursor expired",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/count": {
+            "get": {
+                "description": "Returns the number of GPU offers matching the same filters as /gpus.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Count number of GPUs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider, comma-separated for any of (e.g. vast,runpod)",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GPU model or alias, comma-separated for any of",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring match, comma-separated for any of",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Boolean expression, see /gpus",
+                        "name": "filter",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Count"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/export": {
+            "get": {
+                "description": "Downloads every offer matching the /gpus filters as CSV, newline-delimited JSON (streamed)\nor Parquet. cols takes the search page's column keys (vram_gb, upload_gbps, ...); all columns\nby default. The file name carries the scan time, e.g. gpus-20250814T2000Z.csv. limit is\noptional and not capped.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Export GPUs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "parquet"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "csv, ndjson or parquet",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated column keys, in output order",
+                        "name": "cols",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider, comma-separated for any of (e.g. vast,runpod)",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GPU model or alias, comma-separated for any of",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring match, comma-separated for any of",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Boolean expression, see /gpus",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "updated_at.desc",
+                        "description": "Column.direction (e.g., updated_at.desc)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Max rows",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/featured": {
+            "get": {
+                "description": "Labelled picks for the front page, each with the reason it was chosen. Strategies: top_score,\nstudent, hottest, best_value_per_tier, price_drop, new_high_end, reliable_cheap. price_drop and\nnew_high_end compare against the previous scan and return nothing until one is available.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Featured GPUs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated strategies, all by default",
+                        "name": "strategy",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Cards per strategy (per tier for best_value_per_tier)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.FeaturedCard"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/stream": {
+            "get": {
+                "description": "Server-Sent Events pushed after each scan: added, removed, price_up, price_down and availability,\nwith an OfferChange as data (see /scans/{id}/diff). Takes the /gpus filters (sort and paging are\nignored). Reconnects resume after the Last-Event-ID header or last_event_id parameter; when that is\ntoo old a reset event tells the client to reload /gpus. Comments are sent as heartbeats.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Stream offer changes (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resume after this change id (EventSource sends Last-Event-ID itself)",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sources",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GPU models",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location substrings",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Max total_cost_ph",
+                        "name": "max_price",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OfferChange"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/stream/ws": {
+            "get": {
+                "description": "The /gpus/stream feed over WebSocket. Each text message is {\"event\": ..., \"change\": OfferChange};\nevents are added, removed, price_up, price_down, availability, reset and heartbeat. Resume with last_event_id.\nMessages from the client are ignored.",
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Stream offer changes (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resume after this change id",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/{id}": {
+            "get": {
+                "description": "Returns the offer with this id from the current scan, or from the store if it has just dropped out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Get one GPU offer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Offer id from /gpus",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.GPU"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown offer",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gpus/{id}/history": {
+            "get": {
+                "description": "Time-bucketed total_cost_ph (min, median, p90) and availability for the offer with this id.\nOffer ids change every scan, so earlier sightings are matched on source, name, num_gpus and\nlocation. Buckets without scans are omitted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Price history of an offer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Offer id from /gpus",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Bucket size, e.g. 1h, 6h, 1d",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7d",
+                        "description": "How far back from to, e.g. 24h, 30d (max 90d)",
+                        "name": "range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start (RFC 3339), instead of range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "now",
+                        "description": "End (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PriceHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown offer",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/models/{model}/history": {
+            "get": {
+                "description": "Time-bucketed total_cost_ph (min, median, p90) across every offer of a canonical model, with\noffer and GPU counts per source and region. model is a name, slug or alias (h100-sxm, A100 PCIe, 4090);\none that matches several models, such as h100, is a 400 listing them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Price history of a GPU model",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GPU model",
+                        "name": "model",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this provider",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of location",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Bucket size, e.g. 1h, 6h, 1d",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7d",
+                        "description": "How far back from to, e.g. 24h, 30d (max 90d)",
+                        "name": "range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start (RFC 3339), instead of range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "now",
+                        "description": "End (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PriceHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or ambiguous model",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown model",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scans": {
+            "get": {
+                "description": "Scanner runs, newest first, with the number of changes of each type against the run before.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scans"
+                ],
+                "summary": "Recent scans",
+                "parameters": [
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 24,
+                        "description": "Max scans",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.ScanSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scans/{id}/diff": {
+            "get": {
+                "description": "Offers added, removed, price_up / price_down (delta in $/h and percent) and availability (listing\ncount) changes of a scan against the previous one. Offers are matched on source|name|num_gpus|location\nsince ids change every scan; prices are the cheapest listing of that key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scans"
+                ],
+                "summary": "What changed in a scan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scan id, or latest",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated: added, removed, price_up, price_down, availability",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sources",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GPU model, e.g. h100",
+                        "name": "model",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ScanDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown scan",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches": {
+            "post": {
+                "description": "Saves a /gpus filter set and notifies a webhook and/or email after each scan when offers start matching it:\ntrigger=below fires for offers whose total_cost_ph drops under max_total_cost_ph, trigger=new\nfor offers that were not there in the previous scan. Webhooks get a json AlertEvent or a\nSlack/Discord message, signed in X-Gpufindr-Signature (t=\u003cunix\u003e,v1=\u003chex HMAC-SHA256 of\n\"\u003ct\u003e.\u003cbody\u003e\" keyed by secret\u003e), retried with backoff on network errors, 408, 429 and 5xx.\nEmails go out at once or in an hourly/daily digest (email.digest) and carry an unsubscribe link,\nonce the address is confirmed through the link in the confirmation email sent here\n(email.confirmed). An address gets one confirmation a day while it goes unanswered, and a\nfew per client IP an hour. The token and secret are only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Save a search",
+                "parameters": [
+                    {
+                        "description": "Search",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.CreatedSearch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many confirmation emails to the address or from this client",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Get a saved search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003ctoken\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SavedSearch"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown search",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stops its alerts and drops its delivery log.",
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Delete a saved search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003ctoken\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown search",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches/{id}/deliveries": {
+            "get": {
+                "description": "Every webhook attempt, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Delivery log of a saved search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003ctoken\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Max attempts",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown search",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches/{id}/test": {
+            "post": {
+                "description": "Sends everything the search matches in the current scan to its webhook and email with\nevent=test, through the usual signing, retries and delivery log. Digests are bypassed; an\nunconfirmed email address gets nothing.",
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Send a test alert",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003ctoken\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown search",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "description": "Offer counts, GPUs available, min/median/max total_cost_ph and the best flops-per-dollar and\nscore-per-dollar offers, per canonical GPU model, per source and per region, with the change\nsince the previous scan. Computed once per scan.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gpus"
+                ],
+                "summary": "Market statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MarketStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Upstream error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/unsubscribe": {
+            "get": {
+                "description": "The page behind the link in every alert email. It only asks for confirmation, so mail\nscanners that follow links do not unsubscribe anyone.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Confirm unsubscribing from alert emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            },
+            "post": {
+                "description": "Removes the email channel of the search the token belongs to, and the search itself when\nit has no webhook. Also the RFC 8058 one-click target of List-Unsubscribe.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Unsubscribe from alert emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "502": {
+                        "description": "Bad Gateway"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "param": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CompareField": {
+            "type": "object",
+            "properties": {
+                "better": {
+                    "description": "higher or lower",
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "ratios": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "winners": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.CompareSubject": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "offer": {
+                    "$ref": "#/definitions/api.GPU"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ProviderRange"
+                    }
+                },
+                "spec": {
+                    "$ref": "#/definitions/gpumodel.Spec"
+                }
+            }
+        },
+        "api.Comparison": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.CompareField"
+                    }
+                },
+                "subjects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.CompareSubject"
+                    }
+                }
+            }
+        },
+        "api.Count": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.CreatedSearch": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "$ref": "#/definitions/api.EmailTarget"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_total_cost_ph": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is a /gpus query string, e.g. name=h100\u0026min_num_gpus=8. Paging and sort are dropped.",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "description": "below or new",
+                    "type": "string"
+                },
+                "webhook": {
+                    "$ref": "#/definitions/api.Webhook"
+                }
+            }
+        },
+        "api.Delivery": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "attempt": {
+                    "type": "integer"
+                },
+                "channel": {
+                    "description": "webhook or email",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "http_status": {
+                    "description": "HTTPStatus is the webhook's HTTP status, or the SMTP reply code for email.",
+                    "type": "integer"
+                },
+                "offers": {
+                    "type": "integer"
+                },
+                "search_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "delivered, retrying or failed",
+                    "type": "string"
+                }
+            }
+        },
+        "api.EmailTarget": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "digest": {
+                    "description": "none, hourly or daily",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/api.APIError"
+                }
+            }
+        },
+        "api.FeaturedCard": {
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "offer": {
+                    "$ref": "#/definitions/api.GPU"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "strategy": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.GPU": {
+            "type": "object",
+            "properties": {
+                "cpu_arch": {
+                    "type": "string"
+                },
+                "cpu_cores": {
+                    "description": "CPU specs",
+                    "type": "number"
+                },
+                "cpu_ghz": {
                     "type": "number"
                 },
                 "cpu_name": {
                     "type": "string"
                 },
-                "disk_bw_gbps": {
+                "disk_bw_gbps": {
+                    "type": "number"
+                },
+                "disk_cost_ph": {
+                    "type": "number"
+                },
+                "disk_name": {
+                    "type": "string"
+                },
+                "disk_space_gb": {
+                    "description": "SSD",
+                    "type": "number"
+                },
+                "download_cost_ph": {
+                    "type": "number"
+                },
+                "download_mbps": {
+                    "type": "number"
+                },
+                "duration_hours": {
+                    "type": "number"
+                },
+                "flops_per_dollar_ph": {
+                    "type": "number"
+                },
+                "gpu_cost_ph": {
+                    "type": "number"
+                },
+                "gpu_mem_bw_gbps": {
+                    "type": "number"
+                },
+                "id": {
+                    "description": "Instance details",
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "GPU details",
+                    "type": "string"
+                },
+                "num_gpus": {
+                    "type": "integer"
+                },
+                "ram_mb": {
+                    "description": "Ram",
+                    "type": "integer"
+                },
+                "reliability": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "score_dollar_ph": {
+                    "type": "number"
+                },
+                "source": {
+                    "description": "e.g., \"tensordock\", \"vast\", etc.",
+                    "type": "string"
+                },
+                "total_cost_ph": {
+                    "description": "Cost",
+                    "type": "number"
+                },
+                "total_flops": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "upload_cost_ph": {
+                    "type": "number"
+                },
+                "upload_mbps": {
+                    "description": "Internet",
+                    "type": "number"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "vram_mb": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.GroupStats": {
+            "type": "object",
+            "properties": {
+                "best_flops_per_dollar_id": {
+                    "type": "string"
+                },
+                "best_flops_per_dollar_ph": {
+                    "type": "number"
+                },
+                "best_score_dollar_id": {
+                    "type": "string"
+                },
+                "best_score_dollar_ph": {
+                    "type": "number"
+                },
+                "delta": {
+                    "description": "Delta is the change since the previous scan; absent when the group is new or there is none.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.StatsDelta"
+                        }
+                    ]
+                },
+                "gpus": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "max_cost_ph": {
+                    "type": "number"
+                },
+                "median_cost_ph": {
+                    "type": "number"
+                },
+                "min_cost_ph": {
+                    "type": "number"
+                },
+                "offers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.HistoryPoint": {
+            "type": "object",
+            "properties": {
+                "by_region": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "by_source": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "gpus": {
+                    "type": "integer"
+                },
+                "median_cost_ph": {
+                    "type": "number"
+                },
+                "min_cost_ph": {
+                    "type": "number"
+                },
+                "offers": {
+                    "type": "integer"
+                },
+                "p90_cost_ph": {
+                    "type": "number"
+                },
+                "scans": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MCPArgShape": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "string"
+                },
+                "calls": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.MCPSessionUsage": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "ended": {
+                    "type": "integer"
+                },
+                "max_seconds": {
                     "type": "number"
                 },
-                "disk_cost_ph": {
+                "mean_seconds": {
                     "type": "number"
+                }
+            }
+        },
+        "api.MCPToolUsage": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "integer"
                 },
-                "disk_name": {
-                    "type": "string"
+                "errors": {
+                    "type": "integer"
                 },
-                "disk_space_gb": {
-                    "description": "SSD",
+                "max_ms": {
                     "type": "number"
                 },
-                "download_cost_ph": {
+                "mean_ms": {
                     "type": "number"
                 },
-                "download_mbps": {
+                "name": {
+                    "type": "string"
+                },
+                "p50_ms": {
+                    "description": "P50Ms and P95Ms cover the latest 1024 calls.",
                     "type": "number"
                 },
-                "duration_hours": {
+                "p95_ms": {
                     "type": "number"
                 },
-                "flops_per_dollar_ph": {
+                "shapes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.MCPArgShape"
+                    }
+                }
+            }
+        },
+        "api.MCPUsage": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "description": "Clients are tool calls by client, when MCP authentication is on.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "prompts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "requests": {
+                    "description": "Requests and Errors are by JSON-RPC method, e.g. tools/call.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "resources": {
+                    "description": "Resources are reads by kind: offers, models, providers, scans or gpus.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "sessions": {
+                    "$ref": "#/definitions/api.MCPSessionUsage"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "tools": {
+                    "description": "Tools are the tools called, most called first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.MCPToolUsage"
+                    }
+                }
+            }
+        },
+        "api.MarketStats": {
+            "type": "object",
+            "properties": {
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.GroupStats"
+                    }
+                },
+                "previous_scanned_at": {
+                    "description": "PreviousScannedAt is the scan the deltas are against; absent until the API has seen two scans.",
+                    "type": "string"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.GroupStats"
+                    }
+                },
+                "scanned_at": {
+                    "type": "string"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.GroupStats"
+                    }
+                },
+                "total": {
+                    "$ref": "#/definitions/api.GroupStats"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.OfferChange": {
+            "type": "object",
+            "properties": {
+                "cost_ph": {
                     "type": "number"
                 },
-                "gpu_cost_ph": {
+                "delta": {
                     "type": "number"
                 },
-                "gpu_mem_bw_gbps": {
+                "delta_pct": {
                     "type": "number"
                 },
                 "id": {
-                    "description": "Instance details",
+                    "description": "ID orders changes and is the Last-Event-ID to resume after.",
                     "type": "string"
                 },
+                "key": {
+                    "type": "string"
+                },
+                "listings": {
+                    "type": "integer"
+                },
                 "location": {
                     "type": "string"
                 },
                 "name": {
-                    "description": "GPU details",
                     "type": "string"
                 },
                 "num_gpus": {
                     "type": "integer"
                 },
-                "ram_mb": {
-                    "description": "Ram",
-                    "type": "integer"
+                "offer": {
+                    "description": "Offer is the key's cheapest listing in the new scan, or in the old one for removed.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.GPU"
+                        }
+                    ]
                 },
-                "reliability": {
+                "prev_cost_ph": {
+                    "description": "Prices are the key's cheapest total_cost_ph; Delta is CostPH - PrevCostPH and DeltaPct\nthat relative to PrevCostPH, in percent.",
                     "type": "number"
                 },
-                "score": {
+                "prev_listings": {
+                    "type": "integer"
+                },
+                "scanned_at": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PriceHistory": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "offer": {
+                    "$ref": "#/definitions/api.GPU"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.HistoryPoint"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ProviderRange": {
+            "type": "object",
+            "properties": {
+                "max_per_gpu_ph": {
                     "type": "number"
                 },
-                "score_dollar_ph": {
+                "min_per_gpu_ph": {
                     "type": "number"
                 },
+                "offers": {
+                    "type": "integer"
+                },
                 "source": {
-                    "description": "e.g., \"tensordock\", \"vast\", etc.",
+                    "type": "string"
+                }
+            }
+        },
+        "api.SavedSearch": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "total_cost_ph": {
-                    "description": "Cost",
+                "email": {
+                    "$ref": "#/definitions/api.EmailTarget"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_total_cost_ph": {
                     "type": "number"
                 },
-                "total_flops": {
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is a /gpus query string, e.g. name=h100\u0026min_num_gpus=8. Paging and sort are dropped.",
+                    "type": "string"
+                },
+                "trigger": {
+                    "description": "below or new",
+                    "type": "string"
+                },
+                "webhook": {
+                    "$ref": "#/definitions/api.Webhook"
+                }
+            }
+        },
+        "api.ScanDiff": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ScanEvent"
+                    }
+                },
+                "scan": {
+                    "$ref": "#/definitions/api.ScanSummary"
+                }
+            }
+        },
+        "api.ScanEvent": {
+            "type": "object",
+            "properties": {
+                "cost_ph": {
                     "type": "number"
                 },
-                "updated_at": {
+                "delta": {
+                    "type": "number"
+                },
+                "delta_pct": {
+                    "type": "number"
+                },
+                "key": {
                     "type": "string"
                 },
-                "upload_cost_ph": {
+                "listings": {
+                    "type": "integer"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "new_model": {
+                    "description": "NewModel marks an added offer of a model no listing had in the previous scan.",
+                    "type": "boolean"
+                },
+                "num_gpus": {
+                    "type": "integer"
+                },
+                "offer_id": {
+                    "description": "OfferID is the key's cheapest listing in this scan, or in the previous one for removed.",
+                    "type": "string"
+                },
+                "prev_cost_ph": {
+                    "description": "Prices are the key's cheapest total_cost_ph; Delta is CostPH - PrevCostPH and DeltaPct\nthat relative to PrevCostPH, in percent.",
                     "type": "number"
                 },
-                "upload_mbps": {
-                    "description": "Internet",
+                "prev_listings": {
+                    "type": "integer"
+                },
+                "scan_id": {
+                    "type": "string"
+                },
+                "scanned_at": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ScanSummary": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "integer"
+                },
+                "availability": {
+                    "type": "integer"
+                },
+                "offers": {
+                    "type": "integer"
+                },
+                "previous_scanned_at": {
+                    "description": "PreviousScannedAt is the scan the diff is against; absent for the first one recorded.",
+                    "type": "string"
+                },
+                "price_down": {
+                    "type": "integer"
+                },
+                "price_up": {
+                    "type": "integer"
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "scan_id": {
+                    "type": "string"
+                },
+                "scanned_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SearchRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "$ref": "#/definitions/api.EmailTarget"
+                },
+                "max_total_cost_ph": {
                     "type": "number"
                 },
-                "vram_mb": {
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "type": "string"
+                },
+                "webhook": {
+                    "$ref": "#/definitions/api.Webhook"
+                }
+            }
+        },
+        "api.StatsDelta": {
+            "type": "object",
+            "properties": {
+                "gpus": {
+                    "type": "integer"
+                },
+                "median_cost_ph": {
+                    "type": "number"
+                },
+                "min_cost_ph": {
+                    "type": "number"
+                },
+                "offers": {
                     "type": "integer"
                 }
             }
+        },
+        "api.Webhook": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "description": "json, slack or discord",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "gpumodel.Spec": {
+            "type": "object",
+            "properties": {
+                "architecture": {
+                    "type": "string"
+                },
+                "fp32_tflops": {
+                    "type": "number"
+                },
+                "mem_bw_gbps": {
+                    "type": "number"
+                },
+                "vram_gb": {
+                    "type": "number"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,617 @@
+// internal/api/alerts.go
+package api
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// Triggers. below fires for offers under MaxTotalCostPH, new for any offer that starts matching.
+const (
+	triggerBelow = "below"
+	triggerNew   = "new"
+)
+
+// Delivery statuses.
+const (
+	deliveryDelivered = "delivered"
+	deliveryRetrying  = "retrying"
+	deliveryFailed    = "failed"
+)
+
+// Channels a saved search can notify.
+const (
+	channelWebhook = "webhook"
+	channelEmail   = "email"
+)
+
+// alertBackoff is the wait before each retry of a failed delivery.
+var alertBackoff = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute, 30 * time.Minute}
+
+// SavedSearch is a /gpus filter set that notifies a webhook, an email address or both when
+// offers start matching it.
+// swagger:model SavedSearch
+type SavedSearch struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Query is a /gpus query string, e.g. name=h100&min_num_gpus=8. Paging and sort are dropped.
+	Query          string       `json:"query"`
+	Trigger        string       `json:"trigger"` // below or new
+	MaxTotalCostPH float64      `json:"max_total_cost_ph,omitempty"`
+	Webhook        *Webhook     `json:"webhook,omitempty"`
+	Email          *EmailTarget `json:"email,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// CreatedSearch is returned once, on creation. Token manages the search; Secret verifies
+// X-Gpufindr-Signature and keys unsubscribe links. Neither can be read back.
+// swagger:model CreatedSearch
+type CreatedSearch struct {
+	SavedSearch
+	Token  string `json:"token"`
+	Secret string `json:"secret"`
+}
+
+// Delivery is one attempt to post an alert.
+// swagger:model Delivery
+type Delivery struct {
+	SearchID string    `json:"search_id"`
+	At       time.Time `json:"at"`
+	Event    string    `json:"event"`
+	Channel  string    `json:"channel"` // webhook or email
+	Attempt  int       `json:"attempt"`
+	Status   string    `json:"status"` // delivered, retrying or failed
+	// HTTPStatus is the webhook's HTTP status, or the SMTP reply code for email.
+	HTTPStatus int    `json:"http_status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Offers     int    `json:"offers"`
+}
+
+// Alerts evaluates saved searches against each new scan and delivers what fires.
+type Alerts struct {
+	store   AlertStore
+	client  *http.Client
+	backoff []time.Duration
+	mailer  *Mailer // nil when SMTP is not configured
+
+	// confirmIPs and confirmAddrs ration confirmation emails per client IP and per address.
+	confirmIPs   *sendLimiter
+	confirmAddrs *sendLimiter
+}
+
+// alerts is the process-wide alert engine, fed by refreshHandler.
+var alerts *Alerts
+
+func newAlerts(store AlertStore, mailer *Mailer) *Alerts {
+	return &Alerts{store: store, client: webhookClient, backoff: alertBackoff, mailer: mailer,
+		confirmIPs: newSendLimiter(confirmPerIP, time.Hour), confirmAddrs: newSendLimiter(confirmPerAddr, 24*time.Hour)}
+}
+
+// onRefresh evaluates every saved search against cur, the scan the scanner just announced.
+// It runs from the scanner's POST /catalogue/refresh only, so each scan is evaluated by one
+// instance, and claims the scan in the AlertStore once its diff has loaded so a retried refresh
+// does not deliver twice. The baseline is the previous scan as the scanner recorded it (scans
+// and scan_diffs), so a cold instance alerts like a warm one. Stores that record no scans, i.e.
+// a local CATALOGUE_FILE, compare with the scan this process loaded before instead.
+func (a *Alerts) onRefresh(ctx context.Context, cur *snapshot) {
+	scans, err := catalogue.store.LoadScans(ctx, 1)
+	if err != nil {
+		log.Printf("alerts: load scans: %v", err)
+		return
+	}
+	if len(scans) == 0 {
+		if prev := catalogue.Before(cur); prev != nil {
+			a.evaluate(ctx, cur, prev.gpus)
+		}
+		return
+	}
+	scan := scans[0]
+	if scan.PreviousScannedAt == nil {
+		return // the first recorded scan: everything would be new
+	}
+	// Claim only once the diff is in hand, so a failed load leaves the scan to the next refresh.
+	_, events, err := catalogue.store.LoadScanDiff(ctx, scan.ID)
+	if err != nil {
+		log.Printf("alerts: load diff of scan %s: %v", scan.ID, err)
+		return
+	}
+	if ok, err := a.store.ClaimScan(ctx, scan.ID); err != nil || !ok {
+		if err != nil {
+			log.Printf("alerts: claim scan %s: %v", scan.ID, err)
+		}
+		return
+	}
+	a.evaluate(ctx, cur, previousOffers(cur.gpus, events))
+}
+
+// previousOffers rebuilds the previous scan's offers from cur and the diff between them: keys the
+// diff marks added were not listed, and a key whose price moved had its cheapest listing at
+// prev_cost_ph. Removed keys are left out; they cannot fire.
+func previousOffers(cur []GPU, events []ScanEvent) []GPU {
+	added := map[string]bool{}
+	repriced := map[string]float64{}
+	for _, e := range events {
+		switch e.Type {
+		case scandiff.Added:
+			added[e.Key] = true
+		case scandiff.PriceUp, scandiff.PriceDown:
+			repriced[e.Key] = e.PrevCostPH
+		}
+	}
+	cheapest := map[string]int{} // key -> index into out of its cheapest listing
+	var out []GPU
+	for _, g := range cur {
+		k := offerKey(g)
+		if added[k] {
+			continue
+		}
+		if i, ok := cheapest[k]; !ok || g.TotalCostPH < out[i].TotalCostPH {
+			cheapest[k] = len(out)
+		}
+		out = append(out, g)
+	}
+	for k, i := range cheapest {
+		if cost, ok := repriced[k]; ok {
+			out[i].TotalCostPH = cost
+		}
+	}
+	return out
+}
+
+func (a *Alerts) evaluate(ctx context.Context, cur *snapshot, prev []GPU) {
+	searches, err := a.store.ListSearches(ctx)
+	if err != nil {
+		log.Printf("alerts: list searches: %v", err)
+		return
+	}
+	for _, s := range searches {
+		hit, err := s.matcher()
+		if err != nil {
+			log.Printf("alerts: %s: %v", s.ID, err)
+			continue
+		}
+		if fired := firing(hit, cur.gpus, prev); len(fired) > 0 {
+			a.notify(ctx, s, s.event("alert", cur, fired))
+		}
+	}
+}
+
+// notify sends ev on every channel of s. Emails go to confirmed addresses only; those of digest
+// searches are queued for the next digest instead, except tests.
+func (a *Alerts) notify(ctx context.Context, s storedSearch, ev AlertEvent) {
+	if s.Webhook != nil {
+		body, err := webhookBody(ev)
+		if err != nil {
+			log.Printf("alerts: %s: encode: %v", s.ID, err)
+		} else {
+			go a.deliver(ctx, s.ID, ev.Event, ev.Matches, channelWebhook, func(ctx context.Context) (int, error) {
+				return a.postWebhook(ctx, s, body)
+			})
+		}
+	}
+	if s.Email != nil && s.Email.Confirmed && a.mailer != nil {
+		if s.Email.Digest != digestNone && ev.Event != "test" {
+			if err := a.store.QueueDigest(ctx, s.ID, digestDue(s, time.Now()), ev); err != nil {
+				log.Printf("alerts: %s: queue digest: %v", s.ID, err)
+			}
+			return
+		}
+		go a.deliver(ctx, s.ID, ev.Event, ev.Matches, channelEmail, func(ctx context.Context) (int, error) {
+			return a.mailer.send(ctx, s, []AlertEvent{ev})
+		})
+	}
+}
+
+// deliveryError is a failed send that says whether trying again may help. Other errors from a
+// send are taken to be transient.
+type deliveryError struct {
+	msg   string
+	retry bool
+}
+
+func (e *deliveryError) Error() string { return e.msg }
+
+// deliver runs send until it succeeds, fails for good or runs out of retries, waiting
+// alertBackoff between attempts. Every attempt goes to the delivery log.
+func (a *Alerts) deliver(ctx context.Context, searchID, event string, offers int, channel string, send func(context.Context) (int, error)) {
+	for attempt := 1; ; attempt++ {
+		d := Delivery{SearchID: searchID, At: time.Now().UTC(), Event: event, Channel: channel, Attempt: attempt, Offers: offers}
+		status, err := send(ctx)
+		d.HTTPStatus = status
+		retry := true
+		var de *deliveryError
+		if errors.As(err, &de) {
+			retry = de.retry
+		}
+		switch {
+		case err == nil:
+			d.Status = deliveryDelivered
+		case !retry || attempt > len(a.backoff):
+			d.Status, d.Error = deliveryFailed, err.Error()
+		default:
+			d.Status, d.Error = deliveryRetrying, err.Error()
+		}
+		if err := a.store.LogDelivery(ctx, d); err != nil {
+			log.Printf("alerts: %s: log delivery: %v", searchID, err)
+		}
+		if d.Status != deliveryRetrying {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.backoff[attempt-1]):
+		}
+	}
+}
+
+// matcher returns the test an offer has to pass to count as a hit for s.
+func (s SavedSearch) matcher() (func(GPU) bool, error) {
+	v, err := url.ParseQuery(s.Query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := parseGPUQuery(v)
+	if err != nil {
+		return nil, err
+	}
+	if s.Trigger == triggerBelow {
+		return func(g GPU) bool { return g.TotalCostPH > 0 && g.TotalCostPH < s.MaxTotalCostPH && q.match(g) }, nil
+	}
+	return q.match, nil
+}
+
+// firing returns the hits in cur that were not hits in prev, by offerKey, so an alert fires when
+// an offer appears or crosses the threshold, not on every scan it stays there.
+func firing(hit func(GPU) bool, cur, prev []GPU) []GPU {
+	before := map[string]bool{}
+	for _, g := range prev {
+		if hit(g) {
+			before[offerKey(g)] = true
+		}
+	}
+	var out []GPU
+	for _, g := range cur {
+		if hit(g) && !before[offerKey(g)] {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// event builds the notification for offers, keeping the cheapest alertOffersShown.
+func (s SavedSearch) event(kind string, snap *snapshot, offers []GPU) AlertEvent {
+	slices.SortFunc(offers, func(a, b GPU) int {
+		if c := cmp.Compare(a.TotalCostPH, b.TotalCostPH); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return AlertEvent{
+		Event:     kind,
+		Search:    s,
+		ScannedAt: snap.scannedAt,
+		Matches:   len(offers),
+		Offers:    offers[:min(len(offers), alertOffersShown)],
+		URL:       siteURL + "/search.html?" + s.Query,
+	}
+}
+
+// SearchRequest is the body of POST /searches.
+// swagger:model SearchRequest
+type SearchRequest struct {
+	Name           string       `json:"name"`
+	Query          string       `json:"query"`
+	Trigger        string       `json:"trigger"`
+	MaxTotalCostPH float64      `json:"max_total_cost_ph"`
+	Webhook        *Webhook     `json:"webhook"`
+	Email          *EmailTarget `json:"email"`
+}
+
+// savedSearchParams are /gpus parameters a saved search does not keep.
+var savedSearchParams = append([]string{"sort"}, pageParams...)
+
+// validate checks r and returns the search it describes, with the query normalised. canMail says
+// whether the server can send email.
+func (r SearchRequest) validate(canMail bool) (SavedSearch, error) {
+	s := SavedSearch{Name: strings.TrimSpace(r.Name), Trigger: r.Trigger, MaxTotalCostPH: r.MaxTotalCostPH, Webhook: r.Webhook, Email: r.Email}
+	if s.Name == "" || len(s.Name) > 100 {
+		return s, badParam("name", "is required, up to 100 characters")
+	}
+	v, err := url.ParseQuery(strings.TrimPrefix(r.Query, "?"))
+	if err != nil {
+		return s, badParam("query", "not a query string: %v", err)
+	}
+	for _, p := range savedSearchParams {
+		v.Del(p)
+	}
+	if _, err := parseGPUQuery(v); err != nil {
+		return s, badParam("query", "%v", err)
+	}
+	s.Query = v.Encode()
+
+	switch s.Trigger {
+	case triggerBelow:
+		if s.MaxTotalCostPH <= 0 {
+			return s, badParam("max_total_cost_ph", "must be positive for the below trigger")
+		}
+	case triggerNew:
+		s.MaxTotalCostPH = 0
+	default:
+		return s, badParam("trigger", "must be below or new")
+	}
+
+	if s.Webhook == nil && s.Email == nil {
+		return s, badParam("webhook", "a webhook, an email or both are required")
+	}
+	if s.Webhook != nil {
+		if s.Webhook.Format == "" {
+			s.Webhook.Format = formatJSON
+		}
+		if !slices.Contains([]string{formatJSON, formatSlack, formatDiscord}, s.Webhook.Format) {
+			return s, badParam("webhook.format", "must be json, slack or discord")
+		}
+		u, err := url.Parse(s.Webhook.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return s, badParam("webhook.url", "must be an absolute http(s) URL")
+		}
+		// Names are checked again when delivering, against what they resolve to then.
+		ip := net.ParseIP(u.Hostname())
+		if !webhookAllowPrivate && ((ip != nil && !publicIP(ip)) || strings.EqualFold(u.Hostname(), "localhost")) {
+			return s, badParam("webhook.url", "must be a public address")
+		}
+	}
+	if s.Email != nil {
+		if !canMail {
+			return s, badParam("email", "email alerts are not configured on this server")
+		}
+		addr, err := mail.ParseAddress(s.Email.Address)
+		if err != nil {
+			return s, badParam("email.address", "%v", err)
+		}
+		s.Email.Address, s.Email.Confirmed = addr.Address, false
+		if s.Email.Digest == "" {
+			s.Email.Digest = digestNone
+		}
+		if _, ok := digestPeriods[s.Email.Digest]; !ok && s.Email.Digest != digestNone {
+			return s, badParam("email.digest", "must be none, hourly or daily")
+		}
+	}
+	return s, nil
+}
+
+// randomToken returns n random bytes, base64url encoded.
+func randomToken(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authorizedSearch loads the {id} search and checks the request's bearer token against it,
+// writing the error response when either fails.
+func authorizedSearch(w http.ResponseWriter, r *http.Request) (storedSearch, bool) {
+	s, err := alerts.store.GetSearch(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no saved search %q", chi.URLParam(r, "id")))
+		return s, false
+	} else if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return s, false
+	}
+	got := tokenHash(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if subtle.ConstantTimeCompare([]byte(got), []byte(s.TokenHash)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid search token"))
+		return s, false
+	}
+	return s, true
+}
+
+// createSearchHandler godoc
+// @Summary     Save a search
+// @Description Saves a /gpus filter set and notifies a webhook and/or email after each scan when offers start matching it:
+// @Description trigger=below fires for offers whose total_cost_ph drops under max_total_cost_ph, trigger=new
+// @Description for offers that were not there in the previous scan. Webhooks get a json AlertEvent or a
+// @Description Slack/Discord message, signed in X-Gpufindr-Signature (t=<unix>,v1=<hex HMAC-SHA256 of
+// @Description "<t>.<body>" keyed by secret>), retried with backoff on network errors, 408, 429 and 5xx.
+// @Description Emails go out at once or in an hourly/daily digest (email.digest) and carry an unsubscribe link,
+// @Description once the address is confirmed through the link in the confirmation email sent here
+// @Description (email.confirmed). An address gets one confirmation a day while it goes unanswered, and a
+// @Description few per client IP an hour. The token and secret are only returned here.
+// @Tags        alerts
+// @Accept      json
+// @Produce     json
+// @Param       search  body      SearchRequest  true  "Search"
+// @Success     201     {object}  CreatedSearch
+// @Failure     400     {object}  ErrorResponse  "Bad request"
+// @Failure     429     {object}  ErrorResponse  "Too many confirmation emails to the address or from this client"
+// @Failure     502     {object}  ErrorResponse  "Upstream error"
+// @Router      /searches [post]
+func createSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	s, err := req.validate(alerts.mailer != nil)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if s.Email != nil {
+		wait, err := alerts.admitConfirmation(r.Context(), clientIP(r), s.Email.Address)
+		switch {
+		case errors.Is(err, errConfirmPending):
+			writeError(w, http.StatusTooManyRequests, err)
+			return
+		case wait > 0:
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, err)
+			return
+		case err != nil:
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+	}
+	s.ID, s.CreatedAt = uuid.New().String(), time.Now().UTC()
+	out := CreatedSearch{SavedSearch: s, Token: randomToken(24), Secret: randomToken(24)}
+	stored := storedSearch{SavedSearch: s, Secret: out.Secret, TokenHash: tokenHash(out.Token)}
+	if err := alerts.store.CreateSearch(r.Context(), stored); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if s.Email != nil {
+		alerts.sendConfirmation(context.WithoutCancel(r.Context()), stored)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// getSearchHandler godoc
+// @Summary     Get a saved search
+// @Tags        alerts
+// @Produce     json
+// @Param       id             path    string  true  "Search id"
+// @Param       Authorization  header  string  true  "Bearer <token>"
+// @Success     200  {object} SavedSearch
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Failure     404  {object} ErrorResponse  "Unknown search"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /searches/{id} [get]
+func getSearchHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := authorizedSearch(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.SavedSearch)
+}
+
+// deleteSearchHandler godoc
+// @Summary     Delete a saved search
+// @Description Stops its alerts and drops its delivery log.
+// @Tags        alerts
+// @Param       id             path    string  true  "Search id"
+// @Param       Authorization  header  string  true  "Bearer <token>"
+// @Success     204
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Failure     404  {object} ErrorResponse  "Unknown search"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /searches/{id} [delete]
+func deleteSearchHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := authorizedSearch(w, r)
+	if !ok {
+		return
+	}
+	if err := alerts.store.DeleteSearch(r.Context(), s.ID); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deliveriesHandler godoc
+// @Summary     Delivery log of a saved search
+// @Description Every webhook attempt, newest first.
+// @Tags        alerts
+// @Produce     json
+// @Param       id             path    string  true   "Search id"
+// @Param       Authorization  header  string  true   "Bearer <token>"
+// @Param       limit          query   int     false  "Max attempts"  default(50) minimum(1) maximum(200)
+// @Success     200  {array}  Delivery
+// @Failure     400  {object} ErrorResponse  "Bad request"
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Failure     404  {object} ErrorResponse  "Unknown search"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /searches/{id}/deliveries [get]
+func deliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, paramSet("limit")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, _, err := parsePage(q, 50, deliveriesKept)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s, ok := authorizedSearch(w, r)
+	if !ok {
+		return
+	}
+	list, err := alerts.store.Deliveries(r.Context(), s.ID, limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(append([]Delivery{}, list...))
+}
+
+// testSearchHandler godoc
+// @Summary     Send a test alert
+// @Description Sends everything the search matches in the current scan to its webhook and email with
+// @Description event=test, through the usual signing, retries and delivery log. Digests are bypassed; an
+// @Description unconfirmed email address gets nothing.
+// @Tags        alerts
+// @Param       id             path    string  true  "Search id"
+// @Param       Authorization  header  string  true  "Bearer <token>"
+// @Success     202
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Failure     404  {object} ErrorResponse  "Unknown search"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /searches/{id}/test [post]
+func testSearchHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := authorizedSearch(w, r)
+	if !ok {
+		return
+	}
+	snap, err := catalogue.Snapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	hit, err := s.matcher()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var offers []GPU
+	for _, g := range snap.gpus {
+		if hit(g) {
+			offers = append(offers, g)
+		}
+	}
+	alerts.notify(context.Background(), s, s.event("test", snap, offers))
+	w.WriteHeader(http.StatusAccepted)
+}
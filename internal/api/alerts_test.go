@@ -0,0 +1,216 @@
+// internal/api/alerts_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// scanStore is a file store that also has the scanner's record of the latest scan.
+type scanStore struct {
+	fileStore
+	scan   ScanSummary
+	events []ScanEvent
+}
+
+func (s scanStore) LoadScans(ctx context.Context, limit int) ([]ScanSummary, error) {
+	return []ScanSummary{s.scan}, nil
+}
+
+func (s scanStore) LoadScanDiff(ctx context.Context, scanID string) (ScanSummary, []ScanEvent, error) {
+	if scanID != s.scan.ID {
+		return ScanSummary{}, nil, errNotFound
+	}
+	return s.scan, s.events, nil
+}
+
+// useScanStore points the package catalogue at gpus and the recorded diff events, loaded once
+// as on a cold instance. first marks the scan as the first one ever recorded.
+func useScanStore(t *testing.T, gpus []GPU, events []ScanEvent, first bool) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gpus.json")
+	writeOffers(t, path, gpus)
+	scan := ScanSummary{ID: "scan-2", ScannedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	if !first {
+		prev := scan.ScannedAt.Add(-time.Hour)
+		scan.PreviousScannedAt = &prev
+	}
+	old := catalogue
+	t.Cleanup(func() { catalogue = old })
+	catalogue = newCatalogue(scanStore{fileStore: fileStore{path: path}, scan: scan, events: events}, time.Hour)
+	if err := catalogue.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func event(typ string, g GPU, prevCost float64) ScanEvent {
+	return ScanEvent{ScanID: "scan-2", Event: scandiff.Event{Type: typ, Key: offerKey(g), PrevCostPH: prevCost, CostPH: g.TotalCostPH}}
+}
+
+func TestPreviousOffers(t *testing.T) {
+	gpus := testOffers()
+	gpus[0].TotalCostPH = 1.99
+	prev := previousOffers(gpus, []ScanEvent{event(scandiff.PriceDown, gpus[0], 2.49), event(scandiff.Added, gpus[8], 0)})
+	if len(prev) != len(gpus)-1 {
+		t.Fatalf("%d previous offers, want %d", len(prev), len(gpus)-1)
+	}
+	for _, g := range prev {
+		switch g.Id {
+		case "offer-00":
+			if g.TotalCostPH != 2.49 {
+				t.Errorf("repriced offer costs %v, want 2.49", g.TotalCostPH)
+			}
+		case "offer-08":
+			t.Error("added offer is in the previous scan")
+		}
+	}
+	if gpus[0].TotalCostPH != 1.99 {
+		t.Error("previousOffers changed the current scan")
+	}
+}
+
+func TestRefreshAlertsOnRecordedDiff(t *testing.T) {
+	gpus := testOffers()
+	gpus[0].TotalCostPH = 1.99 // runpod H100 drops under 2
+	events := []ScanEvent{event(scandiff.PriceDown, gpus[0], 2.49), event(scandiff.Added, gpus[8], 0)}
+	useScanStore(t, gpus, events, false)
+
+	rc := newReceiver(t, http.StatusOK)
+	a, store := testAlerts()
+	oldAlerts := alerts
+	t.Cleanup(func() { alerts = oldAlerts })
+	alerts = a
+	below := webhookSearch(rc.URL, formatJSON)
+	newL4 := webhookSearch(rc.URL, formatJSON)
+	newL4.ID, newL4.Query, newL4.Trigger, newL4.MaxTotalCostPH = "search-2", "name=l4", triggerNew, 0
+	quiet := webhookSearch(rc.URL, formatJSON)
+	quiet.ID, quiet.Query = "search-3", "name=a100" // A100s stay where they were
+	for _, s := range []storedSearch{below, newL4, quiet} {
+		_ = store.CreateSearch(context.Background(), s)
+	}
+
+	t.Setenv("REFRESH_TOKEN", "refresh-me")
+	refresh := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/catalogue/refresh", nil)
+		req.Header.Set("Authorization", "Bearer refresh-me")
+		refreshHandler(w, req)
+		return w.Code
+	}
+	// This instance never saw the previous scan; the recorded diff is the baseline.
+	if code := refresh(); code != http.StatusNoContent {
+		t.Fatalf("refresh: status %d", code)
+	}
+	waitDeliveries(t, store, below.ID, 1)
+	waitDeliveries(t, store, newL4.ID, 1)
+
+	// The scanner retrying, or a second instance, does not send the scan again.
+	if code := refresh(); code != http.StatusNoContent {
+		t.Fatalf("second refresh: status %d", code)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := rc.calls(); n != 2 {
+		t.Errorf("receiver got %d posts, want 2", n)
+	}
+	if log, _ := store.Deliveries(context.Background(), quiet.ID, 10); len(log) != 0 {
+		t.Errorf("unchanged search fired: %+v", log)
+	}
+}
+
+func TestRefreshSkipsFirstRecordedScan(t *testing.T) {
+	gpus := testOffers()
+	useScanStore(t, gpus, []ScanEvent{event(scandiff.Added, gpus[0], 0)}, true)
+	rc := newReceiver(t, http.StatusOK)
+	a, store := testAlerts()
+	s := webhookSearch(rc.URL, formatJSON)
+	s.Trigger, s.MaxTotalCostPH = triggerNew, 0
+	_ = store.CreateSearch(context.Background(), s)
+
+	snap, _ := catalogue.Snapshot(context.Background())
+	a.onRefresh(context.Background(), snap)
+	time.Sleep(50 * time.Millisecond)
+	if rc.calls() != 0 {
+		t.Errorf("the first recorded scan fired %d alerts", rc.calls())
+	}
+}
+
+func TestSupabaseListSearchesPages(t *testing.T) {
+	const total = 2*supabasePage + 5
+	var pages int
+	pg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		off, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if r.URL.Path != "/rest/v1/saved_searches" || limit != supabasePage {
+			t.Errorf("GET %s", r.URL)
+		}
+		rows := []storedSearch{}
+		for i := off; i < min(off+limit, total); i++ {
+			rows = append(rows, storedSearch{SavedSearch: SavedSearch{ID: fmt.Sprintf("search-%d", i)}})
+		}
+		_ = json.NewEncoder(w).Encode(rows)
+	}))
+	t.Cleanup(pg.Close)
+	old := supabaseURL
+	t.Cleanup(func() { supabaseURL = old })
+	supabaseURL = pg.URL
+
+	list, err := supabaseAlerts{key: "service"}.ListSearches(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != total || list[total-1].ID != fmt.Sprintf("search-%d", total-1) || pages != 3 {
+		t.Errorf("%d searches in %d pages, want %d in 3", len(list), pages, total)
+	}
+}
+
+// flakyDiffStore fails the first failures loads of a scan diff.
+type flakyDiffStore struct {
+	scanStore
+	failures *int
+}
+
+func (s flakyDiffStore) LoadScanDiff(ctx context.Context, scanID string) (ScanSummary, []ScanEvent, error) {
+	if *s.failures > 0 {
+		*s.failures--
+		return ScanSummary{}, nil, errors.New("connection reset")
+	}
+	return s.scanStore.LoadScanDiff(ctx, scanID)
+}
+
+func TestRefreshRetriesScanAfterFailedDiff(t *testing.T) {
+	gpus := testOffers()
+	gpus[0].TotalCostPH = 1.99
+	useScanStore(t, gpus, []ScanEvent{event(scandiff.PriceDown, gpus[0], 2.49)}, false)
+	failures := 1
+	catalogue.store = flakyDiffStore{scanStore: catalogue.store.(scanStore), failures: &failures}
+
+	rc := newReceiver(t, http.StatusOK)
+	a, store := testAlerts()
+	s := webhookSearch(rc.URL, formatJSON)
+	_ = store.CreateSearch(context.Background(), s)
+	snap, _ := catalogue.Snapshot(context.Background())
+
+	a.onRefresh(context.Background(), snap)
+	time.Sleep(50 * time.Millisecond)
+	if rc.calls() != 0 {
+		t.Fatal("alerts went out without the diff")
+	}
+	a.onRefresh(context.Background(), snap)
+	waitDeliveries(t, store, s.ID, 1)
+	a.onRefresh(context.Background(), snap)
+	time.Sleep(50 * time.Millisecond)
+	if n := rc.calls(); n != 1 {
+		t.Errorf("receiver got %d posts, want 1", n)
+	}
+}
@@ -0,0 +1,357 @@
+// internal/api/alertstore.go
+package api
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storedSearch is a saved search with the credentials that never leave the store.
+type storedSearch struct {
+	SavedSearch
+	Secret    string `json:"secret"`     // HMAC key for webhook signatures and unsubscribe tokens
+	TokenHash string `json:"token_hash"` // sha256 of the management token
+}
+
+// AlertStore keeps saved searches and their delivery log.
+type AlertStore interface {
+	ListSearches(ctx context.Context) ([]storedSearch, error)
+	// GetSearch returns one search, or errNotFound.
+	GetSearch(ctx context.Context, id string) (storedSearch, error)
+	CreateSearch(ctx context.Context, s storedSearch) error
+	// UpdateSearch replaces the search with s's id.
+	UpdateSearch(ctx context.Context, s storedSearch) error
+	// DeleteSearch removes a search and its deliveries.
+	DeleteSearch(ctx context.Context, id string) error
+	LogDelivery(ctx context.Context, d Delivery) error
+	// Deliveries returns the latest attempts for a search, newest first.
+	Deliveries(ctx context.Context, searchID string, limit int) ([]Delivery, error)
+	// ClaimScan records that a scan's alerts are being sent and reports whether it was the
+	// first to, so a scan is evaluated once however often it is announced.
+	ClaimScan(ctx context.Context, scanID string) (bool, error)
+	// QueueDigest keeps ev for the search's email digest going out at due.
+	QueueDigest(ctx context.Context, searchID string, due time.Time, ev AlertEvent) error
+	// TakeDigests removes the events of the digests due by now and returns them by search id,
+	// oldest first. Each event is taken once, however many instances ask.
+	TakeDigests(ctx context.Context, now time.Time) (map[string][]AlertEvent, error)
+	// PendingConfirmation reports whether a search created since since is still waiting for
+	// address to be confirmed.
+	PendingConfirmation(ctx context.Context, address string, since time.Time) (bool, error)
+}
+
+// newAlertStoreFromEnv uses Supabase when SUPABASE_SERVICE_KEY is set; the saved_searches and
+// alert_deliveries tables are not readable with the anon key. Otherwise searches live in memory,
+// which is only for local runs: on Cloud Run (K_SERVICE set) against Supabase a missing key is
+// fatal, as every saved search would be lost with the instance.
+func newAlertStoreFromEnv() AlertStore {
+	if key := strings.TrimSpace(os.Getenv("SUPABASE_SERVICE_KEY")); key != "" && os.Getenv("CATALOGUE_FILE") == "" {
+		return supabaseAlerts{key: key}
+	}
+	if os.Getenv("K_SERVICE") != "" && os.Getenv("CATALOGUE_FILE") == "" {
+		log.Fatal("alerts: SUPABASE_SERVICE_KEY is required when deployed; refusing to keep saved searches in memory")
+	}
+	log.Println("alerts: WARNING: SUPABASE_SERVICE_KEY not set, saved searches are kept in memory and lost on restart")
+	return newMemoryAlerts()
+}
+
+// supabaseAlerts stores searches in PostgREST with the service key.
+type supabaseAlerts struct {
+	key string
+}
+
+// do runs a PostgREST request against table and decodes the response into out when non-nil.
+func (s supabaseAlerts) do(ctx context.Context, method, table string, v url.Values, body, out any) error {
+	return s.doPrefer(ctx, method, table, "", v, body, out)
+}
+
+// doPrefer is do with a Prefer header.
+func (s supabaseAlerts) doPrefer(ctx context.Context, method, table, prefer string, v url.Values, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, _ := http.NewRequestWithContext(ctx, method, supabaseURL+"/rest/v1/"+table+"?"+v.Encode(), rd)
+	req.Header.Set("apikey", s.key)
+	req.Header.Set("Authorization", "Bearer "+s.key)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+
+	resp, err := httpc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, string(b))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ListSearches pages through saved_searches, which can outgrow PostgREST's row cap.
+func (s supabaseAlerts) ListSearches(ctx context.Context) ([]storedSearch, error) {
+	var all []storedSearch
+	for off := 0; ; off += supabasePage {
+		v := url.Values{}
+		v.Set("select", "*")
+		v.Set("order", "created_at.asc,id.asc")
+		v.Set("limit", strconv.Itoa(supabasePage))
+		v.Set("offset", strconv.Itoa(off))
+		var page []storedSearch
+		if err := s.do(ctx, "GET", "saved_searches", v, nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < supabasePage {
+			return all, nil
+		}
+	}
+}
+
+func (s supabaseAlerts) GetSearch(ctx context.Context, id string) (storedSearch, error) {
+	v := url.Values{}
+	v.Set("select", "*")
+	v.Set("id", "eq."+id)
+	var list []storedSearch
+	if err := s.do(ctx, "GET", "saved_searches", v, nil, &list); err != nil {
+		return storedSearch{}, err
+	}
+	if len(list) == 0 {
+		return storedSearch{}, errNotFound
+	}
+	return list[0], nil
+}
+
+func (s supabaseAlerts) CreateSearch(ctx context.Context, ss storedSearch) error {
+	return s.do(ctx, "POST", "saved_searches", url.Values{}, ss, nil)
+}
+
+func (s supabaseAlerts) UpdateSearch(ctx context.Context, ss storedSearch) error {
+	b, err := json.Marshal(ss)
+	if err != nil {
+		return err
+	}
+	var row map[string]any
+	if err := json.Unmarshal(b, &row); err != nil {
+		return err
+	}
+	// Channels are omitempty; a removed one has to be written as null.
+	row["webhook"], row["email"] = ss.Webhook, ss.Email
+
+	v := url.Values{}
+	v.Set("id", "eq."+ss.ID)
+	return s.do(ctx, "PATCH", "saved_searches", v, row, nil)
+}
+
+// DeleteSearch relies on alert_deliveries cascading.
+func (s supabaseAlerts) DeleteSearch(ctx context.Context, id string) error {
+	v := url.Values{}
+	v.Set("id", "eq."+id)
+	return s.do(ctx, "DELETE", "saved_searches", v, nil, nil)
+}
+
+func (s supabaseAlerts) LogDelivery(ctx context.Context, d Delivery) error {
+	return s.do(ctx, "POST", "alert_deliveries", url.Values{}, d, nil)
+}
+
+func (s supabaseAlerts) Deliveries(ctx context.Context, searchID string, limit int) ([]Delivery, error) {
+	v := url.Values{}
+	v.Set("select", "*")
+	v.Set("search_id", "eq."+searchID)
+	v.Set("order", "at.desc")
+	v.Set("limit", strconv.Itoa(limit))
+	var list []Delivery
+	return list, s.do(ctx, "GET", "alert_deliveries", v, nil, &list)
+}
+
+// ClaimScan inserts into alert_scans, whose primary key turns a second claim into no row.
+func (s supabaseAlerts) ClaimScan(ctx context.Context, scanID string) (bool, error) {
+	var rows []map[string]any
+	err := s.doPrefer(ctx, "POST", "alert_scans", "resolution=ignore-duplicates,return=representation",
+		url.Values{}, map[string]string{"scan_id": scanID}, &rows)
+	return len(rows) > 0, err
+}
+
+// digestRow is a queued event in alert_digests.
+type digestRow struct {
+	ID       int64      `json:"id,omitempty"`
+	SearchID string     `json:"search_id"`
+	Due      time.Time  `json:"due"`
+	Event    AlertEvent `json:"event"`
+}
+
+func (s supabaseAlerts) QueueDigest(ctx context.Context, searchID string, due time.Time, ev AlertEvent) error {
+	return s.do(ctx, "POST", "alert_digests", url.Values{}, digestRow{SearchID: searchID, Due: due, Event: ev}, nil)
+}
+
+// TakeDigests deletes the due rows and reads them from the same statement, so two instances
+// cannot both take one.
+func (s supabaseAlerts) TakeDigests(ctx context.Context, now time.Time) (map[string][]AlertEvent, error) {
+	v := url.Values{}
+	v.Set("due", "lte."+now.UTC().Format(time.RFC3339))
+	var rows []digestRow
+	if err := s.doPrefer(ctx, "DELETE", "alert_digests", "return=representation", v, nil, &rows); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(rows, func(a, b digestRow) int { return cmp.Compare(a.ID, b.ID) })
+	out := map[string][]AlertEvent{}
+	for _, r := range rows {
+		out[r.SearchID] = append(out[r.SearchID], r.Event)
+	}
+	return out, nil
+}
+
+func (s supabaseAlerts) PendingConfirmation(ctx context.Context, address string, since time.Time) (bool, error) {
+	v := url.Values{}
+	v.Set("select", "id")
+	v.Set("email->>address", "eq."+address)
+	v.Set("email->>confirmed", "eq.false")
+	v.Set("created_at", "gte."+since.UTC().Format(time.RFC3339))
+	v.Set("limit", "1")
+	var rows []map[string]any
+	if err := s.do(ctx, "GET", "saved_searches", v, nil, &rows); err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
+
+// deliveriesKept caps the in-memory delivery log per search.
+const deliveriesKept = 200
+
+// memoryAlerts is the AlertStore for local runs. Everything is lost on restart.
+type memoryAlerts struct {
+	mu         sync.Mutex
+	searches   []storedSearch
+	deliveries map[string][]Delivery // search id -> oldest first
+	claimed    map[string]bool       // scan ids
+	digests    []digestRow           // queued oldest first
+}
+
+func newMemoryAlerts() *memoryAlerts {
+	return &memoryAlerts{deliveries: map[string][]Delivery{}, claimed: map[string]bool{}}
+}
+
+func (m *memoryAlerts) ListSearches(ctx context.Context) ([]storedSearch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.searches), nil
+}
+
+func (m *memoryAlerts) GetSearch(ctx context.Context, id string) (storedSearch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.searches {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return storedSearch{}, errNotFound
+}
+
+func (m *memoryAlerts) CreateSearch(ctx context.Context, s storedSearch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searches = append(m.searches, s)
+	return nil
+}
+
+func (m *memoryAlerts) UpdateSearch(ctx context.Context, s storedSearch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.searches {
+		if m.searches[i].ID == s.ID {
+			m.searches[i] = s
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (m *memoryAlerts) DeleteSearch(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searches = slices.DeleteFunc(m.searches, func(s storedSearch) bool { return s.ID == id })
+	delete(m.deliveries, id)
+	return nil
+}
+
+func (m *memoryAlerts) LogDelivery(ctx context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	log := append(m.deliveries[d.SearchID], d)
+	m.deliveries[d.SearchID] = log[max(0, len(log)-deliveriesKept):]
+	return nil
+}
+
+func (m *memoryAlerts) Deliveries(ctx context.Context, searchID string, limit int) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	log := m.deliveries[searchID]
+	out := make([]Delivery, 0, min(limit, len(log)))
+	for i := len(log) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, log[i])
+	}
+	return out, nil
+}
+
+func (m *memoryAlerts) ClaimScan(ctx context.Context, scanID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.claimed[scanID] {
+		return false, nil
+	}
+	m.claimed[scanID] = true
+	return true, nil
+}
+
+func (m *memoryAlerts) QueueDigest(ctx context.Context, searchID string, due time.Time, ev AlertEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.digests = append(m.digests, digestRow{SearchID: searchID, Due: due, Event: ev})
+	return nil
+}
+
+func (m *memoryAlerts) TakeDigests(ctx context.Context, now time.Time) (map[string][]AlertEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := map[string][]AlertEvent{}
+	m.digests = slices.DeleteFunc(m.digests, func(d digestRow) bool {
+		if now.Before(d.Due) {
+			return false
+		}
+		out[d.SearchID] = append(out[d.SearchID], d.Event)
+		return true
+	})
+	return out, nil
+}
+
+func (m *memoryAlerts) PendingConfirmation(ctx context.Context, address string, since time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.ContainsFunc(m.searches, func(s storedSearch) bool {
+		return s.Email != nil && s.Email.Address == address && !s.Email.Confirmed && !s.CreatedAt.Before(since)
+	}), nil
+}
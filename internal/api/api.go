@@ -0,0 +1,34 @@
+// internal/api/api.go
+
+// Package api is the gpufindr catalogue: the offers kept in memory, the REST API over them and
+// the MCP server. cmd/api serves all of it over HTTP; cmd/gpufindr-mcp serves MCP over stdio.
+package api
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Setup loads the catalogue from st, reloading it every CATALOGUE_REFRESH (default 5m).
+func Setup(st Store) {
+	refreshEvery := 5 * time.Minute
+	if d, err := time.ParseDuration(os.Getenv("CATALOGUE_REFRESH")); err == nil && d > 0 {
+		refreshEvery = d
+	}
+	catalogue = newCatalogue(st, refreshEvery)
+}
+
+// StartNotifications checks saved searches against every scan the scanner announces on
+// /catalogue/refresh, sends their email digests and feeds /gpus/stream, until ctx is done.
+func StartNotifications(ctx context.Context) {
+	alerts = newAlerts(newAlertStoreFromEnv(), newMailerFromEnv())
+	catalogue.OnScan(changes.onScan)
+	go alerts.runDigests(ctx)
+}
+
+// Run loads the catalogue and keeps it fresh until ctx is done. Register every OnScan hook,
+// i.e. call NewMCPServer and StartNotifications, first so none misses the first load.
+func Run(ctx context.Context) {
+	catalogue.Run(ctx)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Gets all blog posts and returns them
+func blogHandler(w http.ResponseWriter, r *http.Request) {
+	base := os.Getenv("SUPABASE_URL")
+	key := os.Getenv("SUPABASE_ANON_KEY")
+	if base == "" || key == "" {
+		writeError(w, http.StatusInternalServerError, errors.New("server not configured: SUPABASE_URL/SUPABASE_ANON_KEY missing"))
+		return
+	}
+
+	q := r.URL.Query()
+	if err := checkParams(q, paramSet("slug", "limit", "offset")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, offset, err := parsePage(q, 100, maxLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	v := url.Values{}
+	v.Set("select", "title,data,created_at")
+	// Adjust to your schema; if you track publish dates:
+	v.Set("order", "created_at.desc")
+
+	// If a specific post is requested:
+	if slug := q.Get("slug"); slug != "" {
+		if !validSlug(slug) {
+			writeError(w, http.StatusBadRequest, badParam("slug", "must be lowercase letters, digits and dashes"))
+			return
+		}
+		v.Set("slug", "eq."+slug)
+		v.Set("limit", "1")
+	} else {
+		// Otherwise list posts with pagination
+		v.Set("limit", strconv.Itoa(limit))
+		if offset > 0 {
+			v.Set("offset", strconv.Itoa(offset))
+		}
+		// If you have a boolean `published` column and don’t want drafts:
+		// v.Set("published", "eq.true")
+	}
+
+	resp, err := blogRequest(r.Context(), v)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// blogRequest queries the blogs table. Non-2xx responses are turned into errors.
+func blogRequest(ctx context.Context, v url.Values) (*http.Response, error) {
+	base := os.Getenv("SUPABASE_URL")
+	key := os.Getenv("SUPABASE_ANON_KEY")
+	endpoint := fmt.Sprintf("%s/rest/v1/blogs?%s", strings.TrimRight(base, "/"), v.Encode())
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	req.Header.Set("apikey", key)
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("upstream %s: %s", resp.Status, string(b))
+	}
+	return resp, nil
+}
+
+// BlogPost is a row of the blogs table. Data is the post in markdown.
+type BlogPost struct {
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	Data      string    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// loadPosts returns the latest posts, newest first.
+func loadPosts(ctx context.Context, limit int) ([]BlogPost, error) {
+	v := url.Values{}
+	v.Set("select", "*") // slug is optional in older schemas
+	v.Set("order", "created_at.desc")
+	v.Set("limit", strconv.Itoa(limit))
+	resp, err := blogRequest(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var posts []BlogPost
+	return posts, json.NewDecoder(resp.Body).Decode(&posts)
+}
+
+func validSlug(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,268 @@
+// internal/api/catalogue.go
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Catalogue is the in-memory copy of the gpus table. Readers get an immutable
+// snapshot; refreshes build a new one and swap it in.
+type Catalogue struct {
+	store Store
+	every time.Duration
+
+	mu      sync.RWMutex
+	snap    *snapshot
+	recent  []*snapshot // previous scans, oldest first, still reachable by cursors
+	refresh sync.Mutex  // serialises loads
+
+	onScan []func(cur, prev *snapshot)
+}
+
+// snapshotsKept is how many scans a pagination cursor can outlive its snapshot by.
+const snapshotsKept = 3
+
+// catalogue is the process-wide cache used by the REST handlers and MCP tools.
+var catalogue *Catalogue
+
+func newCatalogue(store Store, every time.Duration) *Catalogue {
+	return &Catalogue{store: store, every: every}
+}
+
+// Run loads the catalogue and keeps it fresh until ctx is done.
+func (c *Catalogue) Run(ctx context.Context) {
+	if err := c.Refresh(ctx); err != nil {
+		log.Printf("catalogue: initial load failed: %v", err)
+	}
+	t := time.NewTicker(c.every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := c.Refresh(ctx); err != nil {
+				log.Printf("catalogue: refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh reloads every offer from the store.
+func (c *Catalogue) Refresh(ctx context.Context) error {
+	c.refresh.Lock()
+	defer c.refresh.Unlock()
+
+	gpus, err := c.store.LoadGPUs(ctx)
+	if err != nil {
+		return err
+	}
+	s := newSnapshot(gpus)
+	c.mu.Lock()
+	prev := c.snap
+	changed := prev == nil || prev.version != s.version
+	if prev != nil && changed {
+		c.recent = append(c.recent, c.snap)
+		if len(c.recent) > snapshotsKept {
+			c.recent = c.recent[len(c.recent)-snapshotsKept:]
+		}
+	}
+	c.snap = s
+	hooks := c.onScan
+	c.mu.Unlock()
+	log.Printf("catalogue: loaded %d offers (version %s)", len(gpus), s.version)
+	if changed {
+		for _, f := range hooks {
+			f(s, prev)
+		}
+	}
+	return nil
+}
+
+// OnScan registers f to run after every load that brings in a new scan, in load order. prev is
+// the scan it replaced, nil on the first load. f runs on the refresh path and must not block.
+func (c *Catalogue) OnScan(f func(cur, prev *snapshot)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onScan = append(c.onScan, f)
+}
+
+// Snapshot returns the current snapshot, loading it from the store on a cold cache.
+func (c *Catalogue) Snapshot(ctx context.Context) (*snapshot, error) {
+	c.mu.RLock()
+	s := c.snap
+	c.mu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+	if err := c.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snap, nil
+}
+
+// At returns the current or a retained earlier snapshot with the given version.
+func (c *Catalogue) At(version string) (*snapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.snap != nil && c.snap.version == version {
+		return c.snap, true
+	}
+	for _, s := range c.recent {
+		if s.version == version {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Before returns the retained snapshot of the scan before s, or nil. s need not be current: a
+// reader holding a snapshot across a refresh still gets the scan s replaced, not s itself.
+func (c *Catalogue) Before(s *snapshot) *snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+	if c.snap != nil && c.snap.version == s.version {
+		if len(c.recent) == 0 {
+			return nil
+		}
+		return c.recent[len(c.recent)-1]
+	}
+	for i := len(c.recent) - 1; i > 0; i-- {
+		if c.recent[i].version == s.version {
+			return c.recent[i-1]
+		}
+	}
+	return nil
+}
+
+// Get looks an offer up by id, asking the store when the cache does not have it.
+func (c *Catalogue) Get(ctx context.Context, id string) (GPU, error) {
+	if s, err := c.Snapshot(ctx); err == nil {
+		if i, ok := s.byID[id]; ok {
+			return s.gpus[i], nil
+		}
+	}
+	g, err := c.store.GetGPU(ctx, id)
+	if err != nil && !errors.Is(err, errNotFound) {
+		log.Printf("catalogue: store lookup %s failed: %v", id, err)
+	}
+	return g, err
+}
+
+// snapshot is an immutable, indexed view of one load of the catalogue.
+type snapshot struct {
+	gpus     []GPU
+	version  string
+	loadedAt time.Time
+	// scannedAt is the newest updated_at in the set, i.e. when the scan ran.
+	scannedAt time.Time
+
+	byID     map[string]int
+	bySource map[string][]int // lower-cased source
+
+	sortMu sync.Mutex
+	sorted map[string][]int // column -> row indexes in ascending order, built lazily
+
+	statsOnce  sync.Once
+	statsCache *MarketStats
+}
+
+func newSnapshot(gpus []GPU) *snapshot {
+	s := &snapshot{
+		gpus:     gpus,
+		loadedAt: time.Now().UTC(),
+		byID:     make(map[string]int, len(gpus)),
+		bySource: map[string][]int{},
+		sorted:   map[string][]int{},
+	}
+	ids := make([]string, 0, len(gpus))
+	for i, g := range gpus {
+		s.byID[g.Id] = i
+		src := strings.ToLower(g.Source)
+		s.bySource[src] = append(s.bySource[src], i)
+		if g.UpdatedAt.After(s.scannedAt) {
+			s.scannedAt = g.UpdatedAt
+		}
+		ids = append(ids, g.Id)
+	}
+	// Offer ids are minted per scan, so hashing them identifies the data set.
+	sort.Strings(ids)
+	h := fnv.New64a()
+	for _, id := range ids {
+		h.Write([]byte(id))
+	}
+	s.version = strconv.FormatUint(h.Sum64(), 36)
+	return s
+}
+
+// sortedBy returns row indexes ordered ascending by col, ties broken by id, building the
+// index on first use.
+func (s *snapshot) sortedBy(col column) []int {
+	s.sortMu.Lock()
+	defer s.sortMu.Unlock()
+	if idx, ok := s.sorted[col.name]; ok {
+		return idx
+	}
+	idx := make([]int, len(s.gpus))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		ga, gb := s.gpus[idx[a]], s.gpus[idx[b]]
+		if c := col.compare(ga, gb); c != 0 {
+			return c < 0
+		}
+		return ga.Id < gb.Id
+	})
+	s.sorted[col.name] = idx
+	return idx
+}
+
+// refreshHandler godoc
+// @Summary     Reload the catalogue cache
+// @Description Called by the scanner once a scan has been written so the API serves it straight away, and
+// @Description evaluates saved searches against it.
+// @Description Requires Authorization: Bearer $REFRESH_TOKEN; disabled when REFRESH_TOKEN is unset.
+// @Tags        admin
+// @Success     204
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /catalogue/refresh [post]
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	token := os.Getenv("REFRESH_TOKEN")
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid refresh token"))
+		return
+	}
+	if err := catalogue.Refresh(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if alerts != nil {
+		snap, err := catalogue.Snapshot(r.Context())
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		// Deliveries and their retries outlive the request.
+		alerts.onRefresh(context.WithoutCancel(r.Context()), snap)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
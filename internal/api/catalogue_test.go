@@ -0,0 +1,140 @@
+// internal/api/catalogue_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testOffers is a small catalogue: two of each model across three providers, priced so that
+// total_cost_ph orders them deterministically.
+func testOffers() []GPU {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	specs := []struct {
+		name    string
+		vramMB  int
+		price   float64
+		sources []string
+	}{
+		{"H100 SXM5 80GB", 81920, 2.49, []string{"runpod", "lambda"}},
+		{"A100 SXM4 80GB", 81920, 1.29, []string{"vast", "runpod"}},
+		{"A10", 24576, 0.60, []string{"lambda", "vast"}},
+		{"RTX 4090", 24576, 0.35, []string{"vast", "runpod"}},
+		{"L4", 24576, 0.44, []string{"runpod", "lambda"}},
+		{"L40S", 49152, 0.99, []string{"lambda", "vast"}},
+	}
+	var out []GPU
+	for i, s := range specs {
+		for j, src := range s.sources {
+			price := s.price + float64(j)*0.1
+			out = append(out, GPU{
+				Id:          fmt.Sprintf("offer-%02d", 2*i+j),
+				Name:        s.name,
+				Source:      src,
+				Location:    []string{"us-east-1", "eu-west-1"}[j],
+				Vram:        s.vramMB,
+				NumGPUs:     1,
+				TotalCostPH: price,
+				GpuCostPH:   price,
+				TotalFlops:  float64(10 * (i + 1)),
+				Score:       float64(100 - i),
+				UpdatedAt:   at.Add(-time.Duration(2*i+j) * time.Minute),
+			})
+		}
+	}
+	return out
+}
+
+// writeOffers dumps gpus to path as the JSON array a fileStore reads.
+func writeOffers(t *testing.T, path string, gpus []GPU) {
+	t.Helper()
+	b, err := json.Marshal(gpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rescan re-mints the offer ids the way every scan does and applies change to the offers.
+func rescan(gpus []GPU, scan int, change func(gpus []GPU)) []GPU {
+	out := make([]GPU, len(gpus))
+	for i, g := range gpus {
+		g.Id = fmt.Sprintf("offer-%02d-%d", i, scan)
+		out[i] = g
+	}
+	if change != nil {
+		change(out)
+	}
+	return out
+}
+
+// useCatalogue points the package catalogue at a file store holding gpus, loaded, and returns the
+// file's path so a test can swap the scan and Refresh.
+func useCatalogue(t *testing.T, gpus []GPU) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gpus.json")
+	writeOffers(t, path, gpus)
+	old := catalogue
+	t.Cleanup(func() { catalogue = old })
+	catalogue = newCatalogue(NewFileStore(path), time.Hour)
+	if err := catalogue.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCatalogueRefreshKeepsRecentSnapshots(t *testing.T) {
+	gpus := testOffers()
+	path := useCatalogue(t, gpus)
+	first, _ := catalogue.Snapshot(context.Background())
+
+	var seen [][2]*snapshot
+	catalogue.OnScan(func(cur, prev *snapshot) { seen = append(seen, [2]*snapshot{cur, prev}) })
+
+	// Reloading the same file is not a new scan.
+	if err := catalogue.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 0 {
+		t.Fatalf("OnScan ran %d times for an unchanged scan", len(seen))
+	}
+
+	writeOffers(t, path, rescan(gpus, 1, func(gpus []GPU) { gpus[0].TotalCostPH = 1.99 }))
+	if err := catalogue.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cur, _ := catalogue.Snapshot(context.Background())
+	if cur.version == first.version {
+		t.Fatal("version did not change with the scan")
+	}
+	if len(seen) != 1 || seen[0][0] != cur || seen[0][1].version != first.version {
+		t.Fatalf("OnScan got %v, want one call with (cur, first)", seen)
+	}
+	if _, ok := catalogue.At(first.version); !ok {
+		t.Error("previous snapshot is no longer reachable by version")
+	}
+	if prev := catalogue.Before(cur); prev == nil || prev.version != first.version {
+		t.Error("Before(cur) is not the replaced scan")
+	}
+	if prev := catalogue.Before(first); prev != nil {
+		t.Errorf("Before(first) = %s, want nil", prev.version)
+	}
+}
+
+func TestCatalogueGetFallsBackToStore(t *testing.T) {
+	useCatalogue(t, testOffers())
+	g, err := catalogue.Get(context.Background(), "offer-03")
+	if err != nil || g.Name != "A100 SXM4 80GB" {
+		t.Fatalf("Get(offer-03) = %v, %v", g.Name, err)
+	}
+	if _, err := catalogue.Get(context.Background(), "missing"); err == nil {
+		t.Error("Get(missing) succeeded")
+	}
+}
@@ -0,0 +1,290 @@
+// internal/api/compare.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// Comparison is the response schema for /compare and /compare/models. Fields are rows aligned
+// with Subjects: Values[i] belongs to Subjects[i].
+// swagger:model Comparison
+type Comparison struct {
+	Subjects []CompareSubject `json:"subjects"`
+	Fields   []CompareField   `json:"fields"`
+}
+
+// CompareSubject is one column of a comparison: an offer or a GPU model.
+type CompareSubject struct {
+	ID        string          `json:"id"`
+	Label     string          `json:"label"`
+	Model     string          `json:"model,omitempty"`
+	Offer     *GPU            `json:"offer,omitempty"`
+	Spec      *gpumodel.Spec  `json:"spec,omitempty"`
+	Providers []ProviderRange `json:"providers"`
+}
+
+// ProviderRange is what one provider charges for a model right now, per GPU-hour.
+type ProviderRange struct {
+	Source     string  `json:"source"`
+	Offers     int     `json:"offers"`
+	MinPerGPUH float64 `json:"min_per_gpu_ph"`
+	MaxPerGPUH float64 `json:"max_per_gpu_ph"`
+}
+
+// CompareField is one row. Winners holds the indexes of the best subjects (ties share) and is
+// empty when nothing can be compared. Ratios are each value over the first subject's.
+type CompareField struct {
+	Key     string    `json:"key"`
+	Label   string    `json:"label"`
+	Better  string    `json:"better"` // higher or lower
+	Values  []float64 `json:"values"`
+	Winners []int     `json:"winners"`
+	Ratios  []float64 `json:"ratios"`
+}
+
+type compareMetric[T any] struct {
+	key, label string
+	higher     bool
+	value      func(T) float64
+}
+
+var offerMetrics = []compareMetric[GPU]{
+	{"total_cost_ph", "$ / h", false, func(g GPU) float64 { return g.TotalCostPH }},
+	{"cost_per_gpu_ph", "$ / GPU-h", false, perGPUHour},
+	{"num_gpus", "GPUs", true, func(g GPU) float64 { return float64(g.NumGPUs) }},
+	{"vram_gb", "VRAM per GPU (GB)", true, func(g GPU) float64 { return float64(g.Vram) / 1024 }},
+	{"total_tflops", "Total TFLOPs", true, func(g GPU) float64 { return g.TotalFlops / 1e12 }},
+	{"flops_per_dollar_ph", "TFLOPs / $·h", true, func(g GPU) float64 { return g.FlopsPerDollarPH }},
+	{"gpu_mem_bw_gbps", "GPU mem BW (GB/s)", true, func(g GPU) float64 { return g.GpuMemoryBandwith }},
+	{"mem_bw_per_dollar_ph", "GB/s / $·h", true, func(g GPU) float64 {
+		return ratio(g.GpuMemoryBandwith*float64(max(g.NumGPUs, 1)), g.TotalCostPH)
+	}},
+	{"score", "Score", true, func(g GPU) float64 { return g.Score }},
+	{"score_dollar_ph", "Score / $·h", true, func(g GPU) float64 { return g.ScoreDPH }},
+	{"reliability", "Reliability", true, func(g GPU) float64 { return g.Reliability }},
+	{"ram_gb", "RAM (GB)", true, func(g GPU) float64 { return float64(g.Ram) / 1024 }},
+	{"cpu_cores", "CPU cores", true, func(g GPU) float64 { return g.CpuCores }},
+	{"disk_space_gb", "Disk (GB)", true, func(g GPU) float64 { return g.DiskSpace }},
+	{"download_gbps", "Down (Gbps)", true, func(g GPU) float64 { return g.DownloadSpeed / 1000 }},
+}
+
+// modelMarket is a model's spec plus what the current catalogue charges for it.
+type modelMarket struct {
+	model    gpumodel.Model
+	offers   int
+	minPerGH float64
+	medPerGH float64
+}
+
+var modelMetrics = []compareMetric[modelMarket]{
+	{"fp32_tflops", "FP32 TFLOPs", true, func(m modelMarket) float64 { return m.model.Spec.FP32TFLOPS }},
+	{"vram_gb", "VRAM (GB)", true, func(m modelMarket) float64 { return m.model.Spec.VramGB }},
+	{"mem_bw_gbps", "Memory BW (GB/s)", true, func(m modelMarket) float64 { return m.model.Spec.MemBWGBps }},
+	{"offers", "Offers now", true, func(m modelMarket) float64 { return float64(m.offers) }},
+	{"min_per_gpu_ph", "Cheapest $ / GPU-h", false, func(m modelMarket) float64 { return m.minPerGH }},
+	{"median_per_gpu_ph", "Median $ / GPU-h", false, func(m modelMarket) float64 { return m.medPerGH }},
+	{"tflops_per_dollar_ph", "TFLOPs / $·h at cheapest", true, func(m modelMarket) float64 {
+		return ratio(m.model.Spec.FP32TFLOPS, m.minPerGH)
+	}},
+	{"mem_bw_per_dollar_ph", "GB/s / $·h at cheapest", true, func(m modelMarket) float64 {
+		return ratio(m.model.Spec.MemBWGBps, m.minPerGH)
+	}},
+	{"vram_gb_per_dollar_ph", "GB VRAM / $·h at cheapest", true, func(m modelMarket) float64 {
+		return ratio(m.model.Spec.VramGB, m.minPerGH)
+	}},
+}
+
+func perGPUHour(g GPU) float64 { return g.TotalCostPH / float64(max(g.NumGPUs, 1)) }
+
+// ratio is a/b, or 0 when b is not positive.
+func ratio(a, b float64) float64 {
+	if b <= 0 {
+		return 0
+	}
+	return a / b
+}
+
+// compareRows builds one CompareField per metric. Zero values count as unknown: they never win
+// and have no ratio.
+func compareRows[T any](metrics []compareMetric[T], subjects []T) []CompareField {
+	fields := make([]CompareField, 0, len(metrics))
+	for _, m := range metrics {
+		f := CompareField{Key: m.key, Label: m.label, Better: "lower", Winners: []int{}}
+		if m.higher {
+			f.Better = "higher"
+		}
+		best := 0.0
+		for i, s := range subjects {
+			v := m.value(s)
+			f.Values = append(f.Values, v)
+			if v <= 0 {
+				continue
+			}
+			switch {
+			case len(f.Winners) == 0 || (m.higher && v > best) || (!m.higher && v < best):
+				best, f.Winners = v, []int{i}
+			case v == best:
+				f.Winners = append(f.Winners, i)
+			}
+		}
+		if len(f.Winners) == len(subjects) {
+			f.Winners = []int{} // all equal, nobody wins
+		}
+		for _, v := range f.Values {
+			f.Ratios = append(f.Ratios, ratio(v, f.Values[0]))
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// marketFor summarises the current offers of model m, with per-provider price ranges.
+func marketFor(s *snapshot, m gpumodel.Model) (modelMarket, []ProviderRange) {
+	bySource := map[string]*ProviderRange{}
+	var prices []float64
+	for _, g := range s.gpus {
+		if g.TotalCostPH <= 0 || gpumodel.Canonical(g.Name) != m.Name {
+			continue
+		}
+		p := perGPUHour(g)
+		prices = append(prices, p)
+		r, ok := bySource[g.Source]
+		if !ok {
+			r = &ProviderRange{Source: g.Source, MinPerGPUH: p, MaxPerGPUH: p}
+			bySource[g.Source] = r
+		}
+		r.Offers++
+		r.MinPerGPUH, r.MaxPerGPUH = min(r.MinPerGPUH, p), max(r.MaxPerGPUH, p)
+	}
+	sort.Float64s(prices)
+	providers := make([]ProviderRange, 0, len(bySource))
+	for _, r := range bySource {
+		providers = append(providers, *r)
+	}
+	sort.Slice(providers, func(a, b int) bool { return providers[a].MinPerGPUH < providers[b].MinPerGPUH })
+	return modelMarket{model: m, offers: len(prices), minPerGH: quantile(prices, 0), medPerGH: quantile(prices, 0.5)}, providers
+}
+
+// lookupError is an errNotFound that names what was missing.
+type lookupError string
+
+func (e lookupError) Error() string        { return string(e) }
+func (e lookupError) Is(target error) bool { return target == errNotFound }
+
+// compareBounds reads a 2-5 item list parameter.
+func compareBounds(param, value string) ([]string, error) {
+	items := splitList(value)
+	if len(items) < 2 || len(items) > 5 {
+		return nil, badParam(param, "takes 2 to 5 comma-separated values")
+	}
+	return items, nil
+}
+
+// compareOffers builds the comparison of the offers with the given ids.
+func compareOffers(s *snapshot, ids []string) (Comparison, error) {
+	var offers []GPU
+	var subjects []CompareSubject
+	for _, id := range ids {
+		i, ok := s.byID[id]
+		if !ok {
+			return Comparison{}, lookupError(fmt.Sprintf("no offer %q", id))
+		}
+		g := s.gpus[i]
+		sub := CompareSubject{ID: g.Id, Label: fmt.Sprintf("%s on %s (%s)", g.Name, g.Source, g.Location), Offer: &g, Providers: []ProviderRange{}}
+		if m, ok := gpumodel.Lookup(gpumodel.Canonical(g.Name)); ok {
+			spec := m.Spec
+			sub.Model, sub.Spec = m.Name, &spec
+			_, sub.Providers = marketFor(s, m)
+		}
+		offers = append(offers, g)
+		subjects = append(subjects, sub)
+	}
+	return Comparison{Subjects: subjects, Fields: compareRows(offerMetrics, offers)}, nil
+}
+
+// compareModels builds the comparison of the given GPU models at today's prices.
+func compareModels(s *snapshot, names []string) (Comparison, error) {
+	var markets []modelMarket
+	var subjects []CompareSubject
+	for _, name := range names {
+		m, ok := gpumodel.Lookup(name)
+		if !ok {
+			return Comparison{}, lookupError(fmt.Sprintf("unknown GPU model %q", name))
+		}
+		market, providers := marketFor(s, m)
+		spec := m.Spec
+		markets = append(markets, market)
+		subjects = append(subjects, CompareSubject{ID: m.Slug, Label: m.Name, Model: m.Name, Spec: &spec, Providers: providers})
+	}
+	return Comparison{Subjects: subjects, Fields: compareRows(modelMetrics, markets)}, nil
+}
+
+func writeComparison(w http.ResponseWriter, r *http.Request, param string, build func(*snapshot, []string) (Comparison, error)) {
+	q := r.URL.Query()
+	if err := checkParams(q, paramSet(param)); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	items, err := compareBounds(param, q.Get(param))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	snap, err := catalogue.Snapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	cmp, err := build(snap, items)
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cmp)
+}
+
+// compareHandler godoc
+// @Summary     Compare offers
+// @Description Side-by-side table of 2-5 offers: every field's values aligned with subjects, the winning
+// @Description subject(s) and each value as a ratio of the first subject's. Subjects carry their model's
+// @Description datasheet and what each provider charges for that model per GPU-hour.
+// @Tags        compare
+// @Produce     json
+// @Param       ids  query  string  true  "Comma-separated offer ids"
+// @Success     200  {object} Comparison
+// @Failure     400  {object} ErrorResponse  "Bad request"
+// @Failure     404  {object} ErrorResponse  "Unknown offer"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /compare [get]
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	writeComparison(w, r, "ids", compareOffers)
+}
+
+// compareModelsHandler godoc
+// @Summary     Compare GPU models
+// @Description Datasheet and market comparison of 2-5 GPU models (name, slug or alias, e.g. h200,h100-sxm):
+// @Description FP32, VRAM and bandwidth, current cheapest and median price per GPU-hour, and TFLOPs, GB/s and
+// @Description GB of VRAM per dollar at the cheapest price, with winners, ratios to the first model and
+// @Description per-provider price ranges.
+// @Tags        compare
+// @Produce     json
+// @Param       models  query  string  true  "Comma-separated GPU models"
+// @Success     200     {object} Comparison
+// @Failure     400     {object} ErrorResponse  "Bad request"
+// @Failure     404     {object} ErrorResponse  "Unknown model"
+// @Failure     502     {object} ErrorResponse  "Upstream error"
+// @Router      /compare/models [get]
+func compareModelsHandler(w http.ResponseWriter, r *http.Request) {
+	writeComparison(w, r, "models", compareModels)
+}
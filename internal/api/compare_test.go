@@ -0,0 +1,132 @@
+// internal/api/compare_test.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// getComparison runs h on path and decodes the comparison.
+func getComparison(t *testing.T, h http.HandlerFunc, path string) (*httptest.ResponseRecorder, Comparison) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", path, nil))
+	var cmp Comparison
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &cmp); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+	return w, cmp
+}
+
+// field returns the row of cmp with key.
+func field(t *testing.T, cmp Comparison, key string) CompareField {
+	t.Helper()
+	for _, f := range cmp.Fields {
+		if f.Key == key {
+			if len(f.Values) != len(cmp.Subjects) || len(f.Ratios) != len(cmp.Subjects) {
+				t.Errorf("%s: %d values and %d ratios for %d subjects", key, len(f.Values), len(f.Ratios), len(cmp.Subjects))
+			}
+			return f
+		}
+	}
+	t.Fatalf("no %s row", key)
+	return CompareField{}
+}
+
+func TestCompareOffers(t *testing.T) {
+	useCatalogue(t, testOffers())
+
+	w, cmp := getComparison(t, compareHandler, "/compare?ids=offer-06,offer-00,offer-07")
+	if w.Code != http.StatusOK || len(cmp.Subjects) != 3 || len(cmp.Fields) != len(offerMetrics) {
+		t.Fatalf("status %d, %d subjects, %d fields: %s", w.Code, len(cmp.Subjects), len(cmp.Fields), w.Body)
+	}
+	s := cmp.Subjects[0]
+	if s.ID != "offer-06" || s.Label != "RTX 4090 on vast (us-east-1)" || s.Model != "RTX 4090" || s.Spec == nil || s.Offer == nil {
+		t.Errorf("first subject = %+v", s)
+	}
+	if p := s.Providers; len(p) != 2 || p[0].Source != "vast" || p[0].MinPerGPUH != 0.35 || p[1].Source != "runpod" || p[1].Offers != 1 {
+		t.Errorf("4090 providers = %v", s.Providers)
+	}
+
+	price := field(t, cmp, "total_cost_ph")
+	if price.Better != "lower" || !slices.Equal(price.Winners, []int{0}) || math.Abs(price.Ratios[1]-2.49/0.35) > 1e-9 || price.Ratios[0] != 1 {
+		t.Errorf("total_cost_ph = %+v", price)
+	}
+	if score := field(t, cmp, "score"); score.Better != "higher" || !slices.Equal(score.Winners, []int{1}) {
+		t.Errorf("score = %+v", score)
+	}
+	// Equal everywhere, nobody wins; unknown (zero) values neither win nor have a ratio.
+	if gpus := field(t, cmp, "num_gpus"); len(gpus.Winners) != 0 {
+		t.Errorf("num_gpus = %+v", gpus)
+	}
+	if rel := field(t, cmp, "reliability"); len(rel.Winners) != 0 || rel.Ratios[1] != 0 {
+		t.Errorf("reliability = %+v", rel)
+	}
+
+	// Ties for the best value share the win.
+	_, cmp = getComparison(t, compareHandler, "/compare?ids=offer-00,offer-01,offer-02")
+	if score := field(t, cmp, "score"); !slices.Equal(score.Winners, []int{0, 1}) {
+		t.Errorf("tied score winners = %v", score.Winners)
+	}
+}
+
+func TestCompareModels(t *testing.T) {
+	useCatalogue(t, testOffers())
+
+	w, cmp := getComparison(t, compareModelsHandler, "/compare/models?models=rtx-4090,L4,h100-sxm,h200")
+	if w.Code != http.StatusOK || len(cmp.Subjects) != 4 || len(cmp.Fields) != len(modelMetrics) {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var ids []string
+	for _, s := range cmp.Subjects {
+		ids = append(ids, s.ID)
+	}
+	if fmt.Sprint(ids) != "[rtx-4090 l4 h100-sxm h200]" || cmp.Subjects[1].Spec.FP32TFLOPS != 30.3 {
+		t.Errorf("subjects %v, L4 spec %+v", ids, cmp.Subjects[1].Spec)
+	}
+	if len(cmp.Subjects[3].Providers) != 0 || len(cmp.Subjects[2].Providers) != 2 {
+		t.Errorf("providers: H200 %v, H100 %v", cmp.Subjects[3].Providers, cmp.Subjects[2].Providers)
+	}
+
+	// The H200 has no offers, so it has no price and cannot win on one.
+	cheapest := field(t, cmp, "min_per_gpu_ph")
+	if fmt.Sprint(cheapest.Values) != "[0.35 0.44 2.49 0]" || !slices.Equal(cheapest.Winners, []int{0}) || cheapest.Ratios[3] != 0 {
+		t.Errorf("min_per_gpu_ph = %+v", cheapest)
+	}
+	if fp32 := field(t, cmp, "fp32_tflops"); !slices.Equal(fp32.Winners, []int{0}) || fp32.Better != "higher" {
+		t.Errorf("fp32_tflops = %+v", fp32)
+	}
+	if vram := field(t, cmp, "vram_gb_per_dollar_ph"); math.Abs(vram.Values[0]-24/0.35) > 1e-9 {
+		t.Errorf("vram_gb_per_dollar_ph = %+v", vram)
+	}
+}
+
+func TestCompareRejects(t *testing.T) {
+	useCatalogue(t, testOffers())
+	for _, tt := range []struct {
+		h      http.HandlerFunc
+		path   string
+		status int
+		param  string
+	}{
+		{compareHandler, "/compare", http.StatusBadRequest, "ids"},
+		{compareHandler, "/compare?ids=offer-00", http.StatusBadRequest, "ids"},
+		{compareHandler, "/compare?ids=offer-00,offer-01,offer-02,offer-03,offer-04,offer-05", http.StatusBadRequest, "ids"},
+		{compareHandler, "/compare?ids=offer-00,offer-01&models=h100", http.StatusBadRequest, "models"},
+		{compareHandler, "/compare?ids=offer-00,offer-missing", http.StatusNotFound, ""},
+		{compareModelsHandler, "/compare/models?models=h100-sxm", http.StatusBadRequest, "models"},
+		{compareModelsHandler, "/compare/models?models=h100-sxm,voodoo2", http.StatusNotFound, ""},
+	} {
+		w, _ := getComparison(t, tt.h, tt.path)
+		if e := apiError(t, w); w.Code != tt.status || e.Param != tt.param {
+			t.Errorf("%s: status %d, %+v", tt.path, w.Code, e)
+		}
+	}
+}
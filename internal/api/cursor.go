@@ -0,0 +1,71 @@
+// internal/api/cursor.go
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+)
+
+// cursor is the position of the next page of a /gpus listing. It is pinned to the
+// snapshot the first page came from, so paging across a scan neither skips nor repeats
+// offers, and to the query it was issued for.
+type cursor struct {
+	Version string `json:"v"`
+	Query   string `json:"q"`
+	Offset  int    `json:"o"`
+}
+
+// pageParams are the parameters that move through a listing rather than define it.
+var pageParams = []string{"cursor", "limit", "offset", "cols"}
+
+// queryKey fingerprints the parameters that define a listing's rows and order.
+func queryKey(q url.Values) string {
+	v := url.Values{}
+	for k, vals := range q {
+		v[k] = vals
+	}
+	for _, k := range pageParams {
+		v.Del(k)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(v.Encode()))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+func (c cursor) String() string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// parseCursor decodes the cursor parameter and checks it was issued for q.
+func parseCursor(q url.Values) (*cursor, error) {
+	s := q.Get("cursor")
+	if s == "" {
+		return nil, nil
+	}
+	if q.Get("offset") != "" {
+		return nil, badParam("offset", "cannot be combined with cursor")
+	}
+	var c cursor
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(js, &c) != nil || c.Version == "" || c.Offset < 0 {
+		return nil, badParam("cursor", "malformed cursor")
+	}
+	if c.Query != queryKey(q) {
+		return nil, badParam("cursor", "cursor was issued for a different query")
+	}
+	return &c, nil
+}
+
+// nextPageURL is the request URL with offset replaced by a cursor for the page after it.
+func nextPageURL(u *url.URL, c cursor) string {
+	q := u.Query()
+	q.Del("offset")
+	q.Set("cursor", c.String())
+	next := *u
+	next.RawQuery = q.Encode()
+	return next.String()
+}
@@ -0,0 +1,53 @@
+// internal/api/diff.go
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// OfferChange is one difference between two scans, as pushed by /gpus/stream.
+// swagger:model OfferChange
+type OfferChange struct {
+	// ID orders changes and is the Last-Event-ID to resume after.
+	ID string `json:"id"`
+	scandiff.Event
+	// Offer is the key's cheapest listing in the new scan, or in the old one for removed.
+	Offer     GPU       `json:"offer"`
+	ScannedAt time.Time `json:"scanned_at"`
+
+	prev *GPU // the key's cheapest listing in the old scan, when the change was diffed live
+}
+
+func diffOffer(g GPU) scandiff.Offer {
+	return scandiff.Offer{Source: g.Source, Name: g.Name, Location: g.Location, NumGPUs: g.NumGPUs, TotalCostPH: g.TotalCostPH}
+}
+
+func diffOffers(gpus []GPU) []scandiff.Offer {
+	out := make([]scandiff.Offer, len(gpus))
+	for i, g := range gpus {
+		out[i] = diffOffer(g)
+	}
+	return out
+}
+
+// diffSnapshots lists what changed from prev to cur, see scandiff.Diff.
+func diffSnapshots(prev, cur *snapshot) []OfferChange {
+	events := scandiff.Diff(diffOffers(prev.gpus), diffOffers(cur.gpus))
+	out := make([]OfferChange, len(events))
+	for i, e := range events {
+		c := OfferChange{ID: cur.version + "." + strconv.Itoa(i), Event: e, ScannedAt: cur.scannedAt}
+		if e.Prev >= 0 {
+			c.prev = &prev.gpus[e.Prev]
+		}
+		if e.Cur >= 0 {
+			c.Offer = cur.gpus[e.Cur]
+		} else {
+			c.Offer = prev.gpus[e.Prev]
+		}
+		out[i] = c
+	}
+	return out
+}
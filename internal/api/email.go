@@ -0,0 +1,651 @@
+// internal/api/email.go
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// EmailTarget is where and how often a saved search emails its alerts. Nothing is sent but the
+// confirmation email until the link in it is followed.
+type EmailTarget struct {
+	Address   string `json:"address"`
+	Digest    string `json:"digest"` // none, hourly or daily
+	Confirmed bool   `json:"confirmed"`
+}
+
+// digestNone sends every alert as it fires; digestPeriods batch them.
+const digestNone = "none"
+
+var digestPeriods = map[string]time.Duration{"hourly": time.Hour, "daily": 24 * time.Hour}
+
+// SMTP transport security, SMTP_TLS.
+const (
+	smtpStartTLS = "starttls"
+	smtpTLS      = "tls" // implicit TLS, usually port 465
+	smtpPlain    = "none"
+)
+
+// Mailer sends alert emails over SMTP.
+type Mailer struct {
+	host     string
+	port     int
+	security string
+	username string
+	password string
+	from     *mail.Address
+	tls      *tls.Config // nil verifies host against the system roots
+}
+
+// newMailerFromEnv reads SMTP_HOST, SMTP_PORT, SMTP_TLS (starttls, tls or none), SMTP_USERNAME,
+// SMTP_PASSWORD and SMTP_FROM. It returns nil, and email alerts are off, when SMTP_HOST is unset.
+func newMailerFromEnv() *Mailer {
+	m := &Mailer{
+		host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		security: strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_TLS"))),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+	}
+	if m.host == "" {
+		return nil
+	}
+	ports := map[string]int{smtpStartTLS: 587, smtpTLS: 465, smtpPlain: 25}
+	if m.security == "" {
+		m.security = smtpStartTLS
+	}
+	if _, ok := ports[m.security]; !ok {
+		log.Printf("alerts: SMTP_TLS=%q is not starttls, tls or none; email alerts are off", m.security)
+		return nil
+	}
+	m.port = ports[m.security]
+	if p, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && p > 0 {
+		m.port = p
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "gpufindr alerts <alerts@gpufindr.com>"
+	}
+	var err error
+	if m.from, err = mail.ParseAddress(from); err != nil {
+		log.Printf("alerts: SMTP_FROM: %v; email alerts are off", err)
+		return nil
+	}
+	return m
+}
+
+// send mails events to the search's address and returns the final SMTP reply code. 4xx replies
+// and connection failures are retried, 5xx are not.
+func (m *Mailer) send(ctx context.Context, s storedSearch, events []AlertEvent) (int, error) {
+	msg, err := m.message(s, events)
+	if err != nil {
+		return 0, &deliveryError{msg: err.Error()}
+	}
+	return m.sendMessage(ctx, s.Email.Address, msg)
+}
+
+// tlsConfig is the TLS configuration for the SMTP server.
+func (m *Mailer) tlsConfig() *tls.Config {
+	if m.tls != nil {
+		return m.tls
+	}
+	return &tls.Config{ServerName: m.host}
+}
+
+// sendMessage delivers a rendered message to one recipient, see send.
+func (m *Mailer) sendMessage(ctx context.Context, to string, msg []byte) (int, error) {
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if m.security == smtpTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: m.tlsConfig()}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return smtpResult(err)
+	}
+	defer c.Close()
+
+	if m.security == smtpStartTLS {
+		if err := c.StartTLS(m.tlsConfig()); err != nil {
+			return smtpResult(err)
+		}
+	}
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return smtpResult(err)
+		}
+	}
+	if err := c.Mail(m.from.Address); err != nil {
+		return smtpResult(err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return smtpResult(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpResult(err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return smtpResult(err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpResult(err)
+	}
+	_ = c.Quit()
+	return 250, nil
+}
+
+// smtpResult turns an SMTP failure into a reply code and a deliveryError.
+func smtpResult(err error) (int, error) {
+	var te *textproto.Error
+	if errors.As(err, &te) {
+		return te.Code, &deliveryError{msg: err.Error(), retry: te.Code < 500}
+	}
+	return 0, err
+}
+
+// emailData feeds the email templates. One event is an alert; more are a digest.
+type emailData struct {
+	Subject        string
+	Search         SavedSearch
+	Events         []AlertEvent
+	SearchURL      string
+	UnsubscribeURL string
+}
+
+var emailFuncs = template.FuncMap{
+	"gpus":  func(n int) int { return max(n, 1) },
+	"price": func(f float64) string { return fmt.Sprintf("$%.2f/h", f) },
+	"more":  func(ev AlertEvent) int { return ev.Matches - len(ev.Offers) },
+}
+
+var emailText = template.Must(template.New("text").Funcs(emailFuncs).Parse(`{{.Subject}}
+{{range .Events}}
+Scan of {{.ScannedAt.Format "2006-01-02 15:04 MST"}}: {{.Matches}} offer(s)
+{{range .Offers}}  - {{gpus .NumGPUs}}x {{.Name}} on {{.Source}} ({{.Location}}), {{price .TotalCostPH}}{{if .Url}}
+    {{.Url}}{{end}}
+{{end}}{{if gt (more .) 0}}  ...and {{more .}} more
+{{end}}{{end}}
+All matches: {{.SearchURL}}
+
+You get this email for the saved search "{{.Search.Name}}".
+Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+var emailHTML = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap(emailFuncs)).Parse(`<!doctype html>
+<html><body style="font-family:system-ui,sans-serif;color:#111">
+<h2 style="font-size:18px">{{.Subject}}</h2>
+{{range .Events}}
+<p style="margin:16px 0 4px;color:#555">Scan of {{.ScannedAt.Format "2006-01-02 15:04 MST"}}: {{.Matches}} offer(s)</p>
+<table cellpadding="6" style="border-collapse:collapse;font-size:14px">
+<tr style="text-align:left;border-bottom:1px solid #ddd"><th>GPU</th><th>Provider</th><th>Location</th><th>Price</th><th></th></tr>
+{{range .Offers}}<tr style="border-bottom:1px solid #eee">
+<td>{{gpus .NumGPUs}}x {{.Name}}</td><td>{{.Source}}</td><td>{{.Location}}</td><td>{{price .TotalCostPH}}</td>
+<td>{{if .Url}}<a href="{{.Url}}">Rent</a>{{end}}</td>
+</tr>{{end}}
+</table>
+{{if gt (more .) 0}}<p style="color:#555">...and {{more .}} more</p>{{end}}
+{{end}}
+<p><a href="{{.SearchURL}}">See all matches on gpufindr</a></p>
+<p style="font-size:12px;color:#777">You get this email for the saved search "{{.Search.Name}}".
+<a href="{{.UnsubscribeURL}}">Unsubscribe</a></p>
+</body></html>
+`))
+
+// subject summarises events for the Subject header.
+func subject(s SavedSearch, events []AlertEvent) string {
+	if len(events) > 1 {
+		n := 0
+		for _, ev := range events {
+			n += ev.Matches
+		}
+		return fmt.Sprintf("%s: %d offers in your %s digest", s.Name, n, s.Email.Digest)
+	}
+	ev := events[0]
+	switch {
+	case ev.Event == "test":
+		return fmt.Sprintf("%s: %d offers match now (test)", s.Name, ev.Matches)
+	case s.Trigger == triggerBelow:
+		return fmt.Sprintf("%s: %d offers under $%.2f/h", s.Name, ev.Matches, s.MaxTotalCostPH)
+	}
+	return fmt.Sprintf("%s: %d new offers", s.Name, ev.Matches)
+}
+
+var confirmText = template.Must(template.New("confirm").Parse(`Confirm alert emails from gpufindr
+
+Someone, hopefully you, saved the search "{{.Search.Name}}" on gpufindr and asked for its alerts
+to be emailed to {{.Address}}. Nothing more will be sent to this address unless you confirm:
+
+{{.ConfirmURL}}
+
+If this was not you, ignore this email.
+`))
+
+// headers writes the headers common to every email to buf.
+func (m *Mailer) headers(buf *bytes.Buffer, to, subject string, extra ...[2]string) {
+	_, domain, _ := strings.Cut(m.from.Address, "@")
+	for _, h := range append([][2]string{
+		{"From", m.from.String()},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + randomToken(12) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+	}, extra...) {
+		fmt.Fprintf(buf, "%s: %s\r\n", h[0], h[1])
+	}
+	buf.WriteString("\r\n")
+}
+
+// confirmation renders the opt-in email sent when a search is saved with an email address.
+func (m *Mailer) confirmation(s storedSearch) ([]byte, error) {
+	var buf bytes.Buffer
+	m.headers(&buf, s.Email.Address, "Confirm gpufindr alerts for "+s.Name,
+		[2]string{"Content-Type", "text/plain; charset=utf-8"},
+		[2]string{"Content-Transfer-Encoding", "quoted-printable"})
+	qp := quotedprintable.NewWriter(&buf)
+	data := struct {
+		Search              SavedSearch
+		Address, ConfirmURL string
+	}{s.SavedSearch, s.Email.Address, confirmURL(s)}
+	if err := confirmText.Execute(qp, data); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// message renders a multipart/alternative email with plain-text and HTML parts.
+func (m *Mailer) message(s storedSearch, events []AlertEvent) ([]byte, error) {
+	data := emailData{
+		Subject:        subject(s.SavedSearch, events),
+		Search:         s.SavedSearch,
+		Events:         events,
+		SearchURL:      events[0].URL,
+		UnsubscribeURL: unsubscribeURL(s),
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	m.headers(&buf, s.Email.Address, data.Subject,
+		[2]string{"List-Unsubscribe", "<" + data.UnsubscribeURL + ">"},
+		[2]string{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"},
+		[2]string{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()})
+
+	parts := []struct {
+		contentType string
+		render      func(*quotedprintable.Writer) error
+	}{
+		{"text/plain; charset=utf-8", func(w *quotedprintable.Writer) error { return emailText.Execute(w, data) }},
+		{"text/html; charset=utf-8", func(w *quotedprintable.Writer) error { return emailHTML.Execute(w, data) }},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if err := p.render(qp); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// digestDue is when the digest an alert fired at now goes out: the end of the current hour or
+// UTC day.
+func digestDue(s storedSearch, now time.Time) time.Time {
+	period := digestPeriods[s.Email.Digest]
+	return now.UTC().Truncate(period).Add(period)
+}
+
+// runDigests sends due digests every minute until ctx is done. The queue is in the AlertStore,
+// so digests survive restarts and each goes out from one instance.
+func (a *Alerts) runDigests(ctx context.Context) {
+	if a.mailer == nil {
+		return
+	}
+	t := time.NewTicker(time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			a.sendDigests(ctx, now)
+		}
+	}
+}
+
+// sendDigests takes the digests due by now from the queue and mails each, unless its search has
+// since been deleted or lost its email.
+func (a *Alerts) sendDigests(ctx context.Context, now time.Time) {
+	due, err := a.store.TakeDigests(ctx, now)
+	if err != nil {
+		log.Printf("alerts: take digests: %v", err)
+		return
+	}
+	for id, events := range due {
+		s, err := a.store.GetSearch(ctx, id)
+		if err != nil || s.Email == nil || !s.Email.Confirmed {
+			continue
+		}
+		offers := 0
+		for _, ev := range events {
+			offers += ev.Matches
+		}
+		go a.deliver(ctx, s.ID, "digest", offers, channelEmail, func(ctx context.Context) (int, error) {
+			return a.mailer.send(ctx, s, events)
+		})
+	}
+}
+
+// Confirmation emails go to whatever address a POST /searches names, so they are rationed: an
+// address waiting on one gets no other for confirmPending, and each client IP and address has a
+// few an hour or a day.
+const (
+	confirmPending = 24 * time.Hour
+	confirmPerIP   = 5 // an hour
+	confirmPerAddr = 3 // a day
+)
+
+// sendLimiter allows max sends per key in any window.
+type sendLimiter struct {
+	max    int
+	window time.Duration
+
+	mu    sync.Mutex
+	sent  map[string][]time.Time // key -> sends within the window, oldest first
+	swept time.Time              // when keys with no recent sends were last dropped
+}
+
+func newSendLimiter(max int, window time.Duration) *sendLimiter {
+	return &sendLimiter{max: max, window: window, sent: map[string][]time.Time{}}
+}
+
+// allow records a send for key at now, or returns how long until key may send again.
+func (l *sendLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.swept) >= l.window {
+		for k, sent := range l.sent {
+			if now.Sub(sent[len(sent)-1]) >= l.window {
+				delete(l.sent, k)
+			}
+		}
+		l.swept = now
+	}
+	sent := l.sent[key]
+	for len(sent) > 0 && now.Sub(sent[0]) >= l.window {
+		sent = sent[1:]
+	}
+	if len(sent) >= l.max {
+		l.sent[key] = sent
+		return false, sent[0].Add(l.window).Sub(now)
+	}
+	l.sent[key] = append(sent, now)
+	return true, 0
+}
+
+// clientIP is who sent r: the hop the proxy in front appended to X-Forwarded-For when
+// trustProxy is set, the connection's address otherwise.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Values("X-Forwarded-For"); trustProxy && len(fwd) > 0 {
+		hops := strings.Split(fwd[len(fwd)-1], ",")
+		if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// admitConfirmation decides whether a confirmation may go to addr for a request from ip. It
+// fails with errConfirmPending while an earlier one to addr is unanswered, and with how long to
+// wait when ip or addr is over its limit.
+func (a *Alerts) admitConfirmation(ctx context.Context, ip, addr string) (time.Duration, error) {
+	now := time.Now()
+	pending, err := a.store.PendingConfirmation(ctx, addr, now.Add(-confirmPending))
+	if err != nil {
+		return 0, err
+	}
+	if pending {
+		return 0, errConfirmPending
+	}
+	if ok, wait := a.confirmIPs.allow(ip, now); !ok {
+		return wait, fmt.Errorf("too many confirmation emails from %s; try again later", ip)
+	}
+	if ok, wait := a.confirmAddrs.allow(strings.ToLower(addr), now); !ok {
+		return wait, fmt.Errorf("too many confirmation emails to %s; try again later", addr)
+	}
+	return 0, nil
+}
+
+var errConfirmPending = errors.New("a confirmation email to this address is waiting to be followed; confirm that search first")
+
+// sendConfirmation mails the opt-in link for s's address.
+func (a *Alerts) sendConfirmation(ctx context.Context, s storedSearch) {
+	msg, err := a.mailer.confirmation(s)
+	if err != nil {
+		log.Printf("alerts: %s: confirmation: %v", s.ID, err)
+		return
+	}
+	go a.deliver(ctx, s.ID, "confirm", 0, channelEmail, func(ctx context.Context) (int, error) {
+		return a.mailer.sendMessage(ctx, s.Email.Address, msg)
+	})
+}
+
+// unsubscribeToken authenticates an unsubscribe link: the search id and a MAC of it under the
+// search's secret, so links need no storage and die with the search.
+func unsubscribeToken(s storedSearch) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte("unsubscribe:" + s.ID))
+	return s.ID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func unsubscribeURL(s storedSearch) string {
+	return siteURL + "/unsubscribe?token=" + url.QueryEscape(unsubscribeToken(s))
+}
+
+// confirmToken authenticates an opt-in link like unsubscribeToken, bound to the address so it
+// cannot confirm another one.
+func confirmToken(s storedSearch) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte("confirm:" + s.ID + ":" + s.Email.Address))
+	return s.ID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func confirmURL(s storedSearch) string {
+	return siteURL + "/confirm?token=" + url.QueryEscape(confirmToken(s))
+}
+
+// confirmSearch returns the search an opt-in token belongs to, or errNotFound.
+func confirmSearch(ctx context.Context, token string) (storedSearch, error) {
+	id, _, _ := strings.Cut(token, ".")
+	s, err := alerts.store.GetSearch(ctx, id)
+	if err != nil {
+		return s, err
+	}
+	if s.Email == nil || !hmac.Equal([]byte(confirmToken(s)), []byte(token)) {
+		return s, errNotFound
+	}
+	return s, nil
+}
+
+// unsubscribeSearch returns the search a token belongs to, or errNotFound.
+func unsubscribeSearch(ctx context.Context, token string) (storedSearch, error) {
+	id, _, _ := strings.Cut(token, ".")
+	s, err := alerts.store.GetSearch(ctx, id)
+	if err != nil {
+		return s, err
+	}
+	if !hmac.Equal([]byte(unsubscribeToken(s)), []byte(token)) {
+		return s, errNotFound
+	}
+	return s, nil
+}
+
+var emailPage = htmltemplate.Must(htmltemplate.New("email").Parse(`<!doctype html>
+<html><head><meta name="viewport" content="width=device-width"><title>{{.Title}}</title></head>
+<body style="font-family:system-ui,sans-serif;max-width:32rem;margin:4rem auto;padding:0 1rem">
+<h1 style="font-size:20px">{{.Title}}</h1><p>{{.Message}}</p>
+{{if .Action}}<form method="post" action="{{.Action}}"><button type="submit">{{.Button}}</button></form>{{end}}
+</body></html>
+`))
+
+// writeEmailPage renders the page behind an email link; with an action it is a form posting
+// there, so mail scanners that follow links change nothing.
+func writeEmailPage(w http.ResponseWriter, status int, title, message, action, button string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = emailPage.Execute(w, struct{ Title, Message, Action, Button string }{title, message, action, button})
+}
+
+// unsubscribeHandler godoc
+// @Summary     Confirm unsubscribing from alert emails
+// @Description The page behind the link in every alert email. It only asks for confirmation, so mail
+// @Description scanners that follow links do not unsubscribe anyone.
+// @Tags        alerts
+// @Produce     html
+// @Param       token  query  string  true  "Unsubscribe token from the email"
+// @Success     200
+// @Failure     404
+// @Router      /unsubscribe [get]
+func unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	s, err := unsubscribeSearch(r.Context(), token)
+	if err != nil || s.Email == nil {
+		writeEmailPage(w, http.StatusNotFound, "Link expired", "This alert no longer sends email.", "", "")
+		return
+	}
+	writeEmailPage(w, http.StatusOK, "Unsubscribe",
+		fmt.Sprintf("Stop emailing %s about the saved search %q?", s.Email.Address, s.Name),
+		"/unsubscribe?token="+url.QueryEscape(token), "Unsubscribe")
+}
+
+// unsubscribeConfirmHandler godoc
+// @Summary     Unsubscribe from alert emails
+// @Description Removes the email channel of the search the token belongs to, and the search itself when
+// @Description it has no webhook. Also the RFC 8058 one-click target of List-Unsubscribe.
+// @Tags        alerts
+// @Produce     html
+// @Param       token  query  string  true  "Unsubscribe token from the email"
+// @Success     200
+// @Failure     404
+// @Failure     502
+// @Router      /unsubscribe [post]
+func unsubscribeConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	s, err := unsubscribeSearch(r.Context(), r.URL.Query().Get("token"))
+	if errors.Is(err, errNotFound) || (err == nil && s.Email == nil) {
+		writeEmailPage(w, http.StatusNotFound, "Link expired", "This alert no longer sends email.", "", "")
+		return
+	}
+	if err == nil {
+		if s.Email = nil; s.Webhook == nil {
+			err = alerts.store.DeleteSearch(r.Context(), s.ID)
+		} else {
+			err = alerts.store.UpdateSearch(r.Context(), s)
+		}
+	}
+	if err != nil {
+		log.Printf("alerts: unsubscribe: %v", err)
+		writeEmailPage(w, http.StatusBadGateway, "Something went wrong", "Please try the link again later.", "", "")
+		return
+	}
+	writeEmailPage(w, http.StatusOK, "Unsubscribed", fmt.Sprintf("You will get no more email about %q.", s.Name), "", "")
+}
+
+// confirmHandler godoc
+// @Summary     Confirm alert emails
+// @Description The page behind the link in the confirmation email sent when a search is saved with an email
+// @Description address. It only asks for confirmation, so mail scanners that follow links confirm nothing.
+// @Tags        alerts
+// @Produce     html
+// @Param       token  query  string  true  "Confirmation token from the email"
+// @Success     200
+// @Failure     404
+// @Router      /confirm [get]
+func confirmHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	s, err := confirmSearch(r.Context(), token)
+	if err != nil {
+		writeEmailPage(w, http.StatusNotFound, "Link expired", "This alert no longer sends email.", "", "")
+		return
+	}
+	writeEmailPage(w, http.StatusOK, "Confirm alert emails",
+		fmt.Sprintf("Email %s about the saved search %q?", s.Email.Address, s.Name),
+		"/confirm?token="+url.QueryEscape(token), "Confirm")
+}
+
+// confirmPostHandler godoc
+// @Summary     Start alert emails
+// @Description Confirms the email address of the search the token belongs to. Until then the search emails
+// @Description nothing, test alerts included.
+// @Tags        alerts
+// @Produce     html
+// @Param       token  query  string  true  "Confirmation token from the email"
+// @Success     200
+// @Failure     404
+// @Failure     502
+// @Router      /confirm [post]
+func confirmPostHandler(w http.ResponseWriter, r *http.Request) {
+	s, err := confirmSearch(r.Context(), r.URL.Query().Get("token"))
+	if errors.Is(err, errNotFound) {
+		writeEmailPage(w, http.StatusNotFound, "Link expired", "This alert no longer sends email.", "", "")
+		return
+	}
+	if err == nil && !s.Email.Confirmed {
+		s.Email.Confirmed = true
+		err = alerts.store.UpdateSearch(r.Context(), s)
+	}
+	if err != nil {
+		log.Printf("alerts: confirm: %v", err)
+		writeEmailPage(w, http.StatusBadGateway, "Something went wrong", "Please try the link again later.", "", "")
+		return
+	}
+	writeEmailPage(w, http.StatusOK, "Confirmed", fmt.Sprintf("Alerts for %q will be emailed to %s.", s.Name, s.Email.Address), "", "")
+}
@@ -0,0 +1,425 @@
+// internal/api/email_test.go
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// smtpStub is an SMTP server good enough for net/smtp: it offers STARTTLS when given a
+// certificate (and then refuses mail in the clear), answers RCPT TO with the next of rcpt (the
+// last one repeats) and keeps every message it accepts.
+type smtpStub struct {
+	ln    net.Listener
+	tls   *tls.Config
+	mu    sync.Mutex
+	rcpt  []int
+	rcpts int
+	msgs  []*mail.Message
+}
+
+func newSMTPStub(t *testing.T, tlsConfig *tls.Config, rcpt ...int) *smtpStub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rcpt) == 0 {
+		rcpt = []int{250}
+	}
+	st := &smtpStub{ln: ln, tls: tlsConfig, rcpt: rcpt}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go st.serve(conn)
+		}
+	}()
+	return st
+}
+
+func (st *smtpStub) serve(conn net.Conn) {
+	defer func() { conn.Close() }() // conn becomes the TLS connection after STARTTLS
+	tp := textproto.NewConn(conn)
+	secure := false
+	_ = tp.PrintfLine("220 stub ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch {
+		case cmd == "EHLO" || cmd == "HELO":
+			if st.tls != nil && !secure {
+				_ = tp.PrintfLine("250-stub\r\n250 STARTTLS")
+			} else {
+				_ = tp.PrintfLine("250 stub")
+			}
+		case cmd == "STARTTLS" && st.tls != nil:
+			_ = tp.PrintfLine("220 go ahead")
+			tc := tls.Server(conn, st.tls)
+			if tc.Handshake() != nil {
+				return
+			}
+			conn, secure = tc, true
+			tp = textproto.NewConn(conn)
+		case cmd == "MAIL":
+			if st.tls != nil && !secure {
+				_ = tp.PrintfLine("530 must issue STARTTLS first")
+				continue
+			}
+			_ = tp.PrintfLine("250 ok")
+		case cmd == "RCPT":
+			st.mu.Lock()
+			code := st.rcpt[min(st.rcpts, len(st.rcpt)-1)]
+			st.rcpts++
+			st.mu.Unlock()
+			_ = tp.PrintfLine("%d rcpt", code)
+		case cmd == "DATA":
+			_ = tp.PrintfLine("354 go on")
+			b, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+			if err != nil {
+				_ = tp.PrintfLine("554 bad message")
+				continue
+			}
+			st.mu.Lock()
+			st.msgs = append(st.msgs, msg)
+			st.mu.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case cmd == "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (st *smtpStub) messages() []*mail.Message {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return append([]*mail.Message(nil), st.msgs...)
+}
+
+// stubMailer is a Mailer for st. With STARTTLS it trusts the certificate of an httptest TLS
+// server, which the stub presents.
+func stubMailer(t *testing.T, security string, rcpt ...int) (*Mailer, *smtpStub) {
+	from, _ := mail.ParseAddress("gpufindr alerts <alerts@gpufindr.test>")
+	m := &Mailer{host: "127.0.0.1", security: security, from: from}
+	var server *tls.Config
+	if security == smtpStartTLS {
+		ts := httptest.NewTLSServer(http.NotFoundHandler())
+		t.Cleanup(ts.Close)
+		server = &tls.Config{Certificates: ts.TLS.Certificates}
+		m.tls = &tls.Config{ServerName: "127.0.0.1", RootCAs: ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+	}
+	st := newSMTPStub(t, server, rcpt...)
+	m.port = st.ln.Addr().(*net.TCPAddr).Port
+	return m, st
+}
+
+func emailSearch(digest string, confirmed bool) storedSearch {
+	return storedSearch{
+		SavedSearch: SavedSearch{ID: "search-1", Name: "cheap h100", Query: "name=h100", Trigger: triggerBelow, MaxTotalCostPH: 2,
+			Email: &EmailTarget{Address: "me@example.com", Digest: digest, Confirmed: confirmed}},
+		Secret: "sekret",
+	}
+}
+
+func alertEvent(s storedSearch, price float64) AlertEvent {
+	return AlertEvent{Event: "alert", Search: s.SavedSearch, ScannedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), Matches: 1,
+		Offers: []GPU{{Name: "H100 SXM5", Source: "runpod", Location: "us-east-1", NumGPUs: 1, TotalCostPH: price}},
+		URL:    siteURL + "/search.html?" + s.Query}
+}
+
+func body(t *testing.T, msg *mail.Message) string {
+	t.Helper()
+	b, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+		b, err = io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(b))))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return string(b)
+}
+
+func TestMailerSends(t *testing.T) {
+	for _, security := range []string{smtpStartTLS, smtpPlain} {
+		m, st := stubMailer(t, security)
+		s := emailSearch(digestNone, true)
+		code, err := m.send(context.Background(), s, []AlertEvent{alertEvent(s, 1.99)})
+		if err != nil || code != 250 {
+			t.Fatalf("%s: send = %d, %v", security, code, err)
+		}
+		msgs := st.messages()
+		if len(msgs) != 1 {
+			t.Fatalf("%s: stub got %d messages", security, len(msgs))
+		}
+		h := msgs[0].Header
+		if h.Get("To") != "me@example.com" || !strings.Contains(h.Get("Subject"), "1 offers under $2.00/h") {
+			t.Errorf("%s: headers %v", security, h)
+		}
+		if want := "<" + unsubscribeURL(s) + ">"; h.Get("List-Unsubscribe") != want {
+			t.Errorf("%s: List-Unsubscribe = %q, want %q", security, h.Get("List-Unsubscribe"), want)
+		}
+		if h.Get("List-Unsubscribe-Post") != "List-Unsubscribe=One-Click" {
+			t.Errorf("%s: List-Unsubscribe-Post = %q", security, h.Get("List-Unsubscribe-Post"))
+		}
+	}
+}
+
+func TestEmailRetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		rcpt []int
+		want []string // oldest first
+	}{
+		{"4xx is retried", []int{451, 250}, []string{deliveryRetrying, deliveryDelivered}},
+		{"5xx is not", []int{550}, []string{deliveryFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, st := stubMailer(t, smtpPlain, tt.rcpt...)
+			a, store := testAlerts()
+			a.mailer = m
+			s := emailSearch(digestNone, true)
+			a.notify(context.Background(), s, alertEvent(s, 1.99))
+
+			log := waitDeliveries(t, store, s.ID, len(tt.want))
+			for i, d := range log {
+				if want := tt.want[len(tt.want)-1-i]; d.Status != want || d.Channel != channelEmail {
+					t.Errorf("attempt %d = %+v, want %s", d.Attempt, d, want)
+				}
+			}
+			if log[len(log)-1].HTTPStatus != tt.rcpt[0] {
+				t.Errorf("first attempt reply %d, want %d", log[len(log)-1].HTTPStatus, tt.rcpt[0])
+			}
+			if delivered := tt.want[len(tt.want)-1] == deliveryDelivered; delivered != (len(st.messages()) == 1) {
+				t.Errorf("stub got %d messages", len(st.messages()))
+			}
+		})
+	}
+}
+
+func TestDigestBatchesAlerts(t *testing.T) {
+	m, st := stubMailer(t, smtpPlain)
+	a, store := testAlerts()
+	a.mailer = m
+	s := emailSearch("hourly", true)
+	_ = store.CreateSearch(context.Background(), s)
+
+	a.notify(context.Background(), s, alertEvent(s, 1.99))
+	a.notify(context.Background(), s, alertEvent(s, 1.89))
+	if len(st.messages()) != 0 {
+		t.Fatal("a digest search mailed an alert straight away")
+	}
+	due := digestDue(s, time.Now())
+	a.sendDigests(context.Background(), due.Add(-time.Second))
+	a.sendDigests(context.Background(), due)
+	if d := waitDeliveries(t, store, s.ID, 1)[0]; d.Event != "digest" || d.Offers != 2 || d.Status != deliveryDelivered {
+		t.Errorf("digest delivery = %+v", d)
+	}
+	msgs := st.messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Header.Get("Subject"), "2 offers in your hourly digest") {
+		t.Fatalf("got %d messages, want one digest", len(msgs))
+	}
+
+	// Taken once: a later tick, or another instance, has nothing left to send.
+	if left, _ := store.TakeDigests(context.Background(), due.Add(time.Hour)); len(left) != 0 {
+		t.Errorf("digest still queued: %v", left)
+	}
+}
+
+func TestEmailNeedsConfirmation(t *testing.T) {
+	useCatalogue(t, testOffers())
+	m, st := stubMailer(t, smtpPlain)
+	a, store := testAlerts()
+	a.mailer = m
+	oldAlerts := alerts
+	t.Cleanup(func() { alerts = oldAlerts })
+	alerts = a
+
+	// Saving sends the confirmation and nothing else.
+	w := httptest.NewRecorder()
+	createSearchHandler(w, httptest.NewRequest("POST", "/searches", strings.NewReader(
+		`{"name":"cheap h100","query":"name=h100","trigger":"below","max_total_cost_ph":3,"email":{"address":"me@example.com","confirmed":true}}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body)
+	}
+	var created CreatedSearch
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Email.Confirmed {
+		t.Fatalf("created search is confirmed (%v)", err)
+	}
+	waitDeliveries(t, store, created.ID, 1)
+	msgs := st.messages()
+	if len(msgs) != 1 || !strings.HasPrefix(msgs[0].Header.Get("Subject"), "Confirm") {
+		t.Fatalf("got %d messages, want the confirmation", len(msgs))
+	}
+	text := body(t, msgs[0])
+	i := strings.Index(text, siteURL+"/confirm?token=")
+	if i < 0 {
+		t.Fatalf("no confirm link in %q", text)
+	}
+	link, _ := url.Parse(strings.Fields(text[i:])[0])
+	token := link.Query().Get("token")
+
+	// Test alerts do not reach an unconfirmed address.
+	test := func() {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/searches/"+created.ID+"/test", nil)
+		req.Header.Set("Authorization", "Bearer "+created.Token)
+		testSearchHandler(w, withURLParam(req, "id", created.ID))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("test: status %d: %s", w.Code, w.Body)
+		}
+	}
+	test()
+	time.Sleep(50 * time.Millisecond)
+	if n := len(st.messages()); n != 1 {
+		t.Fatalf("unconfirmed address got %d messages", n)
+	}
+
+	// The link's page only asks; posting it confirms.
+	w = httptest.NewRecorder()
+	confirmHandler(w, httptest.NewRequest("GET", "/confirm?token="+url.QueryEscape(token), nil))
+	if s, _ := store.GetSearch(context.Background(), created.ID); w.Code != http.StatusOK || s.Email.Confirmed {
+		t.Fatalf("GET /confirm: status %d, confirmed %v", w.Code, s.Email.Confirmed)
+	}
+	w = httptest.NewRecorder()
+	confirmPostHandler(w, httptest.NewRequest("POST", "/confirm?token="+url.QueryEscape(token+"x"), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("forged token: status %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	confirmPostHandler(w, httptest.NewRequest("POST", "/confirm?token="+url.QueryEscape(token), nil))
+	if s, _ := store.GetSearch(context.Background(), created.ID); w.Code != http.StatusOK || !s.Email.Confirmed {
+		t.Fatalf("POST /confirm: status %d, confirmed %v", w.Code, s.Email.Confirmed)
+	}
+
+	test()
+	waitDeliveries(t, store, created.ID, 2)
+	if msgs := st.messages(); len(msgs) != 2 || !strings.Contains(msgs[1].Header.Get("Subject"), "(test)") {
+		t.Errorf("confirmed address got %d messages, want the test alert", len(msgs))
+	}
+}
+
+func TestConfirmationsAreThrottled(t *testing.T) {
+	useCatalogue(t, testOffers())
+	m, st := stubMailer(t, smtpPlain)
+	a, _ := testAlerts()
+	a.mailer = m
+	oldAlerts := alerts
+	t.Cleanup(func() { alerts = oldAlerts })
+	alerts = a
+
+	create := func(addr, ip string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/searches", strings.NewReader(
+			`{"name":"cheap h100","query":"name=h100","trigger":"below","max_total_cost_ph":3,"email":{"address":"`+addr+`"}}`))
+		req.RemoteAddr = ip + ":4321"
+		createSearchHandler(w, req)
+		return w
+	}
+	if w := create("a@example.com", "192.0.2.1"); w.Code != http.StatusCreated {
+		t.Fatalf("first: status %d: %s", w.Code, w.Body)
+	}
+	// The address already has a confirmation waiting, whoever asks.
+	if w := create("a@example.com", "198.51.100.7"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("pending address: status %d", w.Code)
+	}
+	for i := range confirmPerIP - 1 {
+		if w := create(fmt.Sprintf("%c@example.com", 'b'+i), "192.0.2.1"); w.Code != http.StatusCreated {
+			t.Fatalf("address %d: status %d: %s", i+2, w.Code, w.Body)
+		}
+	}
+	w := create("z@example.com", "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("over the IP limit: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := create("z@example.com", "198.51.100.7"); w.Code != http.StatusCreated {
+		t.Errorf("another client: status %d", w.Code)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(st.messages()) < confirmPerIP+1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(st.messages()); n != confirmPerIP+1 {
+		t.Errorf("%d confirmations sent, want %d", n, confirmPerIP+1)
+	}
+}
+
+func TestSendLimiterWindow(t *testing.T) {
+	l := newSendLimiter(2, time.Hour)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for range 2 {
+		if ok, _ := l.allow("k", now); !ok {
+			t.Fatal("send within the limit refused")
+		}
+	}
+	if ok, wait := l.allow("k", now.Add(time.Minute)); ok || wait != 59*time.Minute {
+		t.Errorf("third send: %v, wait %v", ok, wait)
+	}
+	if ok, _ := l.allow("other", now); !ok {
+		t.Error("keys share a limit")
+	}
+	if ok, _ := l.allow("k", now.Add(time.Hour)); !ok {
+		t.Error("limit did not slide")
+	}
+	l.allow("late", now.Add(3*time.Hour))
+	if _, ok := l.sent["other"]; ok {
+		t.Error("idle key was kept")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("POST", "/searches", nil)
+	req.RemoteAddr = "10.0.0.9:5555"
+	req.Header.Add("X-Forwarded-For", "203.0.113.66, 198.51.100.1")
+	if ip := clientIP(req); ip != "10.0.0.9" {
+		t.Errorf("untrusted X-Forwarded-For: %s", ip)
+	}
+	old := trustProxy
+	t.Cleanup(func() { trustProxy = old })
+	trustProxy = true
+	if ip := clientIP(req); ip != "198.51.100.1" {
+		t.Errorf("behind a proxy: %s, want the hop it appended", ip)
+	}
+}
+
+// withURLParam returns r routed with the chi URL parameter key set to value.
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
@@ -0,0 +1,284 @@
+// internal/api/export.go
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumn is one column of an export. Keys and units follow the search page's column
+// picker: memory in GB (MB/1024) and network in Gbps (Mbps/1000).
+type exportColumn struct {
+	key     string
+	integer bool
+	column
+}
+
+func scaled(name, src string, div float64) column {
+	c := gpuColumns[src]
+	return numCol(name, func(g GPU) float64 { return c.num(g) / div })
+}
+
+// exportColumns is in search.html COLS order, which is also the default export layout.
+var exportColumns = func() []exportColumn {
+	col := func(key string) exportColumn { return exportColumn{key: key, column: gpuColumns[key]} }
+	ints := func(key string) exportColumn { c := col(key); c.integer = true; return c }
+	return []exportColumn{
+		col("source"),
+		col("location"),
+		col("name"),
+		ints("num_gpus"),
+		{key: "vram_gb", column: scaled("vram_gb", "vram_mb", 1024)},
+		{key: "ram_gb", column: scaled("ram_gb", "ram_mb", 1024)},
+		col("total_flops"),
+		col("flops_per_dollar_ph"),
+		col("total_cost_ph"),
+		col("reliability"),
+		col("gpu_mem_bw_gbps"),
+		col("cpu_cores"),
+		col("cpu_name"),
+		col("cpu_ghz"),
+		col("cpu_arch"),
+		col("disk_space_gb"),
+		col("disk_bw_gbps"),
+		col("disk_name"),
+		{key: "upload_gbps", column: scaled("upload_gbps", "upload_mbps", 1000)},
+		{key: "download_gbps", column: scaled("download_gbps", "download_mbps", 1000)},
+		col("gpu_cost_ph"),
+		col("disk_cost_ph"),
+		col("upload_cost_ph"),
+		col("download_cost_ph"),
+		col("score_dollar_ph"),
+		col("score"),
+		col("id"),
+		col("updated_at"),
+		col("url"),
+	}
+}()
+
+// parseExportCols reads cols, defaulting to every column.
+func parseExportCols(s string) ([]exportColumn, error) {
+	keys := splitList(s)
+	if len(keys) == 0 {
+		return exportColumns, nil
+	}
+	out := make([]exportColumn, 0, len(keys))
+	for _, k := range keys {
+		found := false
+		for _, c := range exportColumns {
+			if c.key == k {
+				out = append(out, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, badParam("cols", "unknown column %q", k)
+		}
+	}
+	return out, nil
+}
+
+// text renders a value for CSV.
+func (c exportColumn) text(g GPU) string {
+	switch c.kind {
+	case stringColumn:
+		return c.str(g)
+	case timeColumn:
+		return c.at(g).UTC().Format(time.RFC3339)
+	}
+	return strconv.FormatFloat(c.num(g), 'f', -1, 64)
+}
+
+// value renders a value for JSON.
+func (c exportColumn) value(g GPU) any {
+	switch c.kind {
+	case stringColumn:
+		return c.str(g)
+	case timeColumn:
+		return c.at(g).UTC()
+	}
+	if f := c.num(g); !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	return nil
+}
+
+var exportFormats = map[string]struct{ ext, contentType string }{
+	"csv":     {"csv", "text/csv; charset=utf-8"},
+	"ndjson":  {"ndjson", "application/x-ndjson"},
+	"parquet": {"parquet", "application/vnd.apache.parquet"},
+}
+
+// exportHandler godoc
+// @Summary     Export GPUs
+// @Description Downloads every offer matching the /gpus filters as CSV, newline-delimited JSON (streamed)
+// @Description or Parquet. cols takes the search page's column keys (vram_gb, upload_gbps, ...); all columns
+// @Description by default. The file name carries the scan time, e.g. gpus-20250814T2000Z.csv. limit is
+// @Description optional and not capped.
+// @Tags        gpus
+// @Produce     text/csv
+// @Produce     application/x-ndjson
+// @Produce     application/vnd.apache.parquet
+// @Param       format      query  string  false  "csv, ndjson or parquet" default(csv) Enums(csv, ndjson, parquet)
+// @Param       cols        query  string  false  "Comma-separated column keys, in output order"
+// @Param       source      query  string  false  "Provider, comma-separated for any of (e.g. vast,runpod)"
+// @Param       name        query  string  false  "GPU model or alias, comma-separated for any of"
+// @Param       location    query  string  false  "Case-insensitive substring match, comma-separated for any of"
+// @Param       filter      query  string  false  "Boolean expression, see /gpus"
+// @Param       sort        query  string  false  "Column.direction (e.g., updated_at.desc)" default(updated_at.desc)
+// @Param       limit       query  int     false  "Max rows"  minimum(1)
+// @Param       offset      query  int     false  "Rows to skip"  minimum(0)
+// @Success     200         {file}   file
+// @Failure     400         {object} ErrorResponse  "Bad request"
+// @Failure     502         {object} ErrorResponse  "Upstream error"
+// @Router      /gpus/export [get]
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	params := url.Values{}
+	for k, v := range r.URL.Query() {
+		params[k] = v
+	}
+	format := strings.ToLower(params.Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	params.Del("format")
+	f, ok := exportFormats[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, badParam("format", "must be csv, ndjson or parquet"))
+		return
+	}
+	if params.Has("cursor") {
+		writeError(w, http.StatusBadRequest, badParam("cursor", "not supported on exports"))
+		return
+	}
+	query, err := parseGPUQuery(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.Limit, query.Offset, err = parsePage(params, math.MaxInt32, math.MaxInt32); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	cols, err := parseExportCols(params.Get("cols"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	snap, err := catalogue.Snapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	rows, _ := snap.run(query)
+
+	stamp := snap.scannedAt
+	if stamp.IsZero() {
+		stamp = snap.loadedAt
+	}
+	w.Header().Set("Content-Type", f.contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="gpus-%s.%s"`, stamp.UTC().Format("20060102T1504Z"), f.ext))
+	w.Header().Set("X-Snapshot-Version", snap.version)
+
+	switch format {
+	case "csv":
+		writeCSV(w, cols, rows)
+	case "ndjson":
+		writeNDJSON(w, cols, rows)
+	case "parquet":
+		writeParquetExport(w, cols, rows)
+	}
+}
+
+func writeCSV(w http.ResponseWriter, cols []exportColumn, rows []GPU) {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		record[i] = c.key
+	}
+	_ = cw.Write(record)
+	for _, g := range rows {
+		for i, c := range cols {
+			record[i] = c.text(g)
+		}
+		if err := cw.Write(record); err != nil {
+			return
+		}
+	}
+	cw.Flush()
+}
+
+// writeNDJSON streams one object per line, flushing as it goes so large exports start at once.
+func writeNDJSON(w http.ResponseWriter, cols []exportColumn, rows []GPU) {
+	bw := bufio.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+	var val bytes.Buffer
+	enc := json.NewEncoder(&val)
+	enc.SetEscapeHTML(false)
+	for i, g := range rows {
+		// Marshal by hand to keep the columns in the requested order.
+		bw.WriteByte('{')
+		for j, c := range cols {
+			if j > 0 {
+				bw.WriteByte(',')
+			}
+			val.Reset()
+			_ = enc.Encode(c.key)
+			bw.Write(bytes.TrimSuffix(val.Bytes(), []byte("\n")))
+			bw.WriteByte(':')
+			val.Reset()
+			_ = enc.Encode(c.value(g))
+			bw.Write(bytes.TrimSuffix(val.Bytes(), []byte("\n")))
+		}
+		bw.WriteString("}\n")
+		if i%500 == 499 && flusher != nil {
+			if bw.Flush() != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+	_ = bw.Flush()
+}
+
+func writeParquetExport(w http.ResponseWriter, cols []exportColumn, rows []GPU) {
+	pcols := make([]*parquetColumn, len(cols))
+	for i, c := range cols {
+		pc := &parquetColumn{name: c.key, conv: -1}
+		switch {
+		case c.kind == stringColumn:
+			pc.typ, pc.conv = parquetByteArray, convertedUTF8
+			for _, g := range rows {
+				pc.strings = append(pc.strings, c.str(g))
+			}
+		case c.kind == timeColumn:
+			pc.typ, pc.conv = parquetInt64, convertedTimestampMillis
+			for _, g := range rows {
+				pc.int64s = append(pc.int64s, c.at(g).UnixMilli())
+			}
+		case c.integer:
+			pc.typ = parquetInt64
+			for _, g := range rows {
+				pc.int64s = append(pc.int64s, int64(c.num(g)))
+			}
+		default:
+			pc.typ = parquetDouble
+			for _, g := range rows {
+				pc.doubles = append(pc.doubles, c.num(g))
+			}
+		}
+		pcols[i] = pc
+	}
+	_ = writeParquet(w, len(rows), pcols)
+}
@@ -0,0 +1,145 @@
+// internal/api/export_test.go
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// export runs exportHandler on query.
+func export(t *testing.T, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	exportHandler(w, httptest.NewRequest("GET", "/gpus/export?"+query, nil))
+	return w
+}
+
+// vastByPrice are the vast offers of testOffers, cheapest first.
+var vastByPrice = []string{"offer-06", "offer-05", "offer-11", "offer-02"}
+
+func TestExportCSV(t *testing.T) {
+	useCatalogue(t, testOffers())
+
+	w := export(t, "source=vast&sort=total_cost_ph.asc&cols=id,vram_gb,num_gpus,total_cost_ph,updated_at")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("status %d, %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="gpus-20261001T1200Z.csv"` {
+		t.Errorf("Content-Disposition %q", cd)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || strings.Join(records[0], ",") != "id,vram_gb,num_gpus,total_cost_ph,updated_at" {
+		t.Fatalf("records = %v", records)
+	}
+	if got := strings.Join(records[1], ","); got != "offer-06,24,1,0.35,2026-10-01T11:54:00Z" {
+		t.Errorf("first row = %s", got)
+	}
+	for i, id := range vastByPrice {
+		if records[i+1][0] != id {
+			t.Errorf("row %d is %s, want %s", i+1, records[i+1][0], id)
+		}
+	}
+
+	// Every column by default, in the search page's order.
+	all, _ := csv.NewReader(export(t, "limit=1").Body).ReadAll()
+	if len(all) != 2 || len(all[0]) != len(exportColumns) || all[0][0] != "source" || all[0][len(all[0])-1] != "url" {
+		t.Errorf("default header = %v", all[0])
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	useCatalogue(t, testOffers())
+
+	w := export(t, "format=ndjson&source=vast&sort=total_cost_ph.asc&limit=2&offset=1&cols=total_cost_ph,id,ram_gb")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" ||
+		!strings.HasSuffix(w.Header().Get("Content-Disposition"), `.ndjson"`) {
+		t.Fatalf("status %d, %s, %s", w.Code, w.Header().Get("Content-Type"), w.Header().Get("Content-Disposition"))
+	}
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d lines: %s", len(lines), w.Body)
+	}
+	// Keys come in the requested order.
+	if !strings.HasPrefix(lines[0], `{"total_cost_ph":0.7,"id":"offer-05","ram_gb":0}`) {
+		t.Errorf("first line %s", lines[0])
+	}
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &row); err != nil || row["id"] != vastByPrice[2] || len(row) != 3 {
+		t.Errorf("second line %s (%v)", lines[1], err)
+	}
+}
+
+func TestExportParquet(t *testing.T) {
+	useCatalogue(t, testOffers())
+
+	w := export(t, "format=parquet&source=vast&sort=total_cost_ph.asc&cols=id,num_gpus,total_cost_ph")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/vnd.apache.parquet" {
+		t.Fatalf("status %d, %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	b := w.Body.Bytes()
+	if len(b) < 12 || string(b[:4]) != "PAR1" || string(b[len(b)-4:]) != "PAR1" {
+		t.Fatalf("not a Parquet file: %q", b)
+	}
+	metaLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	if metaLen <= 0 || metaLen > len(b)-12 {
+		t.Fatalf("footer length %d in a %d-byte file", metaLen, len(b))
+	}
+	meta := b[len(b)-8-metaLen : len(b)-8]
+	for _, name := range []string{"schema", "id", "num_gpus", "total_cost_ph", "gpufindr"} {
+		if !bytes.Contains(meta, []byte(name)) {
+			t.Errorf("footer lacks %s", name)
+		}
+	}
+
+	// The pages hold the values PLAIN-encoded, in row order.
+	var ids, gpus, prices bytes.Buffer
+	var n [8]byte
+	for _, id := range vastByPrice {
+		binary.LittleEndian.PutUint32(n[:4], uint32(len(id)))
+		ids.Write(n[:4])
+		ids.WriteString(id)
+		binary.LittleEndian.PutUint64(n[:], 1)
+		gpus.Write(n[:])
+	}
+	for _, p := range []float64{0.35, 0.70, 1.09, 1.29} {
+		binary.LittleEndian.PutUint64(n[:], math.Float64bits(p))
+		prices.Write(n[:])
+	}
+	data := b[4 : len(b)-8-metaLen]
+	for name, page := range map[string][]byte{"id": ids.Bytes(), "num_gpus": gpus.Bytes(), "total_cost_ph": prices.Bytes()} {
+		if !bytes.Contains(data, page) {
+			t.Errorf("no %s page with the vast offers in price order", name)
+		}
+	}
+}
+
+func TestExportRejects(t *testing.T) {
+	useCatalogue(t, testOffers())
+	for query, param := range map[string]string{
+		"format=xlsx":   "format",
+		"cursor=abc":    "cursor",
+		"cols=id,nope":  "cols",
+		"limit=0":       "limit",
+		"colour=red":    "colour",
+		"sort=nope.asc": "sort",
+	} {
+		w := export(t, query)
+		if w.Code != http.StatusBadRequest || apiError(t, w).Param != param {
+			t.Errorf("%s: status %d: %s", query, w.Code, w.Body)
+		}
+	}
+	// limit is not capped at a page.
+	if w := export(t, "limit=100000&cols=id"); w.Code != http.StatusOK || strings.Count(w.Body.String(), "\n") != 13 {
+		t.Errorf("large limit: status %d, %d lines", w.Code, strings.Count(w.Body.String(), "\n"))
+	}
+}
@@ -0,0 +1,277 @@
+// internal/api/featured.go
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// FeaturedCard is one pick for the front page
+// swagger:model FeaturedCard
+type FeaturedCard struct {
+	Strategy string `json:"strategy"`
+	Label    string `json:"label"`
+	Reason   string `json:"reason"`
+	Offer    GPU    `json:"offer"`
+}
+
+// featureStrategy picks up to n offers from cur. prev is the previous scan, nil until the API
+// has seen two. Add a strategy by appending it to featureStrategies.
+type featureStrategy struct {
+	name string
+	pick func(cur, prev *snapshot, n int) []FeaturedCard
+}
+
+// featureStrategies in front-page order.
+var featureStrategies = []featureStrategy{
+	{"top_score", pickTopScore},
+	{"student", pickStudent},
+	{"hottest", pickHottest},
+	{"best_value_per_tier", pickBestValuePerTier},
+	{"price_drop", pickPriceDrops},
+	{"new_high_end", pickNewHighEnd},
+	{"reliable_cheap", pickReliableCheap},
+}
+
+// highEndModels are the data-centre parts new_high_end looks out for.
+var highEndModels = map[string]bool{
+	"GB200": true, "B200": true, "GH200": true, "H200": true, "MI300X": true,
+	"H100 SXM": true, "H100 NVL": true, "H100 PCIe": true, "A100 SXM": true, "A100 PCIe": true,
+}
+
+// vramTiers split offers by memory per GPU for best_value_per_tier.
+var vramTiers = []struct {
+	name         string
+	minMB, maxMB int
+}{
+	{"24 GB or less", 0, 24 * 1024},
+	{"32-48 GB", 24*1024 + 1, 48 * 1024},
+	{"over 48 GB", 48*1024 + 1, 1 << 30},
+}
+
+// top returns the n best offers by better, skipping those keep rejects.
+func top(gpus []GPU, n int, keep func(GPU) bool, better func(a, b GPU) int) []GPU {
+	var out []GPU
+	for _, g := range gpus {
+		if keep(g) {
+			out = append(out, g)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b GPU) int {
+		if c := better(a, b); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return out[:min(n, len(out))]
+}
+
+func priced(g GPU) bool { return g.TotalCostPH > 0 }
+
+func pickTopScore(cur, _ *snapshot, n int) []FeaturedCard {
+	var cards []FeaturedCard
+	for _, g := range top(cur.gpus, n, priced, func(a, b GPU) int { return cmp.Compare(b.Score, a.Score) }) {
+		cards = append(cards, FeaturedCard{Label: "Best for Researchers",
+			Reason: fmt.Sprintf("Highest performance score on the market (%.1f)", g.Score), Offer: g})
+	}
+	return cards
+}
+
+func pickStudent(cur, _ *snapshot, n int) []FeaturedCard {
+	keep := func(g GPU) bool { return priced(g) && g.GpuCostPH < 2 }
+	var cards []FeaturedCard
+	for _, g := range top(cur.gpus, n, keep, func(a, b GPU) int { return cmp.Compare(b.Score, a.Score) }) {
+		cards = append(cards, FeaturedCard{Label: "Best for Students",
+			Reason: fmt.Sprintf("Highest score under $2/h (%.1f at $%.2f/h)", g.Score, g.GpuCostPH), Offer: g})
+	}
+	return cards
+}
+
+func pickHottest(cur, _ *snapshot, n int) []FeaturedCard {
+	keep := func(g GPU) bool { return priced(g) && g.GpuCostPH >= 1 }
+	var cards []FeaturedCard
+	for _, g := range top(cur.gpus, n, keep, func(a, b GPU) int { return cmp.Compare(b.ScoreDPH, a.ScoreDPH) }) {
+		cards = append(cards, FeaturedCard{Label: "Hottest Deal",
+			Reason: fmt.Sprintf("Best score per dollar among serious GPUs (%.1f per $/h)", g.ScoreDPH), Offer: g})
+	}
+	return cards
+}
+
+// pickBestValuePerTier returns the best score per dollar in each VRAM tier; n is per tier.
+func pickBestValuePerTier(cur, _ *snapshot, n int) []FeaturedCard {
+	var cards []FeaturedCard
+	for _, t := range vramTiers {
+		keep := func(g GPU) bool { return priced(g) && g.Vram >= t.minMB && g.Vram <= t.maxMB }
+		for _, g := range top(cur.gpus, n, keep, func(a, b GPU) int { return cmp.Compare(b.ScoreDPH, a.ScoreDPH) }) {
+			cards = append(cards, FeaturedCard{Label: "Best Value: " + t.name,
+				Reason: fmt.Sprintf("Best score per dollar in the %s VRAM tier (%.1f per $/h)", t.name, g.ScoreDPH), Offer: g})
+		}
+	}
+	return cards
+}
+
+// cheapestByKey indexes the lowest price of every offer in s by offerKey.
+func cheapestByKey(s *snapshot) map[string]float64 {
+	out := make(map[string]float64, len(s.gpus))
+	for _, g := range s.gpus {
+		k := offerKey(g)
+		if p, ok := out[k]; !ok || g.TotalCostPH < p {
+			out[k] = g.TotalCostPH
+		}
+	}
+	return out
+}
+
+// pickPriceDrops returns the offers whose price fell the most, relatively, since the previous scan.
+func pickPriceDrops(cur, prev *snapshot, n int) []FeaturedCard {
+	if prev == nil {
+		return nil
+	}
+	before, now := cheapestByKey(prev), cheapestByKey(cur)
+	drop := func(g GPU) float64 { return (before[offerKey(g)] - g.TotalCostPH) / before[offerKey(g)] }
+	keep := func(g GPU) bool {
+		// Compare like with like: the cheapest listing of an offer now against the cheapest before.
+		p, ok := before[offerKey(g)]
+		return ok && priced(g) && g.TotalCostPH == now[offerKey(g)] && p > g.TotalCostPH
+	}
+	var cards []FeaturedCard
+	for _, g := range top(cur.gpus, n, keep, func(a, b GPU) int { return cmp.Compare(drop(b), drop(a)) }) {
+		cards = append(cards, FeaturedCard{Label: "Price Drop",
+			Reason: fmt.Sprintf("Down %.0f%% since the last scan, $%.2f/h to $%.2f/h",
+				100*drop(g), before[offerKey(g)], g.TotalCostPH), Offer: g})
+	}
+	return cards
+}
+
+// pickNewHighEnd returns the cheapest newly listed offer of each high-end model.
+func pickNewHighEnd(cur, prev *snapshot, n int) []FeaturedCard {
+	if prev == nil {
+		return nil
+	}
+	before := cheapestByKey(prev)
+	byModel := map[string][]GPU{}
+	for _, g := range cur.gpus {
+		m := gpumodel.Canonical(g.Name)
+		if _, seen := before[offerKey(g)]; !seen && highEndModels[m] && priced(g) {
+			byModel[m] = append(byModel[m], g)
+		}
+	}
+	models := make([]string, 0, len(byModel))
+	for m := range byModel {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+
+	var cards []FeaturedCard
+	for _, m := range models {
+		g := top(byModel[m], 1, priced, func(a, b GPU) int { return cmp.Compare(a.TotalCostPH, b.TotalCostPH) })[0]
+		cards = append(cards, FeaturedCard{Label: "Newly Available",
+			Reason: fmt.Sprintf("New %s listing on %s at $%.2f/h", m, g.Source, g.TotalCostPH), Offer: g})
+	}
+	slices.SortStableFunc(cards, func(a, b FeaturedCard) int { return cmp.Compare(a.Offer.TotalCostPH, b.Offer.TotalCostPH) })
+	return cards[:min(n, len(cards))]
+}
+
+// pickReliableCheap returns the most reliable offers in the cheapest quarter of the market.
+func pickReliableCheap(cur, _ *snapshot, n int) []FeaturedCard {
+	var prices []float64
+	for _, g := range cur.gpus {
+		if priced(g) {
+			prices = append(prices, g.TotalCostPH)
+		}
+	}
+	sort.Float64s(prices)
+	ceiling := quantile(prices, 0.25)
+
+	keep := func(g GPU) bool { return priced(g) && g.TotalCostPH <= ceiling }
+	better := func(a, b GPU) int {
+		if c := cmp.Compare(b.Reliability, a.Reliability); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.TotalCostPH, b.TotalCostPH)
+	}
+	var cards []FeaturedCard
+	for _, g := range top(cur.gpus, n, keep, better) {
+		cards = append(cards, FeaturedCard{Label: "Reliable and Cheap",
+			Reason: fmt.Sprintf("%.0f%% reliability for $%.2f/h, in the cheapest quarter of offers",
+				100*g.Reliability, g.TotalCostPH), Offer: g})
+	}
+	return cards
+}
+
+func featureStrategyNames() []string {
+	names := make([]string, len(featureStrategies))
+	for i, s := range featureStrategies {
+		names[i] = s.name
+	}
+	return names
+}
+
+// featured runs the named strategies (all when names is empty) against the current catalogue.
+func featured(cur, prev *snapshot, names []string, n int) ([]FeaturedCard, error) {
+	var run []featureStrategy
+	for _, name := range names {
+		i := slices.IndexFunc(featureStrategies, func(s featureStrategy) bool { return s.name == name })
+		if i < 0 {
+			return nil, badParam("strategy", "unknown strategy %q", name)
+		}
+		run = append(run, featureStrategies[i])
+	}
+	if len(run) == 0 {
+		run = featureStrategies
+	}
+	cards := []FeaturedCard{}
+	for _, s := range run {
+		for _, c := range s.pick(cur, prev, n) {
+			c.Strategy = s.name
+			cards = append(cards, c)
+		}
+	}
+	return cards, nil
+}
+
+// featuredHandler godoc
+// @Summary     Featured GPUs
+// @Description Labelled picks for the front page, each with the reason it was chosen. Strategies: top_score,
+// @Description student, hottest, best_value_per_tier, price_drop, new_high_end, reliable_cheap. price_drop and
+// @Description new_high_end compare against the previous scan and return nothing until one is available.
+// @Tags        gpus
+// @Produce     json
+// @Param       strategy  query  string  false  "Comma-separated strategies, all by default"
+// @Param       limit     query  int     false  "Cards per strategy (per tier for best_value_per_tier)"  default(1) minimum(1) maximum(10)
+// @Success     200       {array}  FeaturedCard
+// @Failure     400       {object} ErrorResponse  "Bad request"
+// @Failure     502       {object} ErrorResponse  "Upstream error"
+// @Router      /gpus/featured [get]
+func featuredHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, paramSet("strategy", "limit")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	n, _, err := parsePage(q, 1, 10)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	snap, err := catalogue.Snapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	cards, err := featured(snap, catalogue.Before(snap), splitList(strings.ToLower(q.Get("strategy"))), n)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cards)
+}
@@ -0,0 +1,100 @@
+// internal/api/featured_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// featuredOffers is testOffers with score per dollar filled in and one reliable cheap 4090.
+func featuredOffers() []GPU {
+	gpus := testOffers()
+	for i := range gpus {
+		gpus[i].ScoreDPH = gpus[i].Score / gpus[i].TotalCostPH
+		gpus[i].Reliability = 0.9
+	}
+	gpus[7].Reliability = 0.99
+	return gpus
+}
+
+// getFeatured runs featuredHandler on query and returns the offer ids by strategy.
+func getFeatured(t *testing.T, query string) (*httptest.ResponseRecorder, map[string][]string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	featuredHandler(w, httptest.NewRequest("GET", "/gpus/featured?"+query, nil))
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+	var cards []FeaturedCard
+	if err := json.Unmarshal(w.Body.Bytes(), &cards); err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string][]string{}
+	for _, c := range cards {
+		if c.Label == "" || c.Reason == "" {
+			t.Errorf("%s card for %s has no label or reason", c.Strategy, c.Offer.Id)
+		}
+		ids[c.Strategy] = append(ids[c.Strategy], c.Offer.Id)
+	}
+	return w, ids
+}
+
+func TestFeatured(t *testing.T) {
+	path := useCatalogue(t, featuredOffers())
+
+	_, got := getFeatured(t, "")
+	want := map[string]string{
+		"top_score":           "offer-00",
+		"student":             "offer-02",                   // best score under $2/h
+		"hottest":             "offer-11",                   // best score per dollar at $1/h or more
+		"best_value_per_tier": "offer-06,offer-10,offer-02", // 24 GB, 48 GB, 80 GB
+		"reliable_cheap":      "offer-07",
+	}
+	for s, ids := range want {
+		if g := strings.Join(got[s], ","); g != ids {
+			t.Errorf("%s = %s, want %s", s, g, ids)
+		}
+	}
+	// The scan-over-scan strategies wait for a second scan.
+	if len(got["price_drop"]) != 0 || len(got["new_high_end"]) != 0 || len(got) != len(want) {
+		t.Errorf("first scan: %v", got)
+	}
+
+	next := rescan(featuredOffers(), 2, func(gpus []GPU) { gpus[8].TotalCostPH = 0.30 })
+	next = append(next, GPU{Id: "offer-nvl", Name: "H100 NVL", Source: "vast", Location: "us-east-1", NumGPUs: 1, Vram: 95830, TotalCostPH: 2.99})
+	writeOffers(t, path, next)
+	if err := catalogue.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, got = getFeatured(t, "strategy=price_drop,NEW_HIGH_END&limit=3")
+	if g := strings.Join(got["price_drop"], ","); g != "offer-08-2" {
+		t.Errorf("price_drop = %s, want the runpod L4", g)
+	}
+	if g := strings.Join(got["new_high_end"], ","); g != "offer-nvl" || len(got) != 2 {
+		t.Errorf("new_high_end = %s, want the new H100 NVL: %v", g, got)
+	}
+}
+
+func TestFeaturedLimit(t *testing.T) {
+	useCatalogue(t, featuredOffers())
+	_, got := getFeatured(t, "strategy=top_score,best_value_per_tier&limit=2")
+	if g := strings.Join(got["top_score"], ","); g != "offer-00,offer-01" {
+		t.Errorf("top_score = %s", g)
+	}
+	if len(got["best_value_per_tier"]) != 6 {
+		t.Errorf("best_value_per_tier = %v, want 2 per tier", got["best_value_per_tier"])
+	}
+	if _, got := getFeatured(t, "strategy=student&limit=50"); len(got["student"]) != 10 {
+		t.Errorf("limit=50 gave %d cards, want it capped at 10", len(got["student"]))
+	}
+
+	for query, param := range map[string]string{"strategy=cheapest": "strategy", "limit=0": "limit", "colour=red": "colour"} {
+		if w, _ := getFeatured(t, query); w.Code != http.StatusBadRequest || apiError(t, w).Param != param {
+			t.Errorf("%s: status %d: %s", query, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+// internal/api/feeds.go
+package api
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// Feeds publish the blog and the deals found in scan diffs as Atom (RFC 4287) and JSON Feed 1.1,
+// so people can follow them in any reader.
+
+const (
+	feedEntries = 50
+	// dealDropPct is how far, in percent, the cheapest listing of an offer must fall to be a deal.
+	dealDropPct = 10.0
+	dealWindow  = 7 * 24 * time.Hour
+)
+
+// DealFilter selects the scan_diffs rows behind the deals feeds: price drops of at least
+// MinDropPct percent and first listings of a model, scanned since Since.
+type DealFilter struct {
+	Since      time.Time
+	MinDropPct float64
+	Model      string // canonical model name
+	Source     string // lower-cased source
+	Limit      int
+}
+
+// feed is what both formats are rendered from.
+type feed struct {
+	Title    string
+	Subtitle string
+	Home     string // page the feed is about
+	Self     string // the feed itself
+	Updated  time.Time
+	Entries  []feedEntry
+}
+
+type feedEntry struct {
+	ID        string
+	Title     string
+	URL       string
+	Summary   string
+	Content   string // plain text or markdown
+	Published time.Time
+	Tags      []string
+}
+
+// Atom
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomAuthor  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func (f feed) atom() ([]byte, error) {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Now() // Atom requires one even with no entries
+	}
+	out := atomFeed{
+		ID:       f.Self,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.Self},
+			{Rel: "alternate", Type: "text/html", Href: f.Home},
+		},
+		Author:  atomAuthor{Name: "gpufindr", URI: siteURL},
+		Entries: make([]atomEntry, len(f.Entries)),
+	}
+	for i, e := range f.Entries {
+		at := e.Published.UTC().Format(time.RFC3339)
+		a := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Updated:   at,
+			Published: at,
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: e.URL}},
+		}
+		if e.Summary != "" {
+			a.Summary = &atomText{Type: "text", Body: e.Summary}
+		}
+		if e.Content != "" {
+			a.Content = &atomText{Type: "text", Body: e.Content}
+		}
+		for _, t := range e.Tags {
+			a.Categories = append(a.Categories, atomCategory{Term: t})
+		}
+		out.Entries[i] = a
+	}
+	b, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// JSON Feed
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Authors     []jsonAuthor   `json:"authors"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	Summary       string   `json:"summary,omitempty"`
+	ContentText   string   `json:"content_text"`
+	DatePublished string   `json:"date_published"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+func (f feed) jsonFeed() ([]byte, error) {
+	out := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Subtitle,
+		HomePageURL: f.Home,
+		FeedURL:     f.Self,
+		Authors:     []jsonAuthor{{Name: "gpufindr", URL: siteURL}},
+		Items:       make([]jsonFeedItem, len(f.Entries)),
+	}
+	for i, e := range f.Entries {
+		out.Items[i] = jsonFeedItem{
+			ID:            e.ID,
+			URL:           e.URL,
+			Title:         e.Title,
+			Summary:       e.Summary,
+			ContentText:   cmp.Or(e.Content, e.Summary),
+			DatePublished: e.Published.UTC().Format(time.RFC3339),
+			Tags:          e.Tags,
+		}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// writeFeed renders f in the format named by the {format} path parameter. Last-Modified lets
+// readers poll with If-Modified-Since.
+func writeFeed(w http.ResponseWriter, r *http.Request, f feed) {
+	var (
+		body []byte
+		err  error
+	)
+	switch chi.URLParam(r, "format") {
+	case "atom":
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		body, err = f.atom()
+	case "json":
+		w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+		body, err = f.jsonFeed()
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown feed format %q (atom or json)", chi.URLParam(r, "format")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
+
+// feedUpdated is when the newest entry was published, or the zero time for an empty feed.
+func feedUpdated(entries []feedEntry) time.Time {
+	var t time.Time
+	for _, e := range entries {
+		if e.Published.After(t) {
+			t = e.Published
+		}
+	}
+	return t
+}
+
+// blogFeedHandler godoc
+// @Summary     Blog feed
+// @Description The latest blog posts as Atom or JSON Feed. Content is the post's markdown.
+// @Tags        feeds
+// @Produce     application/atom+xml
+// @Produce     application/feed+json
+// @Param       format  path  string  true  "atom or json"  Enums(atom, json)
+// @Success     200  {string} string "Atom or JSON Feed document"
+// @Failure     404  {object} ErrorResponse  "Unknown format"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /feeds/blog.{format} [get]
+func blogFeedHandler(w http.ResponseWriter, r *http.Request) {
+	posts, err := loadPosts(r.Context(), feedEntries)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	home := siteURL + "/blog.html"
+	entries := make([]feedEntry, len(posts))
+	for i, p := range posts {
+		anchor := cmp.Or(p.Slug, fmt.Sprint(p.CreatedAt.Unix()))
+		entries[i] = feedEntry{
+			ID:        home + "#" + anchor,
+			Title:     p.Title,
+			URL:       home + "#" + anchor,
+			Summary:   postSummary(p.Data),
+			Content:   p.Data,
+			Published: p.CreatedAt,
+		}
+	}
+	writeFeed(w, r, feed{
+		Title:    "gpufindr blog",
+		Subtitle: "Notes on the GPU cloud market",
+		Home:     home,
+		Self:     siteURL + r.URL.Path,
+		Updated:  feedUpdated(entries),
+		Entries:  entries,
+	})
+}
+
+// postSummary is the first paragraph of a markdown post that is not a heading, cut to a tweet.
+func postSummary(md string) string {
+	for _, p := range strings.Split(md, "\n\n") {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		p = strings.Join(strings.Fields(p), " ")
+		if r := []rune(p); len(r) > 280 {
+			p = strings.TrimSpace(string(r[:279])) + "…"
+		}
+		return p
+	}
+	return ""
+}
+
+// dealsFeedHandler godoc
+// @Summary     Deals feed
+// @Description Price drops of at least 10% on an offer's cheapest listing, and models listed for the first time,
+// @Description from the last week of scans, as Atom or JSON Feed.
+// @Tags        feeds
+// @Produce     application/atom+xml
+// @Produce     application/feed+json
+// @Param       format  path  string  true  "atom or json"  Enums(atom, json)
+// @Success     200  {string} string "Atom or JSON Feed document"
+// @Failure     404  {object} ErrorResponse  "Unknown format"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /feeds/deals.{format} [get]
+func dealsFeedHandler(w http.ResponseWriter, r *http.Request) {
+	f := DealFilter{Since: time.Now().Add(-dealWindow), MinDropPct: dealDropPct, Limit: maxLimit}
+	title, home := "gpufindr deals", siteURL+"/search.html"
+	if m := chi.URLParam(r, "model"); m != "" {
+		found, ok := gpumodel.Lookup(m)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown GPU model %q", m))
+			return
+		}
+		f.Model = found.Name
+		title += ": " + found.Name
+		home += "?" + url.Values{"name": {found.Name}}.Encode()
+	}
+	if s := chi.URLParam(r, "source"); s != "" {
+		f.Source = strings.ToLower(s)
+		title += " on " + f.Source
+		home += "?" + url.Values{"source": {f.Source}}.Encode()
+	}
+	events, err := catalogue.store.LoadDeals(r.Context(), f)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	entries := dealEntries(events)
+	writeFeed(w, r, feed{
+		Title:    title,
+		Subtitle: fmt.Sprintf("GPU offers that got %g%% cheaper or were listed for the first time", dealDropPct),
+		Home:     home,
+		Self:     siteURL + r.URL.Path,
+		Updated:  feedUpdated(entries),
+		Entries:  entries,
+	})
+}
+
+// modelDealsFeedHandler godoc
+// @Summary     Deals feed for a model
+// @Description The deals feed restricted to one GPU model.
+// @Tags        feeds
+// @Produce     application/atom+xml
+// @Produce     application/feed+json
+// @Param       model   path  string  true  "GPU model, e.g. h100-sxm"
+// @Param       format  path  string  true  "atom or json"  Enums(atom, json)
+// @Success     200  {string} string "Atom or JSON Feed document"
+// @Failure     404  {object} ErrorResponse  "Unknown format or model"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /feeds/models/{model}/deals.{format} [get]
+func modelDealsFeedHandler(w http.ResponseWriter, r *http.Request) { dealsFeedHandler(w, r) }
+
+// providerDealsFeedHandler godoc
+// @Summary     Deals feed for a provider
+// @Description The deals feed restricted to one source.
+// @Tags        feeds
+// @Produce     application/atom+xml
+// @Produce     application/feed+json
+// @Param       source  path  string  true  "Source, e.g. runpod"
+// @Param       format  path  string  true  "atom or json"  Enums(atom, json)
+// @Success     200  {string} string "Atom or JSON Feed document"
+// @Failure     404  {object} ErrorResponse  "Unknown format"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /feeds/providers/{source}/deals.{format} [get]
+func providerDealsFeedHandler(w http.ResponseWriter, r *http.Request) { dealsFeedHandler(w, r) }
+
+// dealEntries turns deal events into entries: one per price drop, and one per model and scan for
+// first listings however many offers came with it.
+func dealEntries(events []ScanEvent) []feedEntry {
+	var out []feedEntry
+	launches := map[string][]ScanEvent{} // scan_id|model -> added offers
+	var order []string
+	for _, e := range events {
+		if e.NewModel {
+			k := e.ScanID + "|" + e.Model
+			if launches[k] == nil {
+				order = append(order, k)
+			}
+			launches[k] = append(launches[k], e)
+			continue
+		}
+		out = append(out, priceDropEntry(e))
+	}
+	for _, k := range order {
+		out = append(out, launchEntry(launches[k]))
+	}
+	slices.SortStableFunc(out, func(a, b feedEntry) int { return b.Published.Compare(a.Published) })
+	if len(out) > feedEntries {
+		out = out[:feedEntries]
+	}
+	return out
+}
+
+func priceDropEntry(e ScanEvent) feedEntry {
+	shape := e.Name
+	if e.NumGPUs > 1 {
+		shape = fmt.Sprintf("%d× %s", e.NumGPUs, e.Name)
+	}
+	where := e.Source
+	if e.Location != "" {
+		where += " (" + e.Location + ")"
+	}
+	q := url.Values{"name": {e.Name}, "source": {e.Source}}
+	if e.Location != "" {
+		q.Set("location", e.Location)
+	}
+	return feedEntry{
+		ID:    siteURL + "/scans/" + e.ScanID + "/diff#" + url.PathEscape(e.Key),
+		Title: fmt.Sprintf("%s on %s: $%.2f/h → $%.2f/h (%.0f%%)", shape, where, e.PrevCostPH, e.CostPH, e.DeltaPct),
+		URL:   siteURL + "/search.html?" + q.Encode(),
+		Summary: fmt.Sprintf("The cheapest %s listing on %s fell from $%.2f to $%.2f an hour, %.0f%% less.",
+			shape, where, e.PrevCostPH, e.CostPH, -e.DeltaPct),
+		Published: e.ScannedAt,
+		Tags:      dealTags(e),
+	}
+}
+
+func launchEntry(offers []ScanEvent) feedEntry {
+	first := offers[0]
+	cheapest := slices.MinFunc(offers, func(a, b ScanEvent) int { return cmp.Compare(a.CostPH, b.CostPH) })
+	var sources []string
+	for _, o := range offers {
+		if !slices.Contains(sources, o.Source) {
+			sources = append(sources, o.Source)
+		}
+	}
+	slices.Sort(sources)
+	n := fmt.Sprintf("%d offers", len(offers))
+	if len(offers) == 1 {
+		n = "1 offer"
+	}
+	return feedEntry{
+		ID:    siteURL + "/scans/" + first.ScanID + "/diff#new-" + url.PathEscape(first.Model),
+		Title: "New on gpufindr: " + first.Model,
+		URL:   siteURL + "/search.html?" + url.Values{"name": {first.Model}}.Encode(),
+		Summary: fmt.Sprintf("%s is listed for the first time: %s on %s, from $%.2f/h.",
+			first.Model, n, strings.Join(sources, ", "), cheapest.CostPH),
+		Published: first.ScannedAt,
+		Tags:      append([]string{first.Model}, sources...),
+	}
+}
+
+func dealTags(e ScanEvent) []string {
+	if e.Model == "" {
+		return []string{e.Source}
+	}
+	return []string{e.Model, e.Source}
+}
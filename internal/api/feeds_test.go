@@ -0,0 +1,214 @@
+// internal/api/feeds_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// dealStore is a file store with deals, recording the filter it was last asked for.
+type dealStore struct {
+	fileStore
+	deals  []ScanEvent
+	filter *DealFilter
+}
+
+func (s dealStore) LoadDeals(ctx context.Context, f DealFilter) ([]ScanEvent, error) {
+	*s.filter = f
+	return s.deals, nil
+}
+
+var dealsAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// useDeals serves the feeds over a catalogue whose store holds a price drop on an 8× H100 and the
+// first B200s, listed on two providers an hour later. It returns the router and the last filter.
+func useDeals(t *testing.T) (http.Handler, *DealFilter) {
+	t.Helper()
+	path := useCatalogue(t, testOffers())
+	launch := func(source string, cost float64) ScanEvent {
+		return ScanEvent{ScanID: "scan-3", ScannedAt: dealsAt.Add(time.Hour), Model: "B200", NewModel: true,
+			Event: scandiff.Event{Type: scandiff.Added, Key: source + "|B200", Source: source, Name: "B200", CostPH: cost}}
+	}
+	f := &DealFilter{}
+	catalogue.store = dealStore{fileStore: fileStore{path: path}, filter: f, deals: []ScanEvent{
+		{ScanID: "scan-2", ScannedAt: dealsAt, Model: "H100 SXM", Event: scandiff.Event{Type: scandiff.PriceDown,
+			Key: "runpod|H100 SXM5 80GB|8|us-east-1", Source: "runpod", Name: "H100 SXM5 80GB", NumGPUs: 8, Location: "us-east-1",
+			PrevCostPH: 20, CostPH: 16, Delta: -4, DeltaPct: -20}},
+		launch("vast", 40),
+		launch("lambda", 38),
+	}}
+	r := chi.NewRouter()
+	r.Get("/feeds/deals.{format}", dealsFeedHandler)
+	r.Get("/feeds/models/{model}/deals.{format}", modelDealsFeedHandler)
+	r.Get("/feeds/providers/{source}/deals.{format}", providerDealsFeedHandler)
+	r.Get("/feeds/blog.{format}", blogFeedHandler)
+	return r, f
+}
+
+func getFeed(h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestDealsAtomFeed(t *testing.T) {
+	h, filter := useDeals(t)
+	w := getFeed(h, "/feeds/deals.atom")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("status %d, %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if filter.MinDropPct != dealDropPct || time.Since(filter.Since) < dealWindow-time.Minute || filter.Model != "" || filter.Source != "" {
+		t.Errorf("filter = %+v", filter)
+	}
+
+	var doc atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "gpufindr deals" || doc.Updated != "2026-10-01T13:00:00Z" || doc.Links[0].Href != siteURL+"/feeds/deals.atom" {
+		t.Errorf("feed = %+v", doc)
+	}
+	// Newest first, and the two B200 listings make one entry.
+	if len(doc.Entries) != 2 {
+		t.Fatalf("%d entries", len(doc.Entries))
+	}
+	launch, drop := doc.Entries[0], doc.Entries[1]
+	if launch.Title != "New on gpufindr: B200" || launch.Summary == nil ||
+		launch.Summary.Body != "B200 is listed for the first time: 2 offers on lambda, vast, from $38.00/h." {
+		t.Errorf("launch = %+v", launch)
+	}
+	if drop.Title != "8× H100 SXM5 80GB on runpod (us-east-1): $20.00/h → $16.00/h (-20%)" || drop.Published != "2026-10-01T12:00:00Z" {
+		t.Errorf("drop = %+v", drop)
+	}
+	if len(drop.Categories) != 2 || drop.Categories[0].Term != "H100 SXM" || !strings.Contains(drop.Links[0].Href, "source=runpod") {
+		t.Errorf("drop categories %v, link %v", drop.Categories, drop.Links)
+	}
+
+	// Readers polling with the feed's Last-Modified get a 304.
+	if w := getFeed(h, "/feeds/deals.atom", "If-Modified-Since", w.Header().Get("Last-Modified")); w.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since: status %d", w.Code)
+	}
+}
+
+func TestDealsJSONFeed(t *testing.T) {
+	h, filter := useDeals(t)
+	w := getFeed(h, "/feeds/models/h100-sxm/deals.json")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/feed+json; charset=utf-8" {
+		t.Fatalf("status %d, %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if filter.Model != "H100 SXM" {
+		t.Errorf("model filter %q", filter.Model)
+	}
+	var doc jsonFeed
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "https://jsonfeed.org/version/1.1" || doc.Title != "gpufindr deals: H100 SXM" ||
+		doc.FeedURL != siteURL+"/feeds/models/h100-sxm/deals.json" || !strings.Contains(doc.HomePageURL, "name=H100+SXM") {
+		t.Errorf("feed = %+v", doc)
+	}
+	if len(doc.Items) != 2 || doc.Items[1].ContentText != doc.Items[1].Summary || doc.Items[1].DatePublished != "2026-10-01T12:00:00Z" {
+		t.Errorf("items = %+v", doc.Items)
+	}
+
+	if getFeed(h, "/feeds/providers/RunPod/deals.json"); filter.Source != "runpod" || filter.Model != "" {
+		t.Errorf("provider filter = %+v", filter)
+	}
+	for _, path := range []string{"/feeds/deals.rss", "/feeds/models/voodoo2/deals.atom"} {
+		if w := getFeed(h, path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d", path, w.Code)
+		}
+	}
+}
+
+func TestBlogFeed(t *testing.T) {
+	h, _ := useDeals(t)
+	posts := []BlogPost{
+		{Slug: "h100-prices", Title: "H100 prices", Data: "# H100 prices\n\nThey  fell\nagain.\n\nMore below.", CreatedAt: dealsAt},
+		{Title: "Untitled", Data: "Short.", CreatedAt: dealsAt.Add(-24 * time.Hour)},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/v1/blogs" || r.URL.Query().Get("order") != "created_at.desc" {
+			t.Errorf("GET %s", r.URL)
+		}
+		_ = json.NewEncoder(w).Encode(posts)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("SUPABASE_URL", srv.URL)
+	t.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	var doc jsonFeed
+	if err := json.Unmarshal(getFeed(h, "/feeds/blog.json").Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Items) != 2 {
+		t.Fatalf("items = %+v", doc.Items)
+	}
+	first := doc.Items[0]
+	if first.ID != siteURL+"/blog.html#h100-prices" || first.Summary != "They fell again." || first.ContentText != posts[0].Data {
+		t.Errorf("first post = %+v", first)
+	}
+	// Posts without a slug are anchored on their creation time.
+	if want := siteURL + "/blog.html#" + strconv.FormatInt(posts[1].CreatedAt.Unix(), 10); doc.Items[1].URL != want {
+		t.Errorf("second post URL %s, want %s", doc.Items[1].URL, want)
+	}
+}
+
+func TestPostSummary(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	for md, want := range map[string]string{
+		"# Title\n\nFirst  para.\n\nSecond.": "First para.",
+		"## Only headings":                   "",
+		long:                                 strings.TrimSpace(long[:279]) + "…",
+	} {
+		if got := postSummary(md); got != want {
+			t.Errorf("postSummary(%.20q) = %q, want %q", md, got, want)
+		}
+	}
+}
+
+func TestSupabaseLoadDealsQuery(t *testing.T) {
+	var got url.Values
+	pg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/v1/scan_diffs" {
+			t.Errorf("GET %s", r.URL)
+		}
+		got = r.URL.Query()
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(pg.Close)
+	old := supabaseURL
+	t.Cleanup(func() { supabaseURL = old })
+	supabaseURL = pg.URL
+
+	f := DealFilter{Since: dealsAt, MinDropPct: dealDropPct, Model: "H100 SXM", Source: "runpod", Limit: 50}
+	if _, err := (supabaseStore{}).LoadDeals(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{
+		"or":         "(and(type.eq.price_down,delta_pct.lte.-10),new_model.is.true)",
+		"scanned_at": "gte.2026-10-01T12:00:00Z",
+		"model":      "eq.H100 SXM",
+		"source":     "eq.runpod",
+		"limit":      "50",
+	} {
+		if got.Get(k) != want {
+			t.Errorf("%s = %q, want %q", k, got.Get(k), want)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// internal/api/filter.go
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// predicate reports whether an offer passes a filter.
+type predicate func(GPU) bool
+
+// The filter= parameter on /gpus takes a boolean expression over GPU columns:
+//
+//	expr   = and { OR and }
+//	and    = unary { AND unary }
+//	unary  = NOT unary | "(" expr ")" | cond
+//	cond   = field op value
+//	op     = ":" | "=" | "!=" | "<" | "<=" | ">" | ">=" | "~"
+//	value  = word | "quoted string"
+//
+// field is any GPU column (vram_mb, source, cpu_arch, ...) or "model". Keywords are
+// case-insensitive. ":" is membership: it takes a comma-separated list and, on name and
+// model, matches canonical models and aliases like the name= parameter. "~" is a
+// case-insensitive substring match. Numbers compare numerically; updated_at takes a quoted
+// RFC 3339 time.
+//
+//	(source:vast,runpod OR model:h100) AND vram_mb>=81920 AND NOT cpu_arch:arm64
+//	updated_at>="2025-08-01T00:00:00Z" AND location~europe
+func parseFilter(src string) (predicate, error) {
+	p := &filterParser{lex: filterLexer{src: src}}
+	p.next()
+	pred, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	return pred, nil
+}
+
+type filterError struct {
+	pos int
+	msg string
+}
+
+func (e *filterError) Error() string {
+	return fmt.Sprintf("filter: at %d: %s", e.pos, e.msg)
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+type filterLexer struct {
+	src string
+	pos int
+}
+
+func isOpChar(c byte) bool {
+	return strings.IndexByte(":=!<>~", c) >= 0
+}
+
+func (l *filterLexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	switch c := l.src[l.pos]; {
+	case c == '(':
+		l.pos++
+		return token{tokLParen, "(", start}, nil
+	case c == ')':
+		l.pos++
+		return token{tokRParen, ")", start}, nil
+	case c == '"':
+		l.pos++
+		var b strings.Builder
+		for l.pos < len(l.src) && l.src[l.pos] != '"' {
+			if l.src[l.pos] == '\\' && l.pos+1 < len(l.src) {
+				l.pos++
+			}
+			b.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return token{}, &filterError{start, "unterminated string"}
+		}
+		l.pos++
+		return token{tokString, b.String(), start}, nil
+	case isOpChar(c):
+		for l.pos < len(l.src) && isOpChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{tokOp, l.src[start:l.pos], start}, nil
+	}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if unicode.IsSpace(rune(c)) || c == '(' || c == ')' || c == '"' || isOpChar(c) {
+			break
+		}
+		l.pos++
+	}
+	return token{tokWord, l.src[start:l.pos], start}, nil
+}
+
+type filterParser struct {
+	lex filterLexer
+	tok token
+	err error
+}
+
+func (p *filterParser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &filterError{p.tok.pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) keyword(kw string) bool {
+	return p.tok.kind == tokWord && strings.EqualFold(p.tok.text, kw)
+}
+
+func (p *filterParser) expr() (predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(g GPU) bool { return l(g) || right(g) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(g GPU) bool { return l(g) && right(g) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (predicate, error) {
+	switch {
+	case p.keyword("not"):
+		p.next()
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(g GPU) bool { return !inner(g) }, nil
+	case p.tok.kind == tokLParen:
+		p.next()
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return inner, nil
+	}
+	return p.cond()
+}
+
+func (p *filterParser) cond() (predicate, error) {
+	if p.tok.kind != tokWord {
+		return nil, p.errorf("expected field name")
+	}
+	field, fieldPos := strings.ToLower(p.tok.text), p.tok.pos
+	p.next()
+	if p.tok.kind != tokOp {
+		return nil, p.errorf("expected operator after %s", field)
+	}
+	op := p.tok.text
+	p.next()
+	if p.tok.kind != tokWord && p.tok.kind != tokString {
+		return nil, p.errorf("expected value")
+	}
+	value := p.tok.text
+	p.next()
+
+	pred, err := condition(field, op, value)
+	if err != nil {
+		return nil, &filterError{fieldPos, err.Error()}
+	}
+	return pred, nil
+}
+
+// condition builds the predicate for a single "field op value".
+func condition(field, op, value string) (predicate, error) {
+	if field == "model" || field == "name" {
+		switch op {
+		case ":":
+			return anyOf(splitList(value), func(term string) predicate { return nameMatcher(term) }), nil
+		case "=", "!=", "~":
+		default:
+			return nil, fmt.Errorf("operator %s not supported on %s", op, field)
+		}
+		if field == "model" {
+			return stringCondition(field, op, value, func(g GPU) string { return gpumodel.Canonical(g.Name) })
+		}
+	}
+
+	col, ok := gpuColumns[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+	switch col.kind {
+	case stringColumn:
+		if field == "cpu_arch" && op == ":" {
+			return anyOf(splitList(value), func(arch string) predicate {
+				arch = normalizeArch(arch)
+				return func(g GPU) bool { return normalizeArch(g.CpuArch) == arch }
+			}), nil
+		}
+		return stringCondition(field, op, value, col.str)
+	case timeColumn:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs an RFC 3339 time", field)
+		}
+		cmpFn, err := comparison(op)
+		if err != nil {
+			return nil, err
+		}
+		return func(g GPU) bool { return cmpFn(col.at(g).Compare(t)) }, nil
+	}
+
+	if op == ":" {
+		var nums []float64
+		for _, s := range splitList(value) {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s needs numbers, got %q", field, s)
+			}
+			nums = append(nums, f)
+		}
+		return func(g GPU) bool {
+			v := col.num(g)
+			for _, f := range nums {
+				if v == f {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s needs a number, got %q", field, value)
+	}
+	cmpFn, err := comparison(op)
+	if err != nil {
+		return nil, err
+	}
+	return func(g GPU) bool {
+		v := col.num(g)
+		switch {
+		case v < f:
+			return cmpFn(-1)
+		case v > f:
+			return cmpFn(1)
+		}
+		return cmpFn(0)
+	}, nil
+}
+
+func stringCondition(field, op, value string, get func(GPU) string) (predicate, error) {
+	lv := strings.ToLower(value)
+	switch op {
+	case ":":
+		set := map[string]bool{}
+		for _, s := range splitList(lv) {
+			set[s] = true
+		}
+		return func(g GPU) bool { return set[strings.ToLower(get(g))] }, nil
+	case "=":
+		return func(g GPU) bool { return strings.EqualFold(get(g), value) }, nil
+	case "!=":
+		return func(g GPU) bool { return !strings.EqualFold(get(g), value) }, nil
+	case "~":
+		return func(g GPU) bool { return strings.Contains(strings.ToLower(get(g)), lv) }, nil
+	}
+	return nil, fmt.Errorf("operator %s not supported on %s", op, field)
+}
+
+// comparison maps an operator onto the sign of a three-way compare.
+func comparison(op string) (func(int) bool, error) {
+	switch op {
+	case "=":
+		return func(c int) bool { return c == 0 }, nil
+	case "!=":
+		return func(c int) bool { return c != 0 }, nil
+	case "<":
+		return func(c int) bool { return c < 0 }, nil
+	case "<=":
+		return func(c int) bool { return c <= 0 }, nil
+	case ">":
+		return func(c int) bool { return c > 0 }, nil
+	case ">=":
+		return func(c int) bool { return c >= 0 }, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+func anyOf(values []string, build func(string) predicate) predicate {
+	preds := make([]predicate, 0, len(values))
+	for _, v := range values {
+		preds = append(preds, build(v))
+	}
+	return func(g GPU) bool {
+		for _, p := range preds {
+			if p(g) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// splitList splits a comma-separated parameter, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// normalizeArch folds the spellings providers use for the same CPU architecture.
+func normalizeArch(s string) string {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "x86_64", "x86-64", "x64", "amd64", "x86":
+		return "amd64"
+	case "aarch64", "arm64", "arm":
+		return "arm64"
+	}
+	return s
+}
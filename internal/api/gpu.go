@@ -0,0 +1,239 @@
+// api/gpus.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GPU is the response schema
+// swagger:model GPU
+type GPU struct {
+	// Instance details
+	Id          string  `json:"id" bson:"_id"`
+	Location    string  `json:"location" bson:"location"`
+	Reliability float64 `json:"reliability" bson:"reliability"`
+	Duration    float64 `json:"duration_hours" bson:"duration_hours"`
+	Source      string  `json:"source" bson:"source"` // e.g., "tensordock", "vast", etc.
+	Score       float64 `json:"score" bson:"score"`
+	ScoreDPH    float64 `json:"score_dollar_ph" bson:"score_dollar_ph"`
+	Url         string  `json:"url" bson:"url"`
+	// GPU details
+	Name              string  `json:"name" bson:"name"`
+	Vram              int     `json:"vram_mb" bson:"vram_mb"`
+	TotalFlops        float64 `json:"total_flops" bson:"total_flops"`
+	GpuMemoryBandwith float64 `json:"gpu_mem_bw_gbps" bson:"gpu_mem_bw_gbps"`
+	NumGPUs           int     `json:"num_gpus" bson:"num_gpus"`
+	// CPU specs
+	CpuCores float64 `json:"cpu_cores" bson:"cpu_cores"`
+	CpuName  string  `json:"cpu_name" bson:"cpu_name"`
+	CpuGhz   float64 `json:"cpu_ghz" bson:"cpu_ghz"`
+	CpuArch  string  `json:"cpu_arch" bson:"cpu_arch"`
+	// Ram
+	Ram int `json:"ram_mb" bson:"ram_mb"`
+	// SSD
+	DiskSpace float64 `json:"disk_space_gb" bson:"disk_space_gb"`
+	DiskBW    float64 `json:"disk_bw_gbps" bson:"disk_bw_gbps"`
+	DiskName  string  `json:"disk_name" bson:"disk_name"`
+	// Internet
+	UploadSpeed   float64 `json:"upload_mbps" bson:"upload_mbps"`
+	DownloadSpeed float64 `json:"download_mbps" bson:"download_mbps"`
+	// Cost
+	TotalCostPH      float64 `json:"total_cost_ph" bson:"total_cost_ph"` // PH = per hour
+	GpuCostPH        float64 `json:"gpu_cost_ph" bson:"gpu_cost_ph"`
+	DiskCostPH       float64 `json:"disk_cost_ph" bson:"disk_cost_ph"`
+	UploadCostPH     float64 `json:"upload_cost_ph" bson:"upload_cost_ph"`
+	DownloadCostPH   float64 `json:"download_cost_ph" bson:"download_cost_ph"`
+	FlopsPerDollarPH float64 `json:"flops_per_dollar_ph" bson:"flops_per_dollar_ph"`
+
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Count is the response schema for /gpus/count
+// swagger:model Count
+type Count struct {
+	Count int `json:"count" bson:"count"`
+}
+
+var (
+	supabaseURL = "https://eteavfeiumodbjywzqfa.supabase.co"
+	anonKey     = strings.TrimSpace(os.Getenv("SUPABASE_ANON_KEY"))
+	httpc       = &http.Client{Timeout: 10 * time.Second}
+	// siteURL is the public front end, used for links in notifications.
+	siteURL = func() string {
+		if u := strings.TrimSpace(os.Getenv("SITE_URL")); u != "" {
+			return strings.TrimRight(u, "/")
+		}
+		return "https://gpufindr.com"
+	}()
+	// trustProxy says every request comes through a reverse proxy that sets X-Forwarded-For,
+	// -Proto and -Host itself (TRUST_PROXY=1); otherwise clients could forge them.
+	trustProxy = os.Getenv("TRUST_PROXY") == "1"
+)
+
+func mustEnv(k string) string {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		panic("missing env var: " + k)
+	}
+	return v
+}
+
+// getHandler godoc
+// @Summary     List GPUs
+// @Description Returns a JSON array of GPU offers (read-only), served from an in-memory copy of the catalogue.
+// @Description When fit_model or fit_params is set, only offers whose vram_mb × num_gpus hold the
+// @Description estimated memory are returned (cheapest first unless sort is given) and the estimate is
+// @Description reported in the X-Required-Vram-Mb header.
+// @Description All filters are combined with AND. Unknown parameters, unknown columns and malformed values
+// @Description return 400 with an ErrorResponse; limit is clamped to 1000.
+// @Description X-Total-Count carries the number of matching offers. When there are more, X-Next-Cursor and a
+// @Description Link rel="next" header point at the next page of the same scan; cursors outlive a few scans and
+// @Description then return 410.
+// @Tags        gpus
+// @Produce     json
+// @Param       source      query  string  false  "Provider, comma-separated for any of (e.g. vast,runpod)"
+// @Param       location    query  string  false  "Case-insensitive substring match, comma-separated for any of"
+// @Param       max_price   query  number  false  "Max total_cost_ph (alias of max_total_cost_ph)"
+// @Param       min_flopsd  query  number  false  "Min flops_per_dollar_ph (alias of min_flops_per_dollar_ph)"
+// @Param       name        query  string  false  "GPU model or alias, comma-separated for any of (e.g. h100, A100 SXM, 4090)"
+// @Param       cpu_arch    query  string  false  "CPU architecture, comma-separated for any of (x86_64/amd64, arm64/aarch64)"
+// @Param       min_vram_mb      query  number  false  "Min vram_mb per GPU. Every numeric column takes min_<column> and max_<column>"
+// @Param       max_vram_mb      query  number  false  "Max vram_mb per GPU"
+// @Param       min_num_gpus     query  number  false  "Min num_gpus"
+// @Param       max_num_gpus     query  number  false  "Max num_gpus"
+// @Param       min_score        query  number  false  "Min score"
+// @Param       min_reliability  query  number  false  "Min reliability (0-1)"
+// @Param       min_ram_mb       query  number  false  "Min ram_mb"
+// @Param       min_disk_space_gb query number  false  "Min disk_space_gb"
+// @Param       min_download_mbps query number  false  "Min download_mbps"
+// @Param       min_upload_mbps  query  number  false  "Min upload_mbps"
+// @Param       filter      query  string  false  "Boolean expression, e.g. (source:vast OR model:h100) AND vram_mb>=81920 AND NOT cpu_arch:arm64. Operators : = != < <= > >= ~"
+// @Param       sort        query  string  false  "Column.direction (e.g., updated_at.desc)" default(updated_at.desc)
+// @Param       limit       query  int     false  "Limit (1-1000, larger values are clamped)"  default(200) minimum(1) maximum(1000)
+// @Param       offset      query  int     false  "Offset for pagination"                     minimum(0)
+// @Param       cursor      query  string  false  "Opaque X-Next-Cursor from the previous page; replaces offset and keeps paging on the same scan"
+// @Param       fit_model         query  string  false  "Model preset to fit (e.g. llama-3-70b, mixtral-8x7b)"
+// @Param       fit_params        query  number  false  "Model size in billions of parameters (overrides preset)"
+// @Param       fit_layers        query  int     false  "Transformer layers (overrides preset)"
+// @Param       fit_hidden        query  int     false  "Hidden size (overrides preset)"
+// @Param       fit_heads         query  int     false  "Attention heads (overrides preset)"
+// @Param       fit_kv_heads      query  int     false  "KV heads for grouped-query attention (overrides preset)"
+// @Param       fit_precision     query  string  false  "Weight precision: fp32, fp16, bf16, fp8, int8, int4" default(fp16)
+// @Param       fit_kv_precision  query  string  false  "KV cache precision (defaults to weight precision, fp16 for int8/int4)"
+// @Param       fit_batch         query  int     false  "Batch size" default(1)
+// @Param       fit_context       query  int     false  "Context length in tokens" default(4096)
+// @Param       fit_mode          query  string  false  "inference or training" default(inference)
+// @Param       fit_checkpointing query  bool    false  "Activation checkpointing (training only)"
+// @Success     200         {array}  GPU
+// @Header      200         {int}    X-Total-Count       "Offers matching the filters"
+// @Header      200         {string} X-Snapshot-Version  "Scan the page was served from"
+// @Header      200         {string} X-Next-Cursor       "Cursor for the next page, absent on the last page"
+// @Header      200         {string} Link                "rel=next URL for the next page"
+// @Failure     400         {object} ErrorResponse  "Bad request"
+// @Failure     410         {object} ErrorResponse  "Cursor expired"
+// @Failure     502         {object} ErrorResponse  "Upstream error"
+// @Router      /gpus [get]
+func getHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query, err := parseGPUQuery(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	cur, err := parseCursor(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var snap *snapshot
+	if cur != nil {
+		var ok bool
+		if snap, ok = catalogue.At(cur.Version); !ok {
+			writeError(w, http.StatusGone, errors.New("cursor has expired, start again without it"))
+			return
+		}
+		query.Offset = cur.Offset
+	} else if snap, err = catalogue.Snapshot(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	rows, total := snap.run(query)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("X-Snapshot-Version", snap.version)
+	if end := query.Offset + len(rows); end < total {
+		next := cursor{Version: snap.version, Query: queryKey(params), Offset: end}
+		w.Header().Set("X-Next-Cursor", next.String())
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextPageURL(r.URL, next)))
+	}
+	if query.Fit != nil {
+		w.Header().Set("X-Required-Vram-Mb", strconv.FormatFloat(math.Ceil(query.Fit.TotalMB), 'f', 0, 64))
+	}
+	_ = json.NewEncoder(w).Encode(append([]GPU{}, rows...))
+}
+
+// countHandler godoc
+// @Summary     Count number of GPUs
+// @Description Returns the number of GPU offers matching the same filters as /gpus.
+// @Tags        gpus
+// @Produce     json
+// @Param       source      query  string  false  "Provider, comma-separated for any of (e.g. vast,runpod)"
+// @Param       name        query  string  false  "GPU model or alias, comma-separated for any of"
+// @Param       location    query  string  false  "Case-insensitive substring match, comma-separated for any of"
+// @Param       filter      query  string  false  "Boolean expression, see /gpus"
+// @Success     200         {object}  Count
+// @Failure     400         {object}  ErrorResponse  "Bad request"
+// @Failure     502         {object}  ErrorResponse  "Upstream error"
+// @Router      /gpus/count [get]
+func countHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseGPUQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	snap, err := catalogue.Snapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	_, count := snap.run(query)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Count{Count: count})
+}
+
+// gpuHandler godoc
+// @Summary     Get one GPU offer
+// @Description Returns the offer with this id from the current scan, or from the store if it has just dropped out.
+// @Tags        gpus
+// @Produce     json
+// @Param       id   path  string  true  "Offer id from /gpus"
+// @Success     200  {object}  GPU
+// @Failure     404  {object}  ErrorResponse  "Unknown offer"
+// @Failure     502  {object}  ErrorResponse  "Upstream error"
+// @Router      /gpus/{id} [get]
+func gpuHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	g, err := catalogue.Get(r.Context(), id)
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no offer %q", id))
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(g)
+}
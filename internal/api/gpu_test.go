@@ -0,0 +1,168 @@
+// internal/api/gpu_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// getGPUs runs getHandler on query and decodes the page.
+func getGPUs(t *testing.T, query string) (*httptest.ResponseRecorder, []GPU) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	getHandler(w, httptest.NewRequest("GET", "/gpus?"+query, nil))
+	var page []GPU
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode %s: %v", query, err)
+		}
+	}
+	return w, page
+}
+
+func TestGetGPUs(t *testing.T) {
+	useCatalogue(t, testOffers())
+
+	tests := []struct {
+		query string
+		ids   []string
+		total int
+	}{
+		{"sort=total_cost_ph.asc&limit=3", []string{"offer-06", "offer-08", "offer-07"}, 12},
+		{"name=h100&sort=total_cost_ph.asc", []string{"offer-00", "offer-01"}, 2},
+		{"source=lambda&max_total_cost_ph=0.7&sort=total_cost_ph.asc", []string{"offer-09", "offer-04"}, 2},
+		{"location=eu-west&name=l40s", []string{"offer-11"}, 1},
+		{"source=lambda,LAMBDA,lambda&name=h100", []string{"offer-01"}, 1},
+		{"name=nothing-like-this", nil, 0},
+	}
+	for _, tt := range tests {
+		w, page := getGPUs(t, tt.query)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d: %s", tt.query, w.Code, w.Body)
+			continue
+		}
+		var ids []string
+		for _, g := range page {
+			ids = append(ids, g.Id)
+		}
+		if strconv.Itoa(tt.total) != w.Header().Get("X-Total-Count") {
+			t.Errorf("%s: X-Total-Count = %s, want %d", tt.query, w.Header().Get("X-Total-Count"), tt.total)
+		}
+		if len(ids) != len(tt.ids) {
+			t.Errorf("%s: got %v, want %v", tt.query, ids, tt.ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.ids[i] {
+				t.Errorf("%s: got %v, want %v", tt.query, ids, tt.ids)
+				break
+			}
+		}
+	}
+}
+
+func TestGetGPUsBadParams(t *testing.T) {
+	useCatalogue(t, testOffers())
+	for _, q := range []string{"limit=0", "sort=nope.asc", "max_total_cost_ph=cheap", "cursor=not-a-cursor"} {
+		if w, _ := getGPUs(t, q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", q, w.Code)
+		}
+	}
+}
+
+func TestCountGPUs(t *testing.T) {
+	useCatalogue(t, testOffers())
+	for query, want := range map[string]int{"": 12, "source=vast": 4, "name=a100": 2, "source=vast,runpod&name=4090": 2} {
+		w := httptest.NewRecorder()
+		countHandler(w, httptest.NewRequest("GET", "/gpus/count?"+query, nil))
+		var c Count
+		if err := json.Unmarshal(w.Body.Bytes(), &c); err != nil || c.Count != want {
+			t.Errorf("count %q = %d (%v), want %d", query, c.Count, err, want)
+		}
+	}
+}
+
+func TestCursorPagingStaysOnScan(t *testing.T) {
+	gpus := testOffers()
+	path := useCatalogue(t, gpus)
+
+	query := url.Values{"sort": {"total_cost_ph.asc"}, "limit": {"5"}}
+	seen := map[string]bool{}
+	w, page := getGPUs(t, query.Encode())
+	version := w.Header().Get("X-Snapshot-Version")
+
+	// A new scan lands between pages; the cursor keeps reading the old one.
+	writeOffers(t, path, rescan(gpus, 1, func(gpus []GPU) { gpus[0].TotalCostPH = 0.01 }))
+	if err := catalogue.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for pages := 1; ; pages++ {
+		for _, g := range page {
+			if seen[g.Id] {
+				t.Fatalf("page %d repeats %s", pages, g.Id)
+			}
+			seen[g.Id] = true
+		}
+		if got := w.Header().Get("X-Snapshot-Version"); got != version {
+			t.Fatalf("page %d is from scan %s, want %s", pages, got, version)
+		}
+		next := w.Header().Get("X-Next-Cursor")
+		if next == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		if w.Header().Get("Link") == "" {
+			t.Error("X-Next-Cursor without a Link header")
+		}
+		q := url.Values{"sort": {"total_cost_ph.asc"}, "limit": {"5"}, "cursor": {next}}
+		w, page = getGPUs(t, q.Encode())
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: status %d: %s", pages+1, w.Code, w.Body)
+		}
+	}
+	if len(seen) != len(gpus) {
+		t.Errorf("paged through %d offers, want %d", len(seen), len(gpus))
+	}
+}
+
+func TestCursorRejectsOtherQuery(t *testing.T) {
+	useCatalogue(t, testOffers())
+	w, _ := getGPUs(t, "sort=total_cost_ph.asc&limit=2")
+	next := w.Header().Get("X-Next-Cursor")
+	if next == "" {
+		t.Fatal("no cursor on the first page")
+	}
+	q := url.Values{"sort": {"score.desc"}, "limit": {"2"}, "cursor": {next}}
+	if w, _ := getGPUs(t, q.Encode()); w.Code != http.StatusBadRequest {
+		t.Errorf("cursor for another query: status %d, want 400", w.Code)
+	}
+	q = url.Values{"sort": {"total_cost_ph.asc"}, "limit": {"2"}, "cursor": {next}, "offset": {"4"}}
+	if w, _ := getGPUs(t, q.Encode()); w.Code != http.StatusBadRequest {
+		t.Errorf("cursor with offset: status %d, want 400", w.Code)
+	}
+}
+
+func TestCursorExpires(t *testing.T) {
+	gpus := testOffers()
+	path := useCatalogue(t, gpus)
+	w, _ := getGPUs(t, "limit=2")
+	next := w.Header().Get("X-Next-Cursor")
+	for i := 1; i <= snapshotsKept+1; i++ {
+		writeOffers(t, path, rescan(gpus, i, nil))
+		if err := catalogue.Refresh(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q := url.Values{"limit": {"2"}, "cursor": {next}}
+	if w, _ := getGPUs(t, q.Encode()); w.Code != http.StatusGone {
+		t.Errorf("expired cursor: status %d, want 410", w.Code)
+	}
+}
@@ -0,0 +1,360 @@
+// internal/api/history.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// HistoryRow is one offer as seen by one scan, from the gpu_history table the scanner appends to.
+type HistoryRow struct {
+	ScannedAt   time.Time `json:"scanned_at"`
+	OfferID     string    `json:"offer_id"`
+	Source      string    `json:"source"`
+	Location    string    `json:"location"`
+	Name        string    `json:"name"`
+	Model       string    `json:"model"`
+	NumGPUs     int       `json:"num_gpus"`
+	TotalCostPH float64   `json:"total_cost_ph"`
+}
+
+func historyRowOf(g GPU, at time.Time) HistoryRow {
+	return HistoryRow{
+		ScannedAt:   at,
+		OfferID:     g.Id,
+		Source:      g.Source,
+		Location:    g.Location,
+		Name:        g.Name,
+		Model:       gpumodel.Canonical(g.Name),
+		NumGPUs:     g.NumGPUs,
+		TotalCostPH: g.TotalCostPH,
+	}
+}
+
+// HistoryFilter selects history rows. Empty fields match everything; Region is a
+// case-insensitive substring of location, the rest are exact.
+type HistoryFilter struct {
+	Model    string
+	Source   string
+	Location string
+	Region   string
+	Name     string
+	NumGPUs  int
+	From, To time.Time
+}
+
+func (f HistoryFilter) match(r HistoryRow) bool {
+	switch {
+	case r.ScannedAt.Before(f.From) || !r.ScannedAt.Before(f.To),
+		f.Model != "" && r.Model != f.Model,
+		f.Source != "" && r.Source != f.Source,
+		f.Location != "" && r.Location != f.Location,
+		f.Region != "" && !strings.Contains(strings.ToLower(r.Location), strings.ToLower(f.Region)),
+		f.Name != "" && r.Name != f.Name,
+		f.NumGPUs > 0 && r.NumGPUs != f.NumGPUs:
+		return false
+	}
+	return true
+}
+
+// offerFilter matches earlier sightings of g. Offer ids change every scan, so an offer is
+// recognised by provider, GPU, GPU count and location.
+func offerFilter(g GPU) HistoryFilter {
+	return HistoryFilter{Source: g.Source, Name: g.Name, NumGPUs: g.NumGPUs, Location: g.Location}
+}
+
+// offerKey is the same identity as a string, for matching offers between snapshots.
+func offerKey(g GPU) string {
+	return diffOffer(g).Key()
+}
+
+// PriceHistory is the response schema for the history endpoints
+// swagger:model PriceHistory
+type PriceHistory struct {
+	Model  string         `json:"model,omitempty"`
+	Offer  *GPU           `json:"offer,omitempty"`
+	Bucket string         `json:"bucket"`
+	From   time.Time      `json:"from"`
+	To     time.Time      `json:"to"`
+	Points []HistoryPoint `json:"points"`
+}
+
+// HistoryPoint aggregates the scans that fall in one bucket. Prices are total_cost_ph over every
+// sighting in the bucket; availability counts come from the bucket's latest scan.
+type HistoryPoint struct {
+	Start     time.Time      `json:"start"`
+	Scans     int            `json:"scans"`
+	MinCostPH float64        `json:"min_cost_ph"`
+	MedCostPH float64        `json:"median_cost_ph"`
+	P90CostPH float64        `json:"p90_cost_ph"`
+	Offers    int            `json:"offers"`
+	GPUs      int            `json:"gpus"`
+	BySource  map[string]int `json:"by_source"`
+	ByRegion  map[string]int `json:"by_region"`
+}
+
+const (
+	defaultHistoryRange = 7 * 24 * time.Hour
+	maxHistoryRange     = 90 * 24 * time.Hour
+	maxHistoryPoints    = 2000
+)
+
+// parseSpan reads a duration, also accepting whole days ("7d").
+func parseSpan(param, s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, badParam(param, "must be a positive duration like 1h, 6h or 1d")
+}
+
+// formatSpan is the inverse of parseSpan: 24h is "1d", 90m is "1h30m", 30m is "30m".
+func formatSpan(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+	var b strings.Builder
+	if h := d / time.Hour; h > 0 {
+		b.WriteString(strconv.Itoa(int(h)) + "h")
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		b.WriteString(strconv.Itoa(int(m)) + "m")
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+// parseHistoryWindow reads bucket, range, from and to into f and returns the bucket size.
+func parseHistoryWindow(q url.Values, f *HistoryFilter) (time.Duration, error) {
+	f.To = time.Now().UTC()
+	if s := q.Get("to"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return 0, badParam("to", "must be an RFC 3339 time")
+		}
+		f.To = t.UTC()
+	}
+	span := defaultHistoryRange
+	if s := q.Get("range"); s != "" {
+		d, err := parseSpan("range", s)
+		if err != nil {
+			return 0, err
+		}
+		span = d
+	}
+	f.From = f.To.Add(-span)
+	if s := q.Get("from"); s != "" {
+		if q.Has("range") {
+			return 0, badParam("from", "cannot be combined with range")
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return 0, badParam("from", "must be an RFC 3339 time")
+		}
+		f.From = t.UTC()
+	}
+	if !f.From.Before(f.To) {
+		return 0, badParam("from", "must be before to")
+	}
+	if f.To.Sub(f.From) > maxHistoryRange {
+		return 0, badParam("range", "at most 90d")
+	}
+
+	bucket := time.Hour
+	if s := q.Get("bucket"); s != "" {
+		d, err := parseSpan("bucket", s)
+		if err != nil {
+			return 0, err
+		}
+		bucket = d
+	}
+	if f.To.Sub(f.From)/bucket > maxHistoryPoints {
+		return 0, badParam("bucket", "too small for the range, at most %d buckets", maxHistoryPoints)
+	}
+	return bucket, nil
+}
+
+// bucketHistory groups rows into buckets aligned to multiples of size since the epoch, like
+// gpu_history_buckets does in the database.
+func bucketHistory(rows []HistoryRow, size time.Duration) []HistoryPoint {
+	sort.SliceStable(rows, func(a, b int) bool { return rows[a].ScannedAt.Before(rows[b].ScannedAt) })
+	points := []HistoryPoint{}
+	for i := 0; i < len(rows); {
+		start := bucketStart(rows[i].ScannedAt, size)
+		j := i
+		for j < len(rows) && bucketStart(rows[j].ScannedAt, size).Equal(start) {
+			j++
+		}
+		points = append(points, summarise(rows[i:j], start))
+		i = j
+	}
+	return points
+}
+
+// bucketStart is the start of the size bucket holding t.
+func bucketStart(t time.Time, size time.Duration) time.Time {
+	return time.Unix(0, t.UnixNano()-t.UnixNano()%int64(size)).UTC()
+}
+
+func summarise(rows []HistoryRow, start time.Time) HistoryPoint {
+	p := HistoryPoint{Start: start, BySource: map[string]int{}, ByRegion: map[string]int{}}
+	var prices []float64
+	latest := rows[len(rows)-1].ScannedAt
+	for k, r := range rows {
+		if k == 0 || !r.ScannedAt.Equal(rows[k-1].ScannedAt) {
+			p.Scans++
+		}
+		if r.TotalCostPH > 0 {
+			prices = append(prices, r.TotalCostPH)
+		}
+		if r.ScannedAt.Equal(latest) {
+			p.Offers++
+			p.GPUs += max(r.NumGPUs, 1)
+			p.BySource[r.Source]++
+			p.ByRegion[r.Location]++
+		}
+	}
+	sort.Float64s(prices)
+	p.MinCostPH = quantile(prices, 0)
+	p.MedCostPH = quantile(prices, 0.5)
+	p.P90CostPH = quantile(prices, 0.9)
+	return p
+}
+
+// quantile interpolates the q-th quantile of sorted values; 0 when there are none.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := min(lo+1, len(sorted)-1)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func writeHistory(w http.ResponseWriter, r *http.Request, f HistoryFilter, bucket time.Duration, out PriceHistory) {
+	points, err := catalogue.store.LoadHistory(r.Context(), f, bucket)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	out.Bucket = formatSpan(bucket)
+	out.From, out.To = f.From, f.To
+	out.Points = points
+	if out.Points == nil {
+		out.Points = []HistoryPoint{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+var historyParams = paramSet("bucket", "range", "from", "to")
+
+// offerHistoryHandler godoc
+// @Summary     Price history of an offer
+// @Description Time-bucketed total_cost_ph (min, median, p90) and availability for the offer with this id.
+// @Description Offer ids change every scan, so earlier sightings are matched on source, name, num_gpus and
+// @Description location. Buckets without scans are omitted.
+// @Tags        history
+// @Produce     json
+// @Param       id      path   string  true   "Offer id from /gpus"
+// @Param       bucket  query  string  false  "Bucket size, e.g. 1h, 6h, 1d"  default(1h)
+// @Param       range   query  string  false  "How far back from to, e.g. 24h, 30d (max 90d)"  default(7d)
+// @Param       from    query  string  false  "Start (RFC 3339), instead of range"
+// @Param       to      query  string  false  "End (RFC 3339)"  default(now)
+// @Success     200     {object} PriceHistory
+// @Failure     400     {object} ErrorResponse  "Bad request"
+// @Failure     404     {object} ErrorResponse  "Unknown offer"
+// @Failure     502     {object} ErrorResponse  "Upstream error"
+// @Router      /gpus/{id}/history [get]
+func offerHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, historyParams); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	g, err := catalogue.Get(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no offer %q", chi.URLParam(r, "id")))
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	f := offerFilter(g)
+	bucket, err := parseHistoryWindow(q, &f)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeHistory(w, r, f, bucket, PriceHistory{Model: gpumodel.Canonical(g.Name), Offer: &g})
+}
+
+// modelHistoryHandler godoc
+// @Summary     Price history of a GPU model
+// @Description Time-bucketed total_cost_ph (min, median, p90) across every offer of a canonical model, with
+// @Description offer and GPU counts per source and region. model is a name, slug or alias (h100-sxm, A100 PCIe, 4090);
+// @Description one that matches several models, such as h100, is a 400 listing them.
+// @Tags        history
+// @Produce     json
+// @Param       model     path   string  true   "GPU model"
+// @Param       source    query  string  false  "Only this provider"
+// @Param       location  query  string  false  "Case-insensitive substring of location"
+// @Param       bucket    query  string  false  "Bucket size, e.g. 1h, 6h, 1d"  default(1h)
+// @Param       range     query  string  false  "How far back from to, e.g. 24h, 30d (max 90d)"  default(7d)
+// @Param       from      query  string  false  "Start (RFC 3339), instead of range"
+// @Param       to        query  string  false  "End (RFC 3339)"  default(now)
+// @Success     200       {object} PriceHistory
+// @Failure     400       {object} ErrorResponse  "Bad request or ambiguous model"
+// @Failure     404       {object} ErrorResponse  "Unknown model"
+// @Failure     502       {object} ErrorResponse  "Upstream error"
+// @Router      /models/{model}/history [get]
+func modelHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, func(k string) bool { return historyParams(k) || k == "source" || k == "location" }); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	name := chi.URLParam(r, "model")
+	// History is kept per canonical model; a family name such as h100 has to pick one.
+	candidates := gpumodel.Candidates(name)
+	if len(candidates) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown GPU model %q", name))
+		return
+	}
+	if len(candidates) > 1 {
+		slugs := make([]string, len(candidates))
+		for i, m := range candidates {
+			slugs[i] = m.Slug
+		}
+		writeError(w, http.StatusBadRequest, badParam("model", "%q matches %s; name one", name, strings.Join(slugs, ", ")))
+		return
+	}
+	m := candidates[0]
+	f := HistoryFilter{Model: m.Name, Source: strings.ToLower(q.Get("source")), Region: q.Get("location")}
+	bucket, err := parseHistoryWindow(q, &f)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeHistory(w, r, f, bucket, PriceHistory{Model: m.Name})
+}
@@ -0,0 +1,86 @@
+// internal/api/history_test.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSpan(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		10 * time.Minute:              "10m",
+		30 * time.Minute:              "30m",
+		50 * time.Second:              "50s",
+		90 * time.Minute:              "1h30m",
+		time.Hour:                     "1h",
+		6 * time.Hour:                 "6h",
+		24 * time.Hour:                "1d",
+		7 * 24 * time.Hour:            "7d",
+		25*time.Hour + 30*time.Second: "25h30s",
+	} {
+		got := formatSpan(d)
+		if got != want {
+			t.Errorf("formatSpan(%v) = %q, want %q", d, got, want)
+			continue
+		}
+		if back, err := parseSpan("bucket", got); err != nil || back != d {
+			t.Errorf("parseSpan(%q) = %v, %v, want %v", got, back, err, d)
+		}
+	}
+}
+
+func TestBucketHistory(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	row := func(min int, source string, price float64) HistoryRow {
+		return HistoryRow{ScannedAt: at.Add(time.Duration(min) * time.Minute), Source: source, Location: "us-east-1", NumGPUs: 1, TotalCostPH: price}
+	}
+	// Out of order on purpose: the store does not have to sort.
+	rows := []HistoryRow{row(40, "vast", 1.5), row(10, "vast", 1), row(10, "runpod", 2), row(70, "vast", 3), row(40, "lambda", 0)}
+
+	points := bucketHistory(rows, time.Hour)
+	if len(points) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(points))
+	}
+	p := points[0]
+	if !p.Start.Equal(at) || p.Scans != 2 || p.MinCostPH != 1 || p.MedCostPH != 1.5 || p.Offers != 2 {
+		t.Errorf("first bucket = %+v", p)
+	}
+	if p.BySource["vast"] != 1 || p.BySource["lambda"] != 1 || p.BySource["runpod"] != 0 {
+		t.Errorf("first bucket counts its latest scan only, got %v", p.BySource)
+	}
+	if !points[1].Start.Equal(at.Add(time.Hour)) || points[1].Scans != 1 || points[1].P90CostPH != 3 {
+		t.Errorf("second bucket = %+v", points[1])
+	}
+}
+
+func TestModelHistoryResolvesOneModel(t *testing.T) {
+	useCatalogue(t, testOffers())
+	get := func(model string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		modelHistoryHandler(w, withURLParam(httptest.NewRequest("GET", "/models/"+model+"/history", nil), "model", model))
+		return w
+	}
+
+	w := get("h100")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("h100: status %d", w.Code)
+	}
+	for _, slug := range []string{"h100-sxm", "h100-nvl", "h100-pcie"} {
+		if !strings.Contains(w.Body.String(), slug) {
+			t.Errorf("h100: %s does not list %s", w.Body, slug)
+		}
+	}
+	if w := get("no-such-gpu"); w.Code != http.StatusNotFound {
+		t.Errorf("no-such-gpu: status %d", w.Code)
+	}
+
+	w = get("h100-sxm")
+	var h PriceHistory
+	if err := json.NewDecoder(w.Body).Decode(&h); w.Code != http.StatusOK || err != nil || h.Model != "H100 SXM" {
+		t.Errorf("h100-sxm: status %d, model %q (%v)", w.Code, h.Model, err)
+	}
+}
@@ -0,0 +1,62 @@
+// internal/api/jobcost.go
+package api
+
+import (
+	"cmp"
+	"slices"
+)
+
+// defaultUtilization is the share of peak FP32 a job is assumed to reach when it is sized in
+// PFLOPs rather than hours.
+const defaultUtilization = 0.4
+
+// JobCost prices a job on a set of offers, cheapest first.
+type JobCost struct {
+	// Hours is the requested run time; absent when the job was sized in PFLOPs.
+	Hours float64 `json:"hours,omitempty"`
+	// PFLOPs is the job's total FP32 work, run at Utilization of each offer's total_flops.
+	PFLOPs      float64       `json:"pflops,omitempty"`
+	Utilization float64       `json:"utilization,omitempty"`
+	Estimates   []JobEstimate `json:"estimates"`
+}
+
+// JobEstimate is what the job takes on one offer.
+type JobEstimate struct {
+	Offer   GPU     `json:"offer"`
+	Hours   float64 `json:"hours"`
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// estimateJobCost prices a job of hours, or of pflops at utilization, on every offer. Offers
+// that cannot be priced (no price, or no FLOPs for a PFLOPs job) are left out.
+func estimateJobCost(offers []GPU, hours, pflops, utilization float64) (JobCost, error) {
+	switch {
+	case (hours > 0) == (pflops > 0):
+		return JobCost{}, badParam("hours", "give either hours or pflops")
+	case hours < 0:
+		return JobCost{}, badParam("hours", "must be positive")
+	case pflops < 0:
+		return JobCost{}, badParam("pflops", "must be positive")
+	case utilization < 0 || utilization > 1:
+		return JobCost{}, badParam("utilization", "must be between 0 and 1")
+	}
+	out := JobCost{Hours: hours, Estimates: []JobEstimate{}}
+	if pflops > 0 {
+		out.PFLOPs, out.Utilization = pflops, cmp.Or(utilization, defaultUtilization)
+	}
+	for _, g := range offers {
+		h := hours
+		if pflops > 0 {
+			if g.TotalFlops <= 0 {
+				continue
+			}
+			h = pflops * 1e15 / (g.TotalFlops * out.Utilization) / 3600
+		}
+		if g.TotalCostPH <= 0 {
+			continue
+		}
+		out.Estimates = append(out.Estimates, JobEstimate{Offer: g, Hours: h, CostUSD: h * g.TotalCostPH})
+	}
+	slices.SortStableFunc(out.Estimates, func(a, b JobEstimate) int { return cmp.Compare(a.CostUSD, b.CostUSD) })
+	return out, nil
+}
@@ -0,0 +1,468 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// toolQuery maps the search-style tool arguments onto /gpus parameters so tools are validated
+// by the same parser as the REST API. limit defaults to def and is clamped to max.
+func toolQuery(req mcp.CallToolRequest, def, max int) (Query, error) {
+	v := url.Values{}
+	if s := req.GetString("query", ""); s != "" && s != "*" {
+		v.Set("name", s)
+	}
+	if s := req.GetString("region", ""); s != "" && s != "*" {
+		v.Set("location", s)
+	}
+	if f := req.GetFloat("max_price", -1); f > 0 {
+		v.Set("max_price", strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	if f := req.GetFloat("min_score", 0); f > 0 {
+		v.Set("min_score", strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	if s := req.GetString("order_by", ""); s != "" {
+		v.Set("sort", s)
+	}
+	if n := req.GetInt("limit", def); n > 0 {
+		v.Set("limit", strconv.Itoa(n))
+	}
+	if n := req.GetInt("offset", 0); n > 0 {
+		v.Set("offset", strconv.Itoa(n))
+	}
+
+	q, err := parseGPUQuery(v)
+	if err != nil {
+		return Query{}, err
+	}
+	if v.Get("limit") == "" {
+		q.Limit = def
+	}
+	q.Limit = min(q.Limit, max)
+	return q, nil
+}
+
+// ToolPage is the pagination block of the tools that list offers.
+type ToolPage struct {
+	Total    int `json:"total" jsonschema:"description=Offers matching the filters"`
+	Offset   int `json:"offset"`
+	Limit    int `json:"limit"`
+	Returned int `json:"returned"`
+	// NextOffset is absent on the last page.
+	NextOffset *int `json:"next_offset,omitempty" jsonschema:"description=Offset of the next page; absent on the last one"`
+}
+
+func toolPage(q Query, total, returned int) ToolPage {
+	p := ToolPage{Total: total, Offset: q.Offset, Limit: q.Limit, Returned: returned}
+	if next := q.Offset + returned; returned > 0 && next < total {
+		p.NextOffset = &next
+	}
+	return p
+}
+
+func (p ToolPage) String() string {
+	s := fmt.Sprintf("Found %d offers; returning %d from offset %d.", p.Total, p.Returned, p.Offset)
+	if p.NextOffset != nil {
+		s += fmt.Sprintf(" Next page: offset=%d.", *p.NextOffset)
+	}
+	return s
+}
+
+// SearchResult is the structured output of search_gpus.
+type SearchResult struct {
+	Offers []GPU `json:"offers"`
+	ToolPage
+}
+
+// sortPattern is the order_by schema: any GPU column, ascending or descending.
+func sortPattern() string {
+	return `^(` + strings.Join(slices.Sorted(maps.Keys(gpuColumns)), "|") + `)(\.(asc|desc))?$`
+}
+
+// offerLine is the one-line summary of an offer.
+func offerLine(g GPU) string {
+	return fmt.Sprintf("%s x%d on %s (%s), $%.2f/h, id %s", g.Name, g.NumGPUs, g.Source, g.Location, g.TotalCostPH, g.Id)
+}
+
+// offerLines lists the first few offers, one per line.
+func offerLines(gpus []GPU) string {
+	const shown = 10
+	var b strings.Builder
+	for _, g := range gpus[:min(len(gpus), shown)] {
+		fmt.Fprintf(&b, "\n- %s", offerLine(g))
+	}
+	if len(gpus) > shown {
+		fmt.Fprintf(&b, "\n...and %d more in structured content.", len(gpus)-shown)
+	}
+	return b.String()
+}
+
+func searchHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	q, err := toolQuery(req, 50, 200)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	results, total := snap.run(q)
+
+	out := SearchResult{Offers: append([]GPU{}, results...), ToolPage: toolPage(q, total, len(results))}
+	return mcp.NewToolResultStructured(out, out.ToolPage.String()+offerLines(out.Offers)), nil
+}
+
+func fetchHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := req.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	g, err := catalogue.Get(ctx, id)
+	if errors.Is(err, errNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("GPU %s not found", id)), nil
+	} else if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	return mcp.NewToolResultStructured(g, offerLine(g)), nil
+}
+
+// MemoryFitResult is the structured output of estimate_memory_fit.
+type MemoryFitResult struct {
+	Estimate MemoryEstimate `json:"estimate"`
+	Offers   []Fit          `json:"offers"`
+	ToolPage
+}
+
+// memoryFitArgs reads the workload arguments shared by estimate_memory_fit and recommend_gpu.
+func memoryFitArgs(req mcp.CallToolRequest) MemoryFitRequest {
+	return MemoryFitRequest{
+		Model:         req.GetString("model", ""),
+		ParamsB:       req.GetFloat("params_b", 0),
+		Layers:        req.GetInt("layers", 0),
+		Hidden:        req.GetInt("hidden", 0),
+		Heads:         req.GetInt("heads", 0),
+		KVHeads:       req.GetInt("kv_heads", 0),
+		Precision:     req.GetString("precision", ""),
+		KVPrecision:   req.GetString("kv_precision", ""),
+		Batch:         req.GetInt("batch", 0),
+		Context:       req.GetInt("context", 0),
+		Mode:          req.GetString("mode", ""),
+		Checkpointing: req.GetBool("checkpointing", false),
+	}
+}
+
+func memoryFitHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	est, err := estimateMemory(memoryFitArgs(req))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	q, err := toolQuery(req, 20, 200)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	q.Fit = &est
+	q.Sort, q.Desc = gpuColumns["total_cost_ph"], false
+
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	rows, matched := snap.run(q)
+	out := MemoryFitResult{Estimate: est, Offers: fitOffers(est, rows), ToolPage: toolPage(q, matched, len(rows))}
+
+	summary := fmt.Sprintf("Needs %.1f GB VRAM (%s, %s). %s",
+		est.TotalMB/1024, est.Mode, est.Precision, out.ToolPage)
+	return mcp.NewToolResultStructured(out, summary+offerLines(rows)), nil
+}
+
+// FeaturedResult is the structured output of featured_gpus.
+type FeaturedResult struct {
+	Cards []FeaturedCard `json:"cards"`
+}
+
+func featuredToolHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	n := min(max(req.GetInt("limit", 1), 1), 10)
+	cards, err := featured(snap, catalogue.Before(snap), splitList(strings.ToLower(req.GetString("strategy", ""))), n)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var summary strings.Builder
+	for _, c := range cards {
+		fmt.Fprintf(&summary, "%s: %s on %s, $%.2f/h. %s\n", c.Label, c.Offer.Name, c.Offer.Source, c.Offer.TotalCostPH, c.Reason)
+	}
+	return mcp.NewToolResultStructured(FeaturedResult{Cards: cards}, summary.String()), nil
+}
+
+func compareToolHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	names, err := compareBounds("models", req.GetString("models", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	cmp, err := compareModels(snap, names)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultStructured(cmp, comparisonSummary(cmp)), nil
+}
+
+// comparisonSummary renders a comparison one field per line, with ratios and the winner.
+func comparisonSummary(cmp Comparison) string {
+	var summary strings.Builder
+	for _, f := range cmp.Fields {
+		fmt.Fprintf(&summary, "%s:", f.Label)
+		for i, v := range f.Values {
+			fmt.Fprintf(&summary, " %s %.4g", cmp.Subjects[i].Label, v)
+			if i > 0 && f.Ratios[i] > 0 {
+				fmt.Fprintf(&summary, " (x%.2f)", f.Ratios[i])
+			}
+		}
+		if len(f.Winners) > 0 {
+			fmt.Fprintf(&summary, "; best %s", cmp.Subjects[f.Winners[0]].Label)
+		}
+		summary.WriteString("\n")
+	}
+	return summary.String()
+}
+
+func recommendHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	r := RecommendRequest{
+		MinVRAM:  req.GetFloat("min_vram_gb", 0),
+		Priority: req.GetString("priority", priorityValue),
+		Hours:    req.GetFloat("hours", 0),
+		Limit:    min(max(req.GetInt("limit", 5), 1), 20),
+	}
+	if budget := req.GetFloat("budget_usd", 0); budget > 0 {
+		if r.Hours <= 0 {
+			return mcp.NewToolResultError("budget_usd needs hours"), nil
+		}
+		// A total budget caps the hourly price; max_price can only tighten it.
+		if p := req.GetFloat("max_price", -1); p <= 0 || p > budget/r.Hours {
+			req.Params.Arguments = withArgument(req, "max_price", budget/r.Hours)
+		}
+	}
+	q, err := toolQuery(req, 5, 20)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	r.Query = q
+	if fit := memoryFitArgs(req); fit.Model != "" || fit.ParamsB > 0 {
+		est, err := estimateMemory(fit)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		r.Workload = &est
+	}
+
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	rec, err := recommend(snap, r)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "%d offers match; top %d by %s:", rec.Considered, len(rec.Picks), rec.Priority)
+	for _, p := range rec.Picks {
+		fmt.Fprintf(&summary, "\n%d. %s: %s", p.Rank, offerLine(p.Offer), strings.Join(p.Rationale, "; "))
+	}
+	return mcp.NewToolResultStructured(rec, summary.String()), nil
+}
+
+// withArgument returns req's arguments with key set to v.
+func withArgument(req mcp.CallToolRequest, key string, v any) map[string]any {
+	args := maps.Clone(req.GetArguments())
+	if args == nil {
+		args = map[string]any{}
+	}
+	args[key] = v
+	return args
+}
+
+func compareOffersToolHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ids, err := compareBounds("ids", req.GetString("ids", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	cmp, err := compareOffers(snap, ids)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultStructured(cmp, comparisonSummary(cmp)), nil
+}
+
+func jobCostHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, gpu := req.GetString("id", ""), req.GetString("gpu", "")
+	if (id == "") == (gpu == "") {
+		return mcp.NewToolResultError("give either id or gpu"), nil
+	}
+	var offers []GPU
+	if id != "" {
+		g, err := catalogue.Get(ctx, id)
+		if errors.Is(err, errNotFound) {
+			return mcp.NewToolResultError(fmt.Sprintf("GPU %s not found", id)), nil
+		} else if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+		}
+		offers = []GPU{g}
+	} else {
+		snap, err := catalogue.Snapshot(ctx)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+		}
+		// Same matching as the name filter of /gpus: "h100" takes every H100 variant.
+		match := nameMatcher(gpu)
+		n := req.GetInt("num_gpus", 0)
+		region := strings.ToLower(req.GetString("region", ""))
+		for _, g := range snap.gpus {
+			if match(g) && (n <= 0 || g.NumGPUs == n) &&
+				(region == "" || region == "*" || strings.Contains(strings.ToLower(g.Location), region)) {
+				offers = append(offers, g)
+			}
+		}
+		if len(offers) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no offers of %q", gpu)), nil
+		}
+	}
+
+	cost, err := estimateJobCost(offers, req.GetFloat("hours", 0), req.GetFloat("pflops", 0), req.GetFloat("utilization", 0))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	total := len(cost.Estimates)
+	cost.Estimates = cost.Estimates[:min(total, max(req.GetInt("limit", 5), 1), 50)]
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "%d offers priced; cheapest %d:", total, len(cost.Estimates))
+	for _, e := range cost.Estimates {
+		fmt.Fprintf(&summary, "\n- $%.2f over %.1f h on %s", e.CostUSD, e.Hours, offerLine(e.Offer))
+	}
+	return mcp.NewToolResultStructured(cost, summary.String()), nil
+}
+
+// ModelListing is a canonical GPU model and, when it is on offer, its market right now.
+type ModelListing struct {
+	gpumodel.Model
+	Market *GroupStats `json:"market,omitempty"`
+}
+
+// ModelList is the structured output of list_models.
+type ModelList struct {
+	Models []ModelListing `json:"models"`
+}
+
+func listModelsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	st := marketStats(snap)
+	onOffer := req.GetBool("on_offer", false)
+	out := ModelList{Models: []ModelListing{}}
+	var summary strings.Builder
+	for _, m := range gpumodel.Models {
+		l := ModelListing{Model: m}
+		if i := slices.IndexFunc(st.Models, func(g GroupStats) bool { return g.Key == m.Name }); i >= 0 {
+			l.Market = &st.Models[i]
+		}
+		if onOffer && l.Market == nil {
+			continue
+		}
+		out.Models = append(out.Models, l)
+		fmt.Fprintf(&summary, "%s (%s, %g GB)", m.Name, m.Spec.Architecture, m.Spec.VramGB)
+		if l.Market != nil {
+			fmt.Fprintf(&summary, ": %d offers from $%.2f/h", l.Market.Offers, l.Market.MinCostPH)
+		}
+		summary.WriteString("\n")
+	}
+	return mcp.NewToolResultStructured(out, summary.String()), nil
+}
+
+// GroupList is the structured output of list_providers and list_regions.
+type GroupList struct {
+	Groups []GroupStats `json:"groups"`
+	ToolPage
+}
+
+// groupListHandler lists one grouping of the market stats, busiest first, optionally narrowed
+// to keys containing the query argument.
+func groupListHandler(noun string, groups func(MarketStats) []GroupStats) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		snap, err := catalogue.Snapshot(ctx)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+		}
+		term := strings.ToLower(req.GetString("query", ""))
+		var all []GroupStats
+		for _, g := range groups(marketStats(snap)) {
+			if term == "" || term == "*" || strings.Contains(strings.ToLower(g.Key), term) {
+				all = append(all, g)
+			}
+		}
+		q := Query{Limit: min(max(req.GetInt("limit", 50), 1), 200), Offset: max(req.GetInt("offset", 0), 0)}
+		page := all[min(q.Offset, len(all)):min(q.Offset+q.Limit, len(all))]
+		out := GroupList{Groups: append([]GroupStats{}, page...), ToolPage: toolPage(q, len(all), len(page))}
+
+		var summary strings.Builder
+		fmt.Fprintf(&summary, "%d %s; returning %d from offset %d.", len(all), noun, len(page), q.Offset)
+		for _, g := range page {
+			fmt.Fprintf(&summary, "\n- %s: %d offers, %d GPUs, from $%.2f/h (median $%.2f/h)", g.Key, g.Offers, g.GPUs, g.MinCostPH, g.MedianCostPH)
+		}
+		return mcp.NewToolResultStructured(out, summary.String()), nil
+	}
+}
+
+func marketSummaryHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to load catalogue", err), nil
+	}
+	st := marketStats(snap)
+	n := min(max(req.GetInt("top", 5), 1), 50)
+	st.Models = st.Models[:min(n, len(st.Models))]
+	st.Sources = st.Sources[:min(n, len(st.Sources))]
+	st.Regions = st.Regions[:min(n, len(st.Regions))]
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Scan of %s: %d offers, %d GPUs, $%.2f-$%.2f/h (median $%.2f/h).",
+		st.ScannedAt.Format(time.RFC3339), st.Total.Offers, st.Total.GPUs, st.Total.MinCostPH, st.Total.MaxCostPH, st.Total.MedianCostPH)
+	if d := st.Total.Delta; d != nil {
+		fmt.Fprintf(&summary, " Since the previous scan: %+d offers, median %+.2f $/h.", d.Offers, d.MedianCostPH)
+	}
+	for _, part := range []struct {
+		label  string
+		groups []GroupStats
+	}{{"Models", st.Models}, {"Providers", st.Sources}, {"Regions", st.Regions}} {
+		fmt.Fprintf(&summary, "\n%s:", part.label)
+		for _, g := range part.groups {
+			fmt.Fprintf(&summary, " %s %d from $%.2f/h;", g.Key, g.Offers, g.MinCostPH)
+		}
+	}
+	return mcp.NewToolResultStructured(st, summary.String()), nil
+}
@@ -0,0 +1,220 @@
+// internal/api/mcp_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+)
+
+// mcpTools serves the recommend, compare_offers and job-cost tools over an in-process client on
+// the catalogue gpus.
+func mcpTools(t *testing.T, gpus []GPU) *mcptest.Server {
+	t.Helper()
+	useCatalogue(t, gpus)
+	srv, err := mcptest.NewServer(t, recommendTool(), compareOffersTool(), jobCostTool())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fullMCPClient connects an in-process client to the server NewMCPServer registers, hooks and all,
+// on the catalogue gpus.
+func fullMCPClient(t *testing.T, gpus []GPU) *client.Client {
+	t.Helper()
+	useCatalogue(t, gpus)
+	c, err := client.NewInProcessClient(NewMCPServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	var init mcp.InitializeRequest
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Initialize(context.Background(), init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// callTool calls name with args and decodes the structured result into out. It returns the
+// error text when the tool reports one.
+func callTool(t *testing.T, srv *mcptest.Server, name string, args map[string]any, out any) string {
+	t.Helper()
+	var req mcp.CallToolRequest
+	req.Params.Name, req.Params.Arguments = name, args
+	res, err := srv.Client().CallTool(context.Background(), req)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if res.IsError {
+		var text []string
+		for _, c := range res.Content {
+			if tc, ok := c.(mcp.TextContent); ok {
+				text = append(text, tc.Text)
+			}
+		}
+		return strings.Join(text, "\n")
+	}
+	b, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		t.Fatalf("%s: structured content %s: %v", name, b, err)
+	}
+	return ""
+}
+
+func TestRecommendTool(t *testing.T) {
+	srv := mcpTools(t, testOffers())
+
+	var rec Recommendation
+	if msg := callTool(t, srv, "recommend_gpu", map[string]any{"priority": priorityCheapest, "limit": 3, "hours": 10}, &rec); msg != "" {
+		t.Fatal(msg)
+	}
+	if rec.Considered != 12 || len(rec.Picks) != 3 {
+		t.Fatalf("considered %d, %d picks: %+v", rec.Considered, len(rec.Picks), rec)
+	}
+	for i, want := range []string{"offer-06", "offer-08", "offer-07"} {
+		if p := rec.Picks[i]; p.Offer.Id != want || p.Rank != i+1 || len(p.Rationale) == 0 {
+			t.Errorf("pick %d = %s rank %d, want %s", i, p.Offer.Id, p.Rank, want)
+		}
+	}
+	if math.Abs(rec.Picks[0].CostUSD-3.5) > 1e-9 {
+		t.Errorf("first pick costs $%v for 10 hours, want $3.50", rec.Picks[0].CostUSD)
+	}
+
+	// A budget of $1 for 2 hours only takes offers under $0.50/h.
+	rec = Recommendation{}
+	if msg := callTool(t, srv, "recommend_gpu", map[string]any{"budget_usd": 1, "hours": 2}, &rec); msg != "" {
+		t.Fatal(msg)
+	}
+	if rec.Considered != 3 || rec.Priority != priorityValue {
+		t.Errorf("budget: considered %d by %s, want 3 by %s", rec.Considered, rec.Priority, priorityValue)
+	}
+
+	for _, tt := range []struct {
+		args map[string]any
+		want string
+	}{
+		{map[string]any{"priority": "greenest"}, "priority: must be one of"},
+		{map[string]any{"budget_usd": 100}, "budget_usd needs hours"},
+		{map[string]any{"model": "no-such-model"}, "no-such-model"},
+	} {
+		if msg := callTool(t, srv, "recommend_gpu", tt.args, &Recommendation{}); !strings.Contains(msg, tt.want) {
+			t.Errorf("recommend_gpu %v: error %q, want %q", tt.args, msg, tt.want)
+		}
+	}
+}
+
+func TestCompareOffersTool(t *testing.T) {
+	srv := mcpTools(t, testOffers())
+
+	var cmp Comparison
+	if msg := callTool(t, srv, "compare_offers", map[string]any{"ids": "offer-00,offer-02,offer-06"}, &cmp); msg != "" {
+		t.Fatal(msg)
+	}
+	if len(cmp.Subjects) != 3 || len(cmp.Fields) == 0 {
+		t.Fatalf("%d subjects, %d fields", len(cmp.Subjects), len(cmp.Fields))
+	}
+
+	for ids, want := range map[string]string{
+		"":                       "ids: takes 2 to 5",
+		"offer-00":               "ids: takes 2 to 5",
+		"offer-00,offer-missing": `no offer "offer-missing"`,
+	} {
+		if msg := callTool(t, srv, "compare_offers", map[string]any{"ids": ids}, &Comparison{}); !strings.Contains(msg, want) {
+			t.Errorf("compare_offers %q: error %q, want %q", ids, msg, want)
+		}
+	}
+}
+
+func TestJobCostTool(t *testing.T) {
+	srv := mcpTools(t, testOffers())
+
+	var cost JobCost
+	if msg := callTool(t, srv, "estimate_job_cost", map[string]any{"gpu": "h100", "hours": 10}, &cost); msg != "" {
+		t.Fatal(msg)
+	}
+	if len(cost.Estimates) != 2 || cost.Estimates[0].Offer.Id != "offer-00" || math.Abs(cost.Estimates[0].CostUSD-24.9) > 1e-9 {
+		t.Fatalf("h100 for 10 hours: %+v", cost.Estimates)
+	}
+
+	cost = JobCost{}
+	if msg := callTool(t, srv, "estimate_job_cost", map[string]any{"id": "offer-02", "pflops": 1, "utilization": 0.5}, &cost); msg != "" {
+		t.Fatal(msg)
+	}
+	if len(cost.Estimates) != 1 || cost.PFLOPs != 1 || cost.Utilization != 0.5 || cost.Estimates[0].Hours <= 0 {
+		t.Errorf("offer-02 for 1 PFLOP: %+v", cost)
+	}
+
+	for _, tt := range []struct {
+		args map[string]any
+		want string
+	}{
+		{map[string]any{"hours": 1}, "give either id or gpu"},
+		{map[string]any{"id": "offer-00", "gpu": "h100", "hours": 1}, "give either id or gpu"},
+		{map[string]any{"gpu": "h100"}, "hours: give either hours or pflops"},
+		{map[string]any{"gpu": "h100", "hours": 1, "pflops": 1}, "hours: give either hours or pflops"},
+		{map[string]any{"gpu": "h100", "pflops": 1, "utilization": 2}, "utilization: must be between 0 and 1"},
+		{map[string]any{"id": "offer-missing", "hours": 1}, "GPU offer-missing not found"},
+	} {
+		if msg := callTool(t, srv, "estimate_job_cost", tt.args, &JobCost{}); !strings.Contains(msg, tt.want) {
+			t.Errorf("estimate_job_cost %v: error %q, want %q", tt.args, msg, tt.want)
+		}
+	}
+}
+
+func TestMCPToolsOnEmptyCatalogue(t *testing.T) {
+	srv := mcpTools(t, []GPU{})
+
+	var rec Recommendation
+	if msg := callTool(t, srv, "recommend_gpu", map[string]any{}, &rec); msg != "" {
+		t.Fatal(msg)
+	}
+	if rec.Considered != 0 || rec.Picks == nil || len(rec.Picks) != 0 {
+		t.Errorf("recommendation from nothing: %+v", rec)
+	}
+	if msg := callTool(t, srv, "compare_offers", map[string]any{"ids": "offer-00,offer-01"}, &Comparison{}); !strings.Contains(msg, `no offer "offer-00"`) {
+		t.Errorf("compare_offers: error %q", msg)
+	}
+	if msg := callTool(t, srv, "estimate_job_cost", map[string]any{"gpu": "h100", "hours": 1}, &JobCost{}); !strings.Contains(msg, `no offers of "h100"`) {
+		t.Errorf("estimate_job_cost by gpu: error %q", msg)
+	}
+	if msg := callTool(t, srv, "estimate_job_cost", map[string]any{"id": "offer-00", "hours": 1}, &JobCost{}); !strings.Contains(msg, "not found") {
+		t.Errorf("estimate_job_cost by id: error %q", msg)
+	}
+}
+
+func TestListResourcesLeavesOutOffers(t *testing.T) {
+	useCatalogue(t, testOffers())
+	snap, err := catalogue.Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var models, providers int
+	for _, r := range listResources(snap) {
+		switch uri := r.Resource.URI; {
+		case strings.HasPrefix(uri, modelResourcePrefix):
+			models++
+		case strings.HasPrefix(uri, providerResourcePrefix):
+			providers++
+		case uri != latestScanResource:
+			t.Errorf("listed %s", uri)
+		}
+	}
+	if models != 6 || providers != 3 {
+		t.Errorf("listed %d models and %d providers, want 6 and 3", models, providers)
+	}
+}
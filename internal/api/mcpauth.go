@@ -0,0 +1,358 @@
+// internal/api/mcpauth.go
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MCP authentication is off unless MCP_API_KEYS or MCP_OAUTH_INTROSPECTION_URL is set. Then every
+// /mcp request needs a bearer token (or X-API-Key), each client is held to MCP_RATE_LIMIT requests
+// a minute (default 120) and MCP_TOOL_QUOTA tool calls a UTC day (default none), and tool handlers
+// find the caller with mcpClientFrom. Keys are name:key, or name:key:rate:quota to override the
+// limits, comma-separated.
+//
+// OAuth access tokens are checked against the authorization server's introspection endpoint
+// (RFC 7662), authenticating with MCP_OAUTH_CLIENT_ID and MCP_OAUTH_CLIENT_SECRET, and must name
+// MCP_OAUTH_AUDIENCE in aud when it is set. MCP_OAUTH_ISSUER is advertised to clients in
+// /.well-known/oauth-protected-resource (RFC 9728).
+type mcpAuthenticator struct {
+	keys map[string]*mcpClient // tokenHash(key) -> client
+
+	introspectionURL string
+	clientID         string
+	clientSecret     string
+	audience         string
+	issuer           string
+
+	rateLimit int // requests a minute, 0 for none
+	toolQuota int // tool calls a UTC day, 0 for none
+
+	mu      sync.Mutex
+	tokens  map[string]introspected // tokenHash(token) -> introspection result
+	buckets map[string]*tokenBucket // client ID -> rate limit
+	swept   time.Time               // when idle buckets were last dropped
+	day     string                  // UTC date calls counts
+	calls   map[string]int          // client ID -> tool calls on day
+}
+
+// mcpClient is who is calling the MCP server.
+type mcpClient struct {
+	ID        string // the key's name, or oauth:<sub>
+	RateLimit int
+	ToolQuota int
+}
+
+// introspected caches an OAuth token's client, nil for an inactive token, until expires.
+type introspected struct {
+	client  *mcpClient
+	expires time.Time
+}
+
+// tokenBucket holds up to a minute of a client's requests, refilled continuously.
+type tokenBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+const (
+	// mcpTokenTTL is the longest an introspection result is trusted.
+	mcpTokenTTL = 5 * time.Minute
+	// mcpInactiveTTL is how long a rejected token is remembered, sparing the authorization server.
+	mcpInactiveTTL = time.Minute
+)
+
+var errMCPUnauthorized = errors.New("missing or invalid MCP API key or access token")
+
+// mcpAuth guards the MCP endpoints; nil when authentication is off.
+var mcpAuth = newMCPAuthFromEnv()
+
+func newMCPAuthFromEnv() *mcpAuthenticator {
+	a := &mcpAuthenticator{
+		keys:             map[string]*mcpClient{},
+		introspectionURL: strings.TrimSpace(os.Getenv("MCP_OAUTH_INTROSPECTION_URL")),
+		clientID:         os.Getenv("MCP_OAUTH_CLIENT_ID"),
+		clientSecret:     os.Getenv("MCP_OAUTH_CLIENT_SECRET"),
+		audience:         strings.TrimSpace(os.Getenv("MCP_OAUTH_AUDIENCE")),
+		issuer:           strings.TrimRight(strings.TrimSpace(os.Getenv("MCP_OAUTH_ISSUER")), "/"),
+		rateLimit:        120,
+		tokens:           map[string]introspected{},
+		buckets:          map[string]*tokenBucket{},
+		calls:            map[string]int{},
+	}
+	if n, err := strconv.Atoi(os.Getenv("MCP_RATE_LIMIT")); err == nil && n >= 0 {
+		a.rateLimit = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MCP_TOOL_QUOTA")); err == nil && n >= 0 {
+		a.toolQuota = n
+	}
+	// Entries are logged by position only: a malformed one may start with the key itself.
+	for i, entry := range splitList(os.Getenv("MCP_API_KEYS")) {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 && len(parts) != 4 || parts[0] == "" || parts[1] == "" {
+			log.Printf("mcp: MCP_API_KEYS entry %d is not name:key or name:key:rate:quota; skipped", i+1)
+			continue
+		}
+		c := &mcpClient{ID: parts[0], RateLimit: a.rateLimit, ToolQuota: a.toolQuota}
+		if len(parts) == 4 {
+			rate, err1 := strconv.Atoi(parts[2])
+			quota, err2 := strconv.Atoi(parts[3])
+			if err1 != nil || err2 != nil || rate < 0 || quota < 0 {
+				log.Printf("mcp: MCP_API_KEYS entry %d has a bad rate or quota; skipped", i+1)
+				continue
+			}
+			c.RateLimit, c.ToolQuota = rate, quota
+		}
+		a.keys[tokenHash(parts[1])] = c
+	}
+	if len(a.keys) == 0 && a.introspectionURL == "" {
+		return nil
+	}
+	return a
+}
+
+type mcpClientKey struct{}
+
+// mcpClientFrom returns the client calling an MCP handler, or nil when authentication is off or
+// the server runs over stdio.
+func mcpClientFrom(ctx context.Context) *mcpClient {
+	c, _ := ctx.Value(mcpClientKey{}).(*mcpClient)
+	return c
+}
+
+// bearerToken is the request's Authorization bearer token or X-API-Key.
+func bearerToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// authenticate returns the client r's token belongs to, or errMCPUnauthorized.
+func (a *mcpAuthenticator) authenticate(ctx context.Context, r *http.Request) (*mcpClient, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, errMCPUnauthorized
+	}
+	hash := tokenHash(token)
+	if c, ok := a.keys[hash]; ok {
+		return c, nil
+	}
+	if a.introspectionURL == "" {
+		return nil, errMCPUnauthorized
+	}
+
+	a.mu.Lock()
+	cached, ok := a.tokens[hash]
+	a.mu.Unlock()
+	if !ok || time.Now().After(cached.expires) {
+		c, exp, err := a.introspect(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		cached = introspected{client: c, expires: exp}
+		a.mu.Lock()
+		for h, t := range a.tokens {
+			if time.Now().After(t.expires) {
+				delete(a.tokens, h)
+			}
+		}
+		a.tokens[hash] = cached
+		a.mu.Unlock()
+	}
+	if cached.client == nil {
+		return nil, errMCPUnauthorized
+	}
+	return cached.client, nil
+}
+
+// introspect asks the authorization server about token, returning its client (nil when the token
+// is inactive or meant for another audience) and how long to trust the answer.
+func (a *mcpAuthenticator) introspect(ctx context.Context, token string) (*mcpClient, time.Time, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.introspectionURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if a.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	}
+	resp, err := httpc.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("token introspection: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, time.Time{}, fmt.Errorf("token introspection: %s", resp.Status)
+	}
+	var out struct {
+		Active   bool            `json:"active"`
+		Sub      string          `json:"sub"`
+		ClientID string          `json:"client_id"`
+		Exp      int64           `json:"exp"`
+		Aud      json.RawMessage `json:"aud"` // a string or an array of them
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, time.Time{}, fmt.Errorf("token introspection: %w", err)
+	}
+
+	now := time.Now()
+	id := cmp.Or(out.Sub, out.ClientID)
+	if !out.Active || id == "" || (a.audience != "" && !audienceHas(out.Aud, a.audience)) {
+		return nil, now.Add(mcpInactiveTTL), nil
+	}
+	exp := now.Add(mcpTokenTTL)
+	if out.Exp > 0 && time.Unix(out.Exp, 0).Before(exp) {
+		exp = time.Unix(out.Exp, 0)
+	}
+	return &mcpClient{ID: "oauth:" + id, RateLimit: a.rateLimit, ToolQuota: a.toolQuota}, exp, nil
+}
+
+// audienceHas reports whether an introspected aud, a string or an array, names want.
+func audienceHas(aud json.RawMessage, want string) bool {
+	var list []string
+	if json.Unmarshal(aud, &list) != nil {
+		var one string
+		if json.Unmarshal(aud, &one) != nil {
+			return false
+		}
+		list = []string{one}
+	}
+	return slices.Contains(list, want)
+}
+
+// allow takes one of c's requests from its bucket, or returns how long until there is one.
+func (a *mcpAuthenticator) allow(c *mcpClient) (bool, time.Duration) {
+	if c.RateLimit == 0 {
+		return true, 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	// A bucket left alone for a minute is full again, so it can go until the client is back.
+	if now.Sub(a.swept) >= time.Minute {
+		for id, b := range a.buckets {
+			if now.Sub(b.at) >= time.Minute {
+				delete(a.buckets, id)
+			}
+		}
+		a.swept = now
+	}
+	b, ok := a.buckets[c.ID]
+	if !ok {
+		b = &tokenBucket{tokens: float64(c.RateLimit), at: now}
+		a.buckets[c.ID] = b
+	}
+	perSec := float64(c.RateLimit) / 60
+	b.tokens = math.Min(float64(c.RateLimit), b.tokens+now.Sub(b.at).Seconds()*perSec)
+	b.at = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / perSec * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// useToolCall counts a tool call against c's daily quota, failing once the quota is used up.
+func (a *mcpAuthenticator) useToolCall(c *mcpClient) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if day := time.Now().UTC().Format(time.DateOnly); day != a.day {
+		a.day, a.calls = day, map[string]int{}
+	}
+	if c.ToolQuota > 0 && a.calls[c.ID] >= c.ToolQuota {
+		return false
+	}
+	a.calls[c.ID]++
+	return true
+}
+
+// middleware turns away MCP requests without a valid token (401) or over their client's rate
+// limit (429). It passes everything through when a is nil.
+func (a *mcpAuthenticator) middleware(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := a.authenticate(r.Context(), r)
+		if errors.Is(err, errMCPUnauthorized) {
+			challenge := `Bearer realm="gpufindr"`
+			if a.issuer != "" {
+				challenge += fmt.Sprintf(`, resource_metadata="%s/.well-known/oauth-protected-resource"`, requestBaseURL(r))
+			}
+			if bearerToken(r) != "" {
+				challenge += `, error="invalid_token"`
+			}
+			w.Header().Set("WWW-Authenticate", challenge)
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		} else if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		if ok, wait := a.allow(c); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("%s is over its %d requests a minute", c.ID, c.RateLimit))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// contextFunc hands the request's client to MCP handlers. The middleware has already checked the
+// token, so this only hits the key map or the introspection cache.
+func (a *mcpAuthenticator) contextFunc(ctx context.Context, r *http.Request) context.Context {
+	if a == nil {
+		return ctx
+	}
+	c, err := a.authenticate(ctx, r)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, mcpClientKey{}, c)
+}
+
+// toolMiddleware holds authenticated clients to their daily tool-call quota and logs who called
+// what.
+func (a *mcpAuthenticator) toolMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		c := mcpClientFrom(ctx)
+		if a == nil || c == nil {
+			return next(ctx, req)
+		}
+		if !a.useToolCall(c) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s has used its %d tool calls for today; the quota resets at 00:00 UTC", c.ID, c.ToolQuota)), nil
+		}
+		log.Printf("mcp: %s calls %s", c.ID, req.Params.Name)
+		return next(ctx, req)
+	}
+}
+
+// protectedResourceHandler serves the RFC 9728 metadata pointing OAuth clients at MCP_OAUTH_ISSUER.
+func (a *mcpAuthenticator) protectedResourceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"resource":                 requestBaseURL(r) + "/mcp",
+		"resource_name":            "gpufindr",
+		"authorization_servers":    []string{a.issuer},
+		"bearer_methods_supported": []string{"header"},
+	})
+}
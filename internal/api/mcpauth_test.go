@@ -0,0 +1,205 @@
+// internal/api/mcpauth_test.go
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testMCPAuth builds the authenticator from keys and extra environment.
+func testMCPAuth(t *testing.T, keys string, env map[string]string) *mcpAuthenticator {
+	t.Helper()
+	for _, k := range []string{"MCP_API_KEYS", "MCP_OAUTH_INTROSPECTION_URL", "MCP_OAUTH_AUDIENCE", "MCP_OAUTH_ISSUER",
+		"MCP_OAUTH_CLIENT_ID", "MCP_OAUTH_CLIENT_SECRET", "MCP_RATE_LIMIT", "MCP_TOOL_QUOTA"} {
+		t.Setenv(k, env[k])
+	}
+	t.Setenv("MCP_API_KEYS", keys)
+	a := newMCPAuthFromEnv()
+	if a == nil {
+		t.Fatal("authentication is off")
+	}
+	return a
+}
+
+// mcpGet runs a request with token through a's middleware.
+func mcpGet(a *mcpAuthenticator, token string) *httptest.ResponseRecorder {
+	h := a.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, mcpRequest(token))
+	return w
+}
+
+func mcpRequest(token string) *http.Request {
+	req := httptest.NewRequest("POST", "/mcp", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestMCPAuthRejectsThenRateLimits(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	a := testMCPAuth(t, "alice:key-a:2:0, secret-without-name, bob:key-b:x:1", nil)
+	if len(a.keys) != 1 {
+		t.Fatalf("%d keys loaded, want only alice's", len(a.keys))
+	}
+	if out := logged.String(); strings.Contains(out, "secret") || strings.Contains(out, "key-b") ||
+		!strings.Contains(out, "entry 2 ") || !strings.Contains(out, "entry 3 ") {
+		t.Errorf("malformed entries logged as %q", out)
+	}
+
+	w := mcpGet(a, "")
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Header().Get("WWW-Authenticate"), "invalid_token") {
+		t.Errorf("no token: %d, WWW-Authenticate %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	w = mcpGet(a, "key-b")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`) {
+		t.Errorf("unknown key: %d, WWW-Authenticate %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+
+	for i := range 2 {
+		if w := mcpGet(a, "key-a"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: %d", i+1, w.Code)
+		}
+	}
+	w = mcpGet(a, "key-a")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("over the rate: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestMCPAuthEvictsIdleBuckets(t *testing.T) {
+	a := testMCPAuth(t, "alice:key-a,bob:key-b", nil)
+	alice, bob := a.keys[tokenHash("key-a")], a.keys[tokenHash("key-b")]
+	a.allow(alice)
+	a.allow(bob)
+
+	a.mu.Lock()
+	a.buckets[alice.ID].at = time.Now().Add(-2 * time.Minute)
+	a.swept = time.Now().Add(-2 * time.Minute)
+	a.mu.Unlock()
+	a.allow(bob)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.buckets[alice.ID]; ok {
+		t.Error("idle bucket was kept")
+	}
+	if _, ok := a.buckets[bob.ID]; !ok {
+		t.Error("active bucket was dropped")
+	}
+}
+
+func TestMCPToolQuotaResetsDaily(t *testing.T) {
+	a := testMCPAuth(t, "alice:key-a:0:2", nil)
+	ctx := context.WithValue(context.Background(), mcpClientKey{}, a.keys[tokenHash("key-a")])
+	call := a.toolMiddleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	used := func() bool {
+		res, err := call(ctx, mcp.CallToolRequest{})
+		return err == nil && !res.IsError
+	}
+
+	if !used() || !used() {
+		t.Fatal("calls within the quota were refused")
+	}
+	if used() {
+		t.Fatal("third call of a 2-call quota went through")
+	}
+	a.mu.Lock()
+	a.day = time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	a.mu.Unlock()
+	if !used() {
+		t.Error("quota did not reset on a new UTC day")
+	}
+}
+
+func TestMCPAuthChecksAudience(t *testing.T) {
+	var introspections atomic.Int32
+	as := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections.Add(1)
+		if u, p, _ := r.BasicAuth(); u != "gpufindr" || p != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp := map[string]any{"active": true, "sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+		switch r.FormValue("token") {
+		case "for-us":
+			resp["aud"] = []string{"https://other.example", "https://gpufindr.com/mcp"}
+		case "for-us-string":
+			resp["aud"] = "https://gpufindr.com/mcp"
+		case "for-others":
+			resp["aud"] = "https://other.example"
+		case "no-aud":
+		default:
+			resp = map[string]any{"active": false}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(as.Close)
+	a := testMCPAuth(t, "", map[string]string{
+		"MCP_OAUTH_INTROSPECTION_URL": as.URL,
+		"MCP_OAUTH_CLIENT_ID":         "gpufindr",
+		"MCP_OAUTH_CLIENT_SECRET":     "s3cret",
+		"MCP_OAUTH_AUDIENCE":          "https://gpufindr.com/mcp",
+	})
+
+	for token, want := range map[string]int{
+		"for-us":        http.StatusOK,
+		"for-us-string": http.StatusOK,
+		"for-others":    http.StatusUnauthorized,
+		"no-aud":        http.StatusUnauthorized,
+		"revoked":       http.StatusUnauthorized,
+	} {
+		if w := mcpGet(a, token); w.Code != want {
+			t.Errorf("token %s: %d, want %d", token, w.Code, want)
+		}
+	}
+
+	// Answers are cached, accepted and rejected alike.
+	n := introspections.Load()
+	mcpGet(a, "for-us")
+	mcpGet(a, "for-others")
+	if introspections.Load() != n {
+		t.Errorf("introspected %d more times", introspections.Load()-n)
+	}
+	if c, err := a.authenticate(context.Background(), mcpRequest("for-us")); err != nil || c.ID != "oauth:user-1" {
+		t.Errorf("client = %+v, %v", c, err)
+	}
+}
+
+func TestRequestBaseURLTrustsOnlyTheProxy(t *testing.T) {
+	oldBase, oldTrust := mcpBaseURL, trustProxy
+	t.Cleanup(func() { mcpBaseURL, trustProxy = oldBase, oldTrust })
+	mcpBaseURL = ""
+	req := httptest.NewRequest("GET", "http://api.internal:8080/.well-known/oauth-protected-resource", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "gpufindr.com, api.internal")
+
+	trustProxy = false
+	if got := requestBaseURL(req); got != "http://api.internal:8080" {
+		t.Errorf("without a trusted proxy: %s", got)
+	}
+	trustProxy = true
+	if got := requestBaseURL(req); got != "https://gpufindr.com" {
+		t.Errorf("behind a trusted proxy: %s", got)
+	}
+	mcpBaseURL = "https://mcp.gpufindr.com"
+	if got := requestBaseURL(req); got != mcpBaseURL {
+		t.Errorf("with MCP_BASE_URL: %s", got)
+	}
+}
@@ -0,0 +1,73 @@
+// internal/api/mcphttp.go
+package api
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcpBaseURL is where MCP clients reach the API, e.g. https://gpufindr.com. When MCP_BASE_URL is
+// not set it is worked out per request, so a local run hands out localhost URLs.
+var mcpBaseURL = strings.TrimRight(strings.TrimSpace(os.Getenv("MCP_BASE_URL")), "/")
+
+// requestBaseURL returns mcpBaseURL, or the scheme and host r came in on. X-Forwarded-Proto and
+// X-Forwarded-Host are only honoured behind a trusted proxy, as anyone can send them.
+func requestBaseURL(r *http.Request) string {
+	if mcpBaseURL != "" {
+		return mcpBaseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	if trustProxy {
+		if p := r.Header.Get("X-Forwarded-Proto"); p != "" {
+			scheme, _, _ = strings.Cut(p, ",")
+		}
+		if h := r.Header.Get("X-Forwarded-Host"); h != "" {
+			host, _, _ = strings.Cut(h, ",")
+		}
+	}
+	return strings.TrimSpace(scheme) + "://" + strings.TrimSpace(host)
+}
+
+// MountMCP serves s on r over streamable HTTP at /mcp and /mcp/stateless, and over the legacy
+// SSE transport at /mcp/sse and /mcp/message, behind mcpAuth when it is on.
+func MountMCP(r chi.Router, s *server.MCPServer) {
+	auth := mcpAuth.middleware
+	if mcpAuth != nil && mcpAuth.issuer != "" {
+		r.Get("/.well-known/oauth-protected-resource", mcpAuth.protectedResourceHandler)
+	}
+
+	// Streamable HTTP: /mcp keeps sessions (GET streams, resource subscriptions),
+	// /mcp/stateless answers each POST on its own for clients and proxies without affinity.
+	r.Handle("/mcp", auth(sseMiddleware(server.NewStreamableHTTPServer(s,
+		server.WithHeartbeatInterval(30*time.Second),
+		server.WithHTTPContextFunc(mcpAuth.contextFunc),
+	))))
+	r.Handle("/mcp/stateless", auth(sseMiddleware(server.NewStreamableHTTPServer(s,
+		server.WithStateLess(true),
+		server.WithHTTPContextFunc(mcpAuth.contextFunc),
+	))))
+
+	// Legacy SSE transport
+	sse := server.NewSSEServer(
+		s,
+		server.WithStaticBasePath("/mcp"),
+		server.WithSSEEndpoint("/sse"),
+		server.WithMessageEndpoint("/message"),
+		server.WithDynamicBaseURL(requestBaseURL),
+		server.WithUseFullURLForMessageEndpoint(true), // some clients require absolute
+		server.WithKeepAliveInterval(30*time.Second),
+		server.WithSSEContextFunc(mcpAuth.contextFunc),
+	)
+
+	r.Handle("/mcp/sse", auth(sseMiddleware(sse.SSEHandler())))
+	r.Handle("/mcp/message", auth(sse.MessageHandler()))
+}
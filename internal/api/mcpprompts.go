@@ -0,0 +1,308 @@
+// internal/api/mcpprompts.go
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Prompts hand the client a shopping question together with the live offers it has to be
+// answered from, so every client reasons over the same numbers.
+
+// bandwidthEfficiency is the share of peak memory bandwidth decoding is assumed to reach.
+const bandwidthEfficiency = 0.6
+
+// promptOffers caps the offers written into a prompt.
+const promptOffers = 10
+
+// promptFloat reads a numeric prompt argument; prompt arguments arrive as strings.
+func promptFloat(req mcp.GetPromptRequest, name string, def float64) (float64, error) {
+	s := strings.TrimSpace(req.Params.Arguments[name])
+	if s == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, badParam(name, "must be a positive number")
+	}
+	return f, nil
+}
+
+// promptModel reads the model argument: a memfit preset or a parameter count in billions.
+func promptModel(req mcp.GetPromptRequest) (MemoryFitRequest, error) {
+	s := strings.TrimSpace(req.Params.Arguments["model"])
+	if s == "" {
+		return MemoryFitRequest{}, badParam("model", "is required")
+	}
+	if b, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "b"), 64); err == nil {
+		return MemoryFitRequest{ParamsB: b}, nil
+	}
+	return MemoryFitRequest{Model: s}, nil
+}
+
+// fitResource is the gpufindr://gpus filter of every offer holding fit, cheapest first.
+func fitResource(fit MemoryFitRequest, est MemoryEstimate) string {
+	v := url.Values{}
+	if fit.Model != "" {
+		v.Set("fit_model", fit.Model)
+	} else {
+		v.Set("fit_params", strconv.FormatFloat(fit.ParamsB, 'f', -1, 64))
+	}
+	v.Set("fit_precision", est.Precision)
+	v.Set("fit_mode", est.Mode)
+	v.Set("fit_batch", strconv.Itoa(est.Batch))
+	v.Set("fit_context", strconv.Itoa(est.Context))
+	if fit.Checkpointing {
+		v.Set("fit_checkpointing", "true")
+	}
+	v.Set("sort", "total_cost_ph.asc")
+	return filterResource + "?" + v.Encode()
+}
+
+// cheapestPerKey keeps the cheapest listing of each offerKey, cheapest first.
+func cheapestPerKey(gpus []GPU) []GPU {
+	sorted := slices.Clone(gpus)
+	slices.SortStableFunc(sorted, func(a, b GPU) int { return cmp.Compare(a.TotalCostPH, b.TotalCostPH) })
+	seen := map[string]bool{}
+	out := sorted[:0]
+	for _, g := range sorted {
+		if k := offerKey(g); !seen[k] {
+			seen[k] = true
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// promptText is a user message of text.
+func promptText(text string) mcp.PromptMessage {
+	return mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text))
+}
+
+// promptResource is a user message embedding v as the JSON content of uri.
+func promptResource(uri string, v any) (mcp.PromptMessage, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return mcp.PromptMessage{}, err
+	}
+	return mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(
+		mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(b)})), nil
+}
+
+// ServingCandidate is an offer that holds a model and the decode rate it should sustain.
+type ServingCandidate struct {
+	Offer        GPU     `json:"offer"`
+	TokensPerSec float64 `json:"est_tokens_per_sec"`
+	HeadroomMB   float64 `json:"headroom_mb"`
+}
+
+func serveModelPromptHandler(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	fit, err := promptModel(req)
+	if err != nil {
+		return nil, err
+	}
+	target, err := promptFloat(req, "tokens_per_sec", 0)
+	if err != nil {
+		return nil, err
+	}
+	if target <= 0 {
+		return nil, badParam("tokens_per_sec", "is required")
+	}
+	batch, err := promptFloat(req, "batch", 1)
+	if err != nil {
+		return nil, err
+	}
+	ctxLen, err := promptFloat(req, "context", 4096)
+	if err != nil {
+		return nil, err
+	}
+	fit.Precision, fit.Batch, fit.Context, fit.Mode = req.Params.Arguments["precision"], int(batch), int(ctxLen), modeInference
+	est, err := estimateMemory(fit)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoding is memory bound: each step streams the weights and the KV cache once and yields
+	// one token per sequence in the batch.
+	stepMB := est.WeightsMB + est.KVCacheMB
+	var picks []ServingCandidate
+	for _, g := range cheapestPerKey(snap.gpus) {
+		if !est.fits(g) || g.TotalCostPH <= 0 || g.GpuMemoryBandwith <= 0 {
+			continue
+		}
+		bw := g.GpuMemoryBandwith * float64(max(g.NumGPUs, 1)) * 1e9 * bandwidthEfficiency
+		if tps := float64(est.Batch) * bw / (stepMB * mib); tps >= target {
+			picks = append(picks, ServingCandidate{Offer: g, TokensPerSec: tps, HeadroomMB: float64(g.Vram*max(g.NumGPUs, 1)) - est.TotalMB})
+		}
+	}
+	found := len(picks)
+	picks = picks[:min(found, promptOffers)]
+
+	model := cmp.Or(est.Model, fmt.Sprintf("a %gB-parameter model", est.Params/1e9))
+	var b strings.Builder
+	fmt.Fprintf(&b, "I want to serve %s at %g tokens/s (batch %d, context %d, %s weights) as cheaply as possible.\n\n",
+		model, target, est.Batch, est.Context, est.Precision)
+	fmt.Fprintf(&b, "gpufindr estimates it needs %.1f GB of VRAM. Assuming decoding reaches %.0f%% of peak memory bandwidth, "+
+		"%d offers in the scan of %s hold it at that rate; the cheapest %d are attached, with gpufindr://offers/{id} URIs for details.\n\n",
+		est.TotalMB/1024, bandwidthEfficiency*100, found, snap.scannedAt.Format("2006-01-02 15:04 MST"), len(picks))
+	b.WriteString("Recommend one offer and a fallback. Weigh price against reliability, headroom for longer contexts or bigger batches, " +
+		"and how a multi-GPU offer would need tensor parallelism. Say if the bandwidth estimate is the deciding assumption.")
+	if found == 0 {
+		b.Reset()
+		fmt.Fprintf(&b, "I want to serve %s at %g tokens/s (batch %d, context %d, %s weights). gpufindr found no single offer that holds "+
+			"its %.1f GB and sustains that rate. Suggest what to change: a smaller precision, a bigger batch, or spreading it over several offers.",
+			model, target, est.Batch, est.Context, est.Precision, est.TotalMB/1024)
+	}
+	msgs := []mcp.PromptMessage{promptText(b.String())}
+	if found > 0 {
+		m, err := promptResource(fitResource(fit, est), picks)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return mcp.NewGetPromptResult(fmt.Sprintf("Cheapest GPU to serve %s at %g tokens/s", model, target), msgs), nil
+}
+
+func planFinetunePromptHandler(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	fit, err := promptModel(req)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := promptFloat(req, "budget_usd", 0)
+	if err != nil {
+		return nil, err
+	}
+	tokensM, err := promptFloat(req, "tokens_m", 0)
+	if err != nil {
+		return nil, err
+	}
+	if budget <= 0 {
+		return nil, badParam("budget_usd", "is required")
+	}
+	if tokensM <= 0 {
+		return nil, badParam("tokens_m", "is required")
+	}
+	epochs, err := promptFloat(req, "epochs", 1)
+	if err != nil {
+		return nil, err
+	}
+	fit.Precision, fit.Mode = cmp.Or(req.Params.Arguments["precision"], "bf16"), modeTraining
+	fit.Checkpointing = req.Params.Arguments["checkpointing"] == "true"
+	est, err := estimateMemory(fit)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Training costs about 6 FLOPs per parameter per token: forward plus twice that backward.
+	pflops := 6 * est.Params * tokensM * 1e6 * epochs / 1e15
+	var fitting []GPU
+	for _, g := range cheapestPerKey(snap.gpus) {
+		if est.fits(g) {
+			fitting = append(fitting, g)
+		}
+	}
+	cost, err := estimateJobCost(fitting, 0, pflops, defaultUtilization)
+	if err != nil {
+		return nil, err
+	}
+	within := 0
+	for _, e := range cost.Estimates {
+		if e.CostUSD <= budget {
+			within++
+		}
+	}
+	cost.Estimates = cost.Estimates[:min(len(cost.Estimates), promptOffers)]
+
+	model := cmp.Or(est.Model, fmt.Sprintf("a %gB-parameter model", est.Params/1e9))
+	checkpointing := "without"
+	if fit.Checkpointing {
+		checkpointing = "with"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan a full fine-tune of %s on %g million tokens for %g epoch(s) in %s, for at most $%.2f.\n\n",
+		model, tokensM, epochs, est.Precision, budget)
+	fmt.Fprintf(&b, "gpufindr estimates %.1f GB of VRAM with Adam (%s activation checkpointing) and %.0f PFLOPs of work "+
+		"at %.0f%% FP32 utilisation. Of %d offers that hold it in the scan of %s, %d finish within budget; the cheapest runs are attached.\n\n",
+		est.TotalMB/1024, checkpointing, pflops, cost.Utilization*100,
+		len(fitting), snap.scannedAt.Format("2006-01-02 15:04 MST"), within)
+	b.WriteString("Pick an offer and lay out the run: hours, cost, the margin left in the budget, and checkpointing so a preempted " +
+		"instance does not lose the run. If nothing fits the budget, say what would: LoRA, fewer tokens or epochs, a smaller model.")
+	msgs := []mcp.PromptMessage{promptText(b.String())}
+	if len(cost.Estimates) > 0 {
+		m, err := promptResource(fitResource(fit, est), cost)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return mcp.NewGetPromptResult(fmt.Sprintf("Fine-tuning plan for %s under $%.2f", model, budget), msgs), nil
+}
+
+func explainPricePromptHandler(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	a, b := req.Params.Arguments["offer_a"], req.Params.Arguments["offer_b"]
+	if a == "" {
+		return nil, badParam("offer_a", "is required")
+	}
+	if b == "" {
+		return nil, badParam("offer_b", "is required")
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comparison, err := compareOffers(snap, []string{a, b})
+	if err != nil {
+		return nil, err
+	}
+	sa, sb := comparison.Subjects[0], comparison.Subjects[1]
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Explain why %s costs $%.2f/h and %s costs $%.2f/h.\n\n", sa.Label, sa.Offer.TotalCostPH, sb.Label, sb.Offer.TotalCostPH)
+	for _, m := range snap.stats().Models {
+		if m.Key != otherModel && (m.Key == sa.Model || m.Key == sb.Model) {
+			fmt.Fprintf(&text, "%s offers today: %d, from $%.2f/h, median $%.2f/h.\n", m.Key, m.Offers, m.MinCostPH, m.MedianCostPH)
+		}
+	}
+	text.WriteString("\nThe side-by-side comparison and both offers are attached. Separate what the money buys (GPU model and count, " +
+		"VRAM, CPU, RAM, disk, bandwidth) from what it does not (provider, region, reliability, billing components such as " +
+		"disk_cost_ph), say where each sits against its model's market, and whether the dearer one is worth it.")
+
+	msgs := []mcp.PromptMessage{promptText(text.String())}
+	for _, p := range []struct {
+		uri string
+		v   any
+	}{
+		{offerResourcePrefix + a, sa.Offer},
+		{offerResourcePrefix + b, sb.Offer},
+		{siteURL + "/compare?ids=" + url.QueryEscape(a+","+b), comparison},
+	} {
+		m, err := promptResource(p.uri, p.v)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return mcp.NewGetPromptResult("Why two GPU offers differ in price", msgs), nil
+}
+
+// promptModels is the model argument's description: what serve_model and plan_finetune accept.
+func promptModels() string {
+	return "model preset (" + strings.Join(presetNames(), ", ") + ") or a parameter count in billions, e.g. 13b"
+}
@@ -0,0 +1,225 @@
+// internal/api/mcpprompts_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// promptGPUs is testOffers with each model's peak memory bandwidth in GB/s.
+func promptGPUs() []GPU {
+	bandwidth := map[string]float64{
+		"H100 SXM5 80GB": 3350, "A100 SXM4 80GB": 2039, "A10": 600,
+		"RTX 4090": 1008, "L4": 300, "L40S": 864,
+	}
+	gpus := testOffers()
+	for i := range gpus {
+		gpus[i].GpuMemoryBandwith = bandwidth[gpus[i].Name]
+	}
+	return gpus
+}
+
+// getPrompt gets name with args. It returns the error text when the prompt fails.
+func getPrompt(t *testing.T, c *client.Client, name string, args map[string]string) (*mcp.GetPromptResult, string) {
+	t.Helper()
+	var req mcp.GetPromptRequest
+	req.Params.Name, req.Params.Arguments = name, args
+	res, err := c.GetPrompt(context.Background(), req)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return res, ""
+}
+
+// messageText is the text of message i.
+func messageText(t *testing.T, res *mcp.GetPromptResult, i int) string {
+	t.Helper()
+	text, ok := res.Messages[i].Content.(mcp.TextContent)
+	if !ok {
+		t.Fatalf("message %d: %T, want text", i, res.Messages[i].Content)
+	}
+	return text.Text
+}
+
+// messageResource decodes the embedded resource of message i into out and returns its URI.
+func messageResource(t *testing.T, res *mcp.GetPromptResult, i int, out any) string {
+	t.Helper()
+	emb, ok := res.Messages[i].Content.(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("message %d: %T, want an embedded resource", i, res.Messages[i].Content)
+	}
+	contents, ok := emb.Resource.(mcp.TextResourceContents)
+	if !ok {
+		t.Fatalf("message %d: %T, want text contents", i, emb.Resource)
+	}
+	if err := json.Unmarshal([]byte(contents.Text), out); err != nil {
+		t.Fatal(err)
+	}
+	return contents.URI
+}
+
+func TestPromptsListed(t *testing.T) {
+	c := fullMCPClient(t, testOffers())
+	res, err := c.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	required := map[string][]string{}
+	for _, p := range res.Prompts {
+		for _, a := range p.Arguments {
+			if a.Required {
+				required[p.Name] = append(required[p.Name], a.Name)
+			}
+		}
+	}
+	want := map[string]string{
+		"serve_model":              "model,tokens_per_sec",
+		"plan_finetune":            "model,budget_usd,tokens_m",
+		"explain_price_difference": "offer_a,offer_b",
+	}
+	if len(required) != len(want) {
+		t.Errorf("prompts = %v", required)
+	}
+	for name, args := range want {
+		if got := strings.Join(required[name], ","); got != args {
+			t.Errorf("%s required = %q, want %q", name, got, args)
+		}
+	}
+}
+
+func TestServeModelPrompt(t *testing.T) {
+	c := fullMCPClient(t, promptGPUs())
+
+	res, msg := getPrompt(t, c, "serve_model", map[string]string{"model": "llama-3-8b", "tokens_per_sec": "25", "precision": "fp16"})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if len(res.Messages) != 2 {
+		t.Fatalf("messages = %d, want 2", len(res.Messages))
+	}
+	if text := messageText(t, res, 0); !strings.Contains(text, "serve llama-3-8b at 25 tokens/s") || !strings.Contains(text, "8 offers") {
+		t.Errorf("text = %q", text)
+	}
+	var picks []ServingCandidate
+	uri := messageResource(t, res, 1, &picks)
+	if !strings.HasPrefix(uri, filterResource+"?") || !strings.Contains(uri, "fit_model=llama-3-8b") || !strings.Contains(uri, "fit_precision=fp16") {
+		t.Errorf("uri = %q", uri)
+	}
+	// The A10 and L4 hold the model but are too slow at 25 tokens/s.
+	var ids []string
+	for i, p := range picks {
+		ids = append(ids, p.Offer.Id)
+		if p.TokensPerSec < 25 || p.HeadroomMB <= 0 {
+			t.Errorf("%s: %+v", p.Offer.Id, p)
+		}
+		if i > 0 && p.Offer.TotalCostPH < picks[i-1].Offer.TotalCostPH {
+			t.Errorf("%s cheaper than %s", p.Offer.Id, picks[i-1].Offer.Id)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "offer-06,offer-07,offer-10,offer-11,offer-02,offer-03,offer-00,offer-01" {
+		t.Errorf("picks = %s", got)
+	}
+
+	res, msg = getPrompt(t, c, "serve_model", map[string]string{"model": "13b", "tokens_per_sec": "10000"})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if len(res.Messages) != 1 || !strings.Contains(messageText(t, res, 0), "found no single offer") {
+		t.Errorf("messages = %+v", res.Messages)
+	}
+}
+
+func TestPlanFinetunePrompt(t *testing.T) {
+	// At 100 TFLOPS and 40% utilisation the 600 PFLOPs run takes about 4.2 hours everywhere,
+	// so only offers under $1.20/h stay within $5.
+	gpus := testOffers()
+	for i := range gpus {
+		gpus[i].TotalFlops = 100e12
+	}
+	c := fullMCPClient(t, gpus)
+
+	res, msg := getPrompt(t, c, "plan_finetune", map[string]string{"model": "1b", "budget_usd": "5", "tokens_m": "100"})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if len(res.Messages) != 2 {
+		t.Fatalf("messages = %d, want 2", len(res.Messages))
+	}
+	if text := messageText(t, res, 0); !strings.Contains(text, "in bf16") || !strings.Contains(text, "Of 12 offers") || !strings.Contains(text, "8 finish within budget") {
+		t.Errorf("text = %q", text)
+	}
+	var cost JobCost
+	uri := messageResource(t, res, 1, &cost)
+	if !strings.Contains(uri, "fit_params=1") || !strings.Contains(uri, "fit_mode=training") {
+		t.Errorf("uri = %q", uri)
+	}
+	if cost.PFLOPs != 600 || len(cost.Estimates) != promptOffers {
+		t.Errorf("pflops = %g, estimates = %d", cost.PFLOPs, len(cost.Estimates))
+	}
+	for i := 1; i < len(cost.Estimates); i++ {
+		if cost.Estimates[i].CostUSD < cost.Estimates[i-1].CostUSD {
+			t.Errorf("estimate %d cheaper than %d", i, i-1)
+		}
+	}
+
+	res, msg = getPrompt(t, c, "plan_finetune", map[string]string{"model": "1b", "budget_usd": "0.01", "tokens_m": "100"})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if text := messageText(t, res, 0); !strings.Contains(text, " 0 finish within budget") {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestExplainPricePrompt(t *testing.T) {
+	c := fullMCPClient(t, testOffers())
+
+	res, msg := getPrompt(t, c, "explain_price_difference", map[string]string{"offer_a": "offer-00", "offer_b": "offer-02"})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if len(res.Messages) != 4 {
+		t.Fatalf("messages = %d, want 4", len(res.Messages))
+	}
+	if text := messageText(t, res, 0); !strings.Contains(text, "costs $2.49/h") || !strings.Contains(text, "costs $1.29/h") {
+		t.Errorf("text = %q", text)
+	}
+	var a, b GPU
+	if uri := messageResource(t, res, 1, &a); uri != offerResourcePrefix+"offer-00" || a.Id != "offer-00" {
+		t.Errorf("offer_a = %q, %s", uri, a.Id)
+	}
+	if uri := messageResource(t, res, 2, &b); uri != offerResourcePrefix+"offer-02" || b.Id != "offer-02" {
+		t.Errorf("offer_b = %q, %s", uri, b.Id)
+	}
+	var comparison Comparison
+	if uri := messageResource(t, res, 3, &comparison); uri != siteURL+"/compare?ids=offer-00%2Coffer-02" || len(comparison.Subjects) != 2 {
+		t.Errorf("comparison = %q, %d subjects", uri, len(comparison.Subjects))
+	}
+}
+
+func TestPromptErrors(t *testing.T) {
+	c := fullMCPClient(t, promptGPUs())
+	tests := []struct {
+		name string
+		args map[string]string
+		want string
+	}{
+		{"serve_model", map[string]string{"tokens_per_sec": "20"}, "model: is required"},
+		{"serve_model", map[string]string{"model": "llama-3-8b"}, "tokens_per_sec: is required"},
+		{"serve_model", map[string]string{"model": "llama-3-8b", "tokens_per_sec": "fast"}, "tokens_per_sec: must be a positive number"},
+		{"plan_finetune", map[string]string{"model": "1b", "tokens_m": "100"}, "budget_usd: is required"},
+		{"plan_finetune", map[string]string{"model": "1b", "budget_usd": "100"}, "tokens_m: is required"},
+		{"explain_price_difference", map[string]string{"offer_b": "offer-02"}, "offer_a: is required"},
+		{"explain_price_difference", map[string]string{"offer_a": "offer-00", "offer_b": "nope"}, `"nope"`},
+	}
+	for _, tt := range tests {
+		if _, msg := getPrompt(t, c, tt.name, tt.args); !strings.Contains(msg, tt.want) {
+			t.Errorf("%s %v: error %q, want %q", tt.name, tt.args, msg, tt.want)
+		}
+	}
+}
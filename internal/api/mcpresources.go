@@ -0,0 +1,281 @@
+// internal/api/mcpresources.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// The catalogue as MCP resources, so clients can attach an offer or a datasheet to context:
+//
+//	gpufindr://offers/{id}        an offer, as returned by fetch_gpu
+//	gpufindr://models/{model}     a model's datasheet and today's prices
+//	gpufindr://providers/{source} a provider's offers by model and region
+//	gpufindr://scans/latest       the current scan and what changed in it
+//	gpufindr://gpus?{query}       the offers matching a /gpus query string, a filter to subscribe to
+const (
+	offerResourcePrefix    = "gpufindr://offers/"
+	modelResourcePrefix    = "gpufindr://models/"
+	providerResourcePrefix = "gpufindr://providers/"
+	latestScanResource     = "gpufindr://scans/latest"
+	filterResource         = "gpufindr://gpus"
+)
+
+// mcpPageSize is how many items a resources/list (or tools/list) page holds.
+const mcpPageSize = 100
+
+// latestScanChanges caps the changes inlined in gpufindr://scans/latest.
+const latestScanChanges = 100
+
+// ModelResource is the content of gpufindr://models/{model}.
+type ModelResource struct {
+	gpumodel.Model
+	// Market is today's offers by total_cost_ph; absent when the model is not on offer.
+	Market        *GroupStats     `json:"market,omitempty"`
+	MinPerGPUH    float64         `json:"min_per_gpu_ph,omitempty"`
+	MedianPerGPUH float64         `json:"median_per_gpu_ph,omitempty"`
+	Providers     []ProviderRange `json:"providers"`
+}
+
+// ProviderResource is the content of gpufindr://providers/{source}.
+type ProviderResource struct {
+	Source  string       `json:"source"`
+	Market  GroupStats   `json:"market"`
+	Models  []GroupStats `json:"models"`
+	Regions []GroupStats `json:"regions"`
+}
+
+// LatestScan is the content of gpufindr://scans/latest.
+type LatestScan struct {
+	Version           string         `json:"version"`
+	ScannedAt         time.Time      `json:"scanned_at"`
+	PreviousScannedAt *time.Time     `json:"previous_scanned_at,omitempty"`
+	Offers            int            `json:"offers"`
+	Counts            map[string]int `json:"counts"`
+	// Changes are the first changes against the previous scan; /scans/{id}/diff has them all.
+	Changes   []OfferChange `json:"changes"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// registerResources adds the resource templates to s, keeps its resource list in step with the
+// catalogue, one entry per model on offer and provider, and notifies subscribers.
+func registerResources(s *server.MCPServer) {
+	s.AddResourceTemplates(
+		server.ServerResourceTemplate{
+			Template: mcp.NewResourceTemplate(offerResourcePrefix+"{id}", "GPU offer",
+				mcp.WithTemplateDescription("One offer of the current scan, by id"),
+				mcp.WithTemplateMIMEType("application/json")),
+			Handler: readOfferResource,
+		},
+		server.ServerResourceTemplate{
+			Template: mcp.NewResourceTemplate(modelResourcePrefix+"{model}", "GPU model",
+				mcp.WithTemplateDescription("A GPU model's datasheet with today's price range and per-provider prices. The model is a name, slug or alias, e.g. h100-sxm"),
+				mcp.WithTemplateMIMEType("application/json")),
+			Handler: readModelResource,
+		},
+		server.ServerResourceTemplate{
+			Template: mcp.NewResourceTemplate(providerResourcePrefix+"{source}", "GPU provider",
+				mcp.WithTemplateDescription("A provider's offers today, in total and by model and region"),
+				mcp.WithTemplateMIMEType("application/json")),
+			Handler: readProviderResource,
+		},
+		server.ServerResourceTemplate{
+			Template: mcp.NewResourceTemplate(filterResource+"{?filter*}", "GPU filter",
+				mcp.WithTemplateDescription("The offers matching a /gpus query string, e.g. gpufindr://gpus?name=h100&max_total_cost_ph=20. Subscribe to be told when a scan changes them"),
+				mcp.WithTemplateMIMEType("application/json")),
+			Handler: readFilterResource,
+		},
+	)
+	catalogue.OnScan(func(cur, prev *snapshot) { s.SetResources(listResources(cur)...) })
+	catalogue.OnScan(watched.onScan(s))
+}
+
+// listResources is the concrete resources of one scan. Offers are left to the
+// gpufindr://offers/{id} template: listing thousands of them on every scan would only flood
+// clients with list_changed.
+func listResources(snap *snapshot) []server.ServerResource {
+	out := []server.ServerResource{{
+		Resource: mcp.NewResource(latestScanResource, "Latest scan",
+			mcp.WithResourceDescription(fmt.Sprintf("Scan of %s: %d offers and what changed since the one before",
+				snap.scannedAt.Format(time.RFC3339), len(snap.gpus))),
+			mcp.WithMIMEType("application/json")),
+		Handler: readLatestScanResource,
+	}}
+	st := snap.stats()
+	for _, m := range gpumodel.Models {
+		for _, g := range st.Models {
+			if g.Key == m.Name {
+				out = append(out, server.ServerResource{
+					Resource: mcp.NewResource(modelResourcePrefix+m.Slug, m.Name,
+						mcp.WithResourceDescription(fmt.Sprintf("%s %g GB datasheet; %d offers from $%.2f/h", m.Spec.Architecture, m.Spec.VramGB, g.Offers, g.MinCostPH)),
+						mcp.WithMIMEType("application/json")),
+					Handler: readModelResource,
+				})
+			}
+		}
+	}
+	for _, g := range st.Sources {
+		out = append(out, server.ServerResource{
+			Resource: mcp.NewResource(providerResourcePrefix+strings.ToLower(g.Key), g.Key,
+				mcp.WithResourceDescription(fmt.Sprintf("%d offers, %d GPUs, from $%.2f/h", g.Offers, g.GPUs, g.MinCostPH)),
+				mcp.WithMIMEType("application/json")),
+			Handler: readProviderResource,
+		})
+	}
+	return out
+}
+
+// jsonResource renders v as the single content of uri.
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(b)}}, nil
+}
+
+// resourceName is the part of uri after prefix, failing when there is none.
+func resourceName(uri, prefix string) (string, error) {
+	name, ok := strings.CutPrefix(uri, prefix)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("%w: %s", server.ErrResourceNotFound, uri)
+	}
+	return name, nil
+}
+
+func readOfferResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id, err := resourceName(req.Params.URI, offerResourcePrefix)
+	if err != nil {
+		return nil, err
+	}
+	g, err := catalogue.Get(ctx, id)
+	if errors.Is(err, errNotFound) {
+		g, err = watched.current(ctx, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("offer %s: %w", id, err)
+	}
+	return jsonResource(req.Params.URI, g)
+}
+
+func readModelResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name, err := resourceName(req.Params.URI, modelResourcePrefix)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := gpumodel.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown GPU model %q", server.ErrResourceNotFound, name)
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	market, providers := marketFor(snap, m)
+	out := ModelResource{Model: m, MinPerGPUH: market.minPerGH, MedianPerGPUH: market.medPerGH, Providers: providers}
+	for _, g := range marketStats(snap).Models {
+		if g.Key == m.Name {
+			out.Market = &g
+		}
+	}
+	return jsonResource(req.Params.URI, out)
+}
+
+func readProviderResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	source, err := resourceName(req.Params.URI, providerResourcePrefix)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := snap.bySource[strings.ToLower(source)]
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no offers from %q", server.ErrResourceNotFound, source)
+	}
+	gpus := make([]GPU, len(rows))
+	for i, r := range rows {
+		gpus[i] = snap.gpus[r]
+	}
+	out := ProviderResource{
+		Source: gpus[0].Source,
+		Market: groupStats(gpus[0].Source, gpus),
+		Models: groupBy(gpus, func(g GPU) string {
+			if m := gpumodel.Canonical(g.Name); m != "" {
+				return m
+			}
+			return otherModel
+		}),
+		Regions: groupBy(gpus, func(g GPU) string { return g.Location }),
+	}
+	for _, g := range marketStats(snap).Sources {
+		if strings.EqualFold(g.Key, source) {
+			out.Market = g
+		}
+	}
+	return jsonResource(req.Params.URI, out)
+}
+
+func readFilterResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	q, err := filterQuery(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, total := snap.run(q)
+	return jsonResource(req.Params.URI, SearchResult{Offers: append([]GPU{}, rows...), ToolPage: toolPage(q, total, len(rows))})
+}
+
+// filterQuery parses the /gpus query of a gpufindr://gpus URI.
+func filterQuery(uri string) (Query, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme+"://"+u.Host+u.Path != filterResource {
+		return Query{}, fmt.Errorf("%w: %s", server.ErrResourceNotFound, uri)
+	}
+	v, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return Query{}, badParam("query", "%v", err)
+	}
+	return parseGPUQuery(v)
+}
+
+func readLatestScanResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := LatestScan{
+		Version:   snap.version,
+		ScannedAt: snap.scannedAt,
+		Offers:    len(snap.gpus),
+		Counts:    map[string]int{},
+		Changes:   []OfferChange{},
+	}
+	if prev := catalogue.Before(snap); prev != nil {
+		at := prev.scannedAt
+		out.PreviousScannedAt = &at
+		changes := diffSnapshots(prev, snap)
+		events := make([]scandiff.Event, len(changes))
+		for i, c := range changes {
+			events[i] = c.Event
+		}
+		out.Counts = scandiff.Counts(events)
+		out.Changes = changes[:min(len(changes), latestScanChanges)]
+		out.Truncated = len(changes) > latestScanChanges
+	}
+	return jsonResource(req.Params.URI, out)
+}
@@ -0,0 +1,237 @@
+// internal/api/mcpserver.go
+package api
+
+import (
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// NewMCPServer returns the gpufindr MCP server with every tool, resource and prompt registered.
+// The resources follow the catalogue, so call it before Run.
+func NewMCPServer() *server.MCPServer {
+	mcpSrv := server.NewMCPServer(
+		"GPUFinder-MCP", "0.1.0",
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(false),
+		server.WithPaginationLimit(mcpPageSize),
+		server.WithLogging(),
+		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(mcpAuth.toolMiddleware),
+		server.WithHooks(mcpStats.hooks()),
+	)
+
+	// search_gpus
+	mcpSrv.AddTool(
+		mcp.NewTool("search_gpus",
+			mcp.WithDescription("Search gpufindr catalogue by name/region/price"),
+			mcp.WithString("query", mcp.Description("substring to match in GPU name. * for any.")),
+			mcp.WithString("region", mcp.Description("substring of region, e.g. us-south-1, * for any")),
+			mcp.WithNumber("max_price", mcp.Description("max USD per-hour price. -1 for any.")),
+			mcp.WithNumber("min_score", mcp.Description("Min score for performance/efficiency. 0 for any."), mcp.Min(0)),
+			mcp.WithString("order_by", mcp.Description("Column to order by (Ex: score.desc, gpu_cost_ph.asc)"), mcp.Pattern(sortPattern())),
+			mcp.WithNumber("limit", mcp.Description("max rows to return (default 50, max 200)"), mcp.Min(1), mcp.Max(200), mcp.DefaultNumber(50)),
+			mcp.WithNumber("offset", mcp.Description("starting row (default 0), next_offset of the previous page"), mcp.Min(0)),
+			mcp.WithOutputSchema[SearchResult](),
+		),
+		searchHandler,
+	)
+
+	// fetch_gpu
+	mcpSrv.AddTool(
+		mcp.NewTool("fetch_gpu",
+			mcp.WithDescription("Fetch a single GPU offer by id"),
+			mcp.WithString("id", mcp.Required(), mcp.Description("ID returned from search")),
+			mcp.WithOutputSchema[GPU](),
+		),
+		fetchHandler,
+	)
+
+	// estimate_memory_fit
+	mcpSrv.AddTool(
+		mcp.NewTool("estimate_memory_fit",
+			mcp.WithDescription("Estimate the VRAM a model needs (weights, KV cache, optimizer state, activations) and list the cheapest offers whose vram_mb × num_gpus hold it"),
+			mcp.WithString("model", mcp.Description("Model preset, e.g. "+strings.Join(presetNames(), ", "))),
+			mcp.WithNumber("params_b", mcp.Description("Parameter count in billions (overrides preset)")),
+			mcp.WithNumber("layers", mcp.Description("Transformer layers (overrides preset)")),
+			mcp.WithNumber("hidden", mcp.Description("Hidden size (overrides preset)")),
+			mcp.WithNumber("heads", mcp.Description("Attention heads (overrides preset)")),
+			mcp.WithNumber("kv_heads", mcp.Description("KV heads for grouped-query attention (overrides preset)")),
+			mcp.WithString("precision", mcp.Description("Weight precision: fp32, fp16, bf16, fp8, int8, int4 (default fp16)")),
+			mcp.WithString("kv_precision", mcp.Description("KV cache precision (default follows precision, fp16 for int8/int4)")),
+			mcp.WithNumber("batch", mcp.Description("Batch size (default 1)")),
+			mcp.WithNumber("context", mcp.Description("Context length in tokens (default 4096)")),
+			mcp.WithString("mode", mcp.Description("inference or training (default inference)")),
+			mcp.WithBoolean("checkpointing", mcp.Description("Activation checkpointing when training")),
+			mcp.WithString("region", mcp.Description("substring of region, * for any")),
+			mcp.WithNumber("max_price", mcp.Description("max USD per-hour price. -1 for any.")),
+			mcp.WithNumber("limit", mcp.Description("max offers to return (default 20, max 200)"), mcp.Min(1), mcp.Max(200), mcp.DefaultNumber(20)),
+			mcp.WithNumber("offset", mcp.Description("starting row (default 0), next_offset of the previous page"), mcp.Min(0)),
+			mcp.WithOutputSchema[MemoryFitResult](),
+		),
+		memoryFitHandler,
+	)
+
+	// featured_gpus
+	mcpSrv.AddTool(
+		mcp.NewTool("featured_gpus",
+			mcp.WithDescription("Today's featured GPU picks, the same cards as the gpufindr front page, each with the reason it was chosen"),
+			mcp.WithString("strategy", mcp.Description("Comma-separated strategies: "+strings.Join(featureStrategyNames(), ", ")+". All by default")),
+			mcp.WithNumber("limit", mcp.Description("cards per strategy (default 1, max 10)"), mcp.Min(1), mcp.Max(10), mcp.DefaultNumber(1)),
+			mcp.WithOutputSchema[FeaturedResult](),
+		),
+		featuredToolHandler,
+	)
+
+	// compare_models
+	mcpSrv.AddTool(
+		mcp.NewTool("compare_models",
+			mcp.WithDescription("Compare 2-5 GPU models side by side: datasheet (FP32 TFLOPs, VRAM, memory bandwidth), today's cheapest and median price per GPU-hour, TFLOPs and GB/s per dollar, per-provider price ranges, winners and ratios to the first model"),
+			mcp.WithString("models", mcp.Required(), mcp.Description("Comma-separated GPU models, e.g. h100,a100")),
+			mcp.WithOutputSchema[Comparison](),
+		),
+		compareToolHandler,
+	)
+
+	mcpSrv.AddTools(recommendTool(), compareOffersTool(), jobCostTool())
+
+	// list_models
+	mcpSrv.AddTool(
+		mcp.NewTool("list_models",
+			mcp.WithDescription("List the canonical GPU models gpufindr knows, with datasheet specs, aliases and today's offer count and prices"),
+			mcp.WithBoolean("on_offer", mcp.Description("Only models with offers right now")),
+			mcp.WithOutputSchema[ModelList](),
+		),
+		listModelsHandler,
+	)
+
+	// list_providers
+	mcpSrv.AddTool(
+		mcp.NewTool("list_providers",
+			mcp.WithDescription("List providers (sources) with offer and GPU counts, min/median/max price and the change since the previous scan, busiest first"),
+			mcp.WithString("query", mcp.Description("substring of the provider name, * for any")),
+			mcp.WithNumber("limit", mcp.Description("max rows (default 50, max 200)"), mcp.Min(1), mcp.Max(200), mcp.DefaultNumber(50)),
+			mcp.WithNumber("offset", mcp.Description("starting row (default 0)"), mcp.Min(0)),
+			mcp.WithOutputSchema[GroupList](),
+		),
+		groupListHandler("providers", func(st MarketStats) []GroupStats { return st.Sources }),
+	)
+
+	// list_regions
+	mcpSrv.AddTool(
+		mcp.NewTool("list_regions",
+			mcp.WithDescription("List regions (offer locations) with offer and GPU counts, min/median/max price and the change since the previous scan, busiest first"),
+			mcp.WithString("query", mcp.Description("substring of the region, e.g. us, * for any")),
+			mcp.WithNumber("limit", mcp.Description("max rows (default 50, max 200)"), mcp.Min(1), mcp.Max(200), mcp.DefaultNumber(50)),
+			mcp.WithNumber("offset", mcp.Description("starting row (default 0)"), mcp.Min(0)),
+			mcp.WithOutputSchema[GroupList](),
+		),
+		groupListHandler("regions", func(st MarketStats) []GroupStats { return st.Regions }),
+	)
+
+	// market_summary
+	mcpSrv.AddTool(
+		mcp.NewTool("market_summary",
+			mcp.WithDescription("Summarise the GPU market as of the latest scan: totals, price range and median, change since the previous scan, and the busiest models, providers and regions"),
+			mcp.WithNumber("top", mcp.Description("models, providers and regions to include (default 5, max 50)"), mcp.Min(1), mcp.Max(50), mcp.DefaultNumber(5)),
+			mcp.WithOutputSchema[MarketStats](),
+		),
+		marketSummaryHandler,
+	)
+
+	// gpufindr:// resources
+	registerResources(mcpSrv)
+
+	// prompts
+	mcpSrv.AddPrompt(
+		mcp.NewPrompt("serve_model",
+			mcp.WithPromptDescription("Pick the cheapest GPU offer to serve a model at a target decode rate, from the offers that hold it and should sustain the rate"),
+			mcp.WithArgument("model", mcp.ArgumentDescription(promptModels()), mcp.RequiredArgument()),
+			mcp.WithArgument("tokens_per_sec", mcp.ArgumentDescription("number: target output tokens/s across the batch"), mcp.RequiredArgument()),
+			mcp.WithArgument("precision", mcp.ArgumentDescription("weight precision: fp32, fp16, bf16, fp8, int8 or int4 (default fp16)")),
+			mcp.WithArgument("batch", mcp.ArgumentDescription("integer: concurrent sequences (default 1)")),
+			mcp.WithArgument("context", mcp.ArgumentDescription("integer: tokens of context per sequence (default 4096)")),
+		),
+		serveModelPromptHandler,
+	)
+	mcpSrv.AddPrompt(
+		mcp.NewPrompt("plan_finetune",
+			mcp.WithPromptDescription("Plan a full fine-tuning run under a budget: the offers that hold the model for training and what the run costs on each"),
+			mcp.WithArgument("model", mcp.ArgumentDescription(promptModels()), mcp.RequiredArgument()),
+			mcp.WithArgument("budget_usd", mcp.ArgumentDescription("number: most the run may cost, in USD"), mcp.RequiredArgument()),
+			mcp.WithArgument("tokens_m", mcp.ArgumentDescription("number: training tokens per epoch, in millions"), mcp.RequiredArgument()),
+			mcp.WithArgument("epochs", mcp.ArgumentDescription("number: passes over the data (default 1)")),
+			mcp.WithArgument("precision", mcp.ArgumentDescription("training precision: fp32, fp16 or bf16 (default bf16)")),
+			mcp.WithArgument("checkpointing", mcp.ArgumentDescription("true to assume activation checkpointing (default false)")),
+		),
+		planFinetunePromptHandler,
+	)
+	mcpSrv.AddPrompt(
+		mcp.NewPrompt("explain_price_difference",
+			mcp.WithPromptDescription("Explain why two GPU offers differ in price, with both offers, their comparison and their models' markets attached"),
+			mcp.WithArgument("offer_a", mcp.ArgumentDescription("offer id"), mcp.RequiredArgument()),
+			mcp.WithArgument("offer_b", mcp.ArgumentDescription("offer id"), mcp.RequiredArgument()),
+		),
+		explainPricePromptHandler,
+	)
+
+	return mcpSrv
+}
+
+// recommendTool defines recommend_gpu.
+func recommendTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.NewTool("recommend_gpu",
+			mcp.WithDescription("Recommend GPU offers for a workload and budget: filters by region, price and VRAM (or a model to fit, as in estimate_memory_fit), keeps one listing per machine, ranks by priority and explains each pick against the market"),
+			mcp.WithString("priority", mcp.Description("value (FP32 per $/h), cheapest or fastest"), mcp.Enum(recommendPriorities...), mcp.DefaultString(priorityValue)),
+			mcp.WithString("model", mcp.Description("Model preset the offer must hold, e.g. "+strings.Join(presetNames(), ", "))),
+			mcp.WithNumber("params_b", mcp.Description("Parameter count in billions (overrides preset)")),
+			mcp.WithString("precision", mcp.Description("Weight precision: fp32, fp16, bf16, fp8, int8, int4 (default fp16)")),
+			mcp.WithNumber("batch", mcp.Description("Batch size (default 1)")),
+			mcp.WithNumber("context", mcp.Description("Context length in tokens (default 4096)")),
+			mcp.WithString("mode", mcp.Description("inference or training (default inference)")),
+			mcp.WithNumber("min_vram_gb", mcp.Description("Min VRAM across the offer's GPUs, in GB"), mcp.Min(0)),
+			mcp.WithString("query", mcp.Description("substring to match in GPU name. * for any.")),
+			mcp.WithString("region", mcp.Description("substring of region, * for any")),
+			mcp.WithNumber("max_price", mcp.Description("max USD per-hour price. -1 for any.")),
+			mcp.WithNumber("hours", mcp.Description("Run length in hours, to price each pick"), mcp.Min(0)),
+			mcp.WithNumber("budget_usd", mcp.Description("Total budget for hours; caps the hourly price at budget_usd / hours"), mcp.Min(0)),
+			mcp.WithNumber("limit", mcp.Description("picks to return (default 5, max 20)"), mcp.Min(1), mcp.Max(20), mcp.DefaultNumber(5)),
+			mcp.WithOutputSchema[Recommendation](),
+		),
+		Handler: recommendHandler,
+	}
+}
+
+// compareOffersTool defines compare_offers.
+func compareOffersTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.NewTool("compare_offers",
+			mcp.WithDescription("Compare 2-5 offers side by side: price per hour and per GPU-hour, FP32 TFLOPs, VRAM, bandwidth, per-dollar ratios, winners and ratios to the first offer"),
+			mcp.WithString("ids", mcp.Required(), mcp.Description("Comma-separated offer ids from search_gpus")),
+			mcp.WithOutputSchema[Comparison](),
+		),
+		Handler: compareOffersToolHandler,
+	}
+}
+
+// jobCostTool defines estimate_job_cost.
+func jobCostTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.NewTool("estimate_job_cost",
+			mcp.WithDescription("Price a job on one offer (id) or on every offer of a GPU model (gpu), cheapest first. Size the job in hours, or in PFLOPs of FP32 work run at a utilization of each offer's total_flops"),
+			mcp.WithString("id", mcp.Description("Offer id; give this or gpu")),
+			mcp.WithString("gpu", mcp.Description("GPU model, e.g. h100; give this or id")),
+			mcp.WithNumber("num_gpus", mcp.Description("Only offers with this many GPUs (with gpu)"), mcp.Min(0)),
+			mcp.WithString("region", mcp.Description("substring of region, * for any (with gpu)")),
+			mcp.WithNumber("hours", mcp.Description("Run length in hours; give this or pflops"), mcp.Min(0)),
+			mcp.WithNumber("pflops", mcp.Description("Total FP32 work in PFLOPs; give this or hours"), mcp.Min(0)),
+			mcp.WithNumber("utilization", mcp.Description("Share of peak FP32 reached, for pflops (default 0.4)"), mcp.Min(0), mcp.Max(1)),
+			mcp.WithNumber("limit", mcp.Description("estimates to return (default 5, max 50)"), mcp.Min(1), mcp.Max(50), mcp.DefaultNumber(5)),
+			mcp.WithOutputSchema[JobCost](),
+		),
+		Handler: jobCostHandler,
+	}
+}
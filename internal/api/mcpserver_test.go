@@ -0,0 +1,94 @@
+// internal/api/mcpserver_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// stdioClient serves NewMCPServer over a pair of pipes, as gpufindr-mcp does over stdin and
+// stdout, and returns an initialized client of it.
+func stdioClient(t *testing.T, gpus []GPU) *client.Client {
+	t.Helper()
+	useCatalogue(t, gpus)
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.NewStdioServer(NewMCPServer()).Listen(ctx, inR, outW)
+		outW.Close()
+	}()
+	c := client.NewClient(transport.NewIO(outR, inW, io.NopCloser(nil)))
+	t.Cleanup(func() {
+		cancel()
+		inW.Close()
+		<-done
+	})
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var init mcp.InitializeRequest
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestStdioServer(t *testing.T) {
+	c := stdioClient(t, testOffers())
+	ctx := context.Background()
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	for _, want := range []string{"search_gpus", "fetch_gpu", "estimate_memory_fit", "recommend_gpu", "compare_offers", "estimate_job_cost"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("tools = %v, missing %s", names, want)
+		}
+	}
+	prompts, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil || len(prompts.Prompts) != 3 {
+		t.Errorf("prompts = %+v, %v", prompts, err)
+	}
+	templates, err := c.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil || len(templates.ResourceTemplates) != 4 {
+		t.Errorf("resource templates = %+v, %v", templates, err)
+	}
+
+	var req mcp.CallToolRequest
+	req.Params.Name, req.Params.Arguments = "fetch_gpu", map[string]any{"id": "offer-03"}
+	res, err := c.CallTool(ctx, req)
+	if err != nil || res.IsError {
+		t.Fatalf("fetch_gpu = %+v, %v", res, err)
+	}
+	var g GPU
+	if b, _ := json.Marshal(res.StructuredContent); json.Unmarshal(b, &g) != nil || g.Id != "offer-03" {
+		t.Errorf("fetch_gpu = %+v", res.StructuredContent)
+	}
+
+	var read mcp.ReadResourceRequest
+	read.Params.URI = offerResourcePrefix + "offer-03"
+	contents, err := c.ReadResource(ctx, read)
+	if err != nil || len(contents.Contents) != 1 {
+		t.Fatalf("read %s = %+v, %v", read.Params.URI, contents, err)
+	}
+	if text, ok := contents.Contents[0].(mcp.TextResourceContents); !ok || json.Unmarshal([]byte(text.Text), &g) != nil || g.Id != "offer-03" {
+		t.Errorf("read %s = %+v", read.Params.URI, contents.Contents[0])
+	}
+}
@@ -0,0 +1,131 @@
+// internal/api/mcpsubscriptions.go
+package api
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// resourceWatch tells MCP sessions which of their subscribed resources a scan changed, going by
+// the scan's diff events. Offer ids are minted per scan, so a subscribed offer is followed by its
+// offerKey and gpufindr://offers/{id} keeps resolving to that key's cheapest listing.
+type resourceWatch struct {
+	mu   sync.Mutex
+	keys map[string]string // subscribed offer id -> offerKey
+}
+
+// watched follows the resources MCP sessions subscribed to, fed by catalogue.OnScan.
+var watched = &resourceWatch{keys: map[string]string{}}
+
+// onScan returns the catalogue hook sending notifications/resources/updated to s's subscribers.
+// The first load has nothing to compare with and notifies nobody.
+func (w *resourceWatch) onScan(s *server.MCPServer) func(cur, prev *snapshot) {
+	return func(cur, prev *snapshot) {
+		if prev == nil {
+			return
+		}
+		uris := s.SubscribedResources()
+		w.track(uris, prev)
+		if len(uris) == 0 {
+			return
+		}
+		go func() {
+			changes := diffSnapshots(prev, cur)
+			for _, uri := range uris {
+				if touched := w.matcher(uri, prev); touched != nil && slices.ContainsFunc(changes, touched) {
+					s.NotifyResourceUpdated(uri)
+				}
+			}
+		}()
+	}
+}
+
+// track records the key of every newly subscribed offer, as listed in prev, and forgets the
+// offers nobody is subscribed to any more.
+func (w *resourceWatch) track(uris []string, prev *snapshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ids := map[string]bool{}
+	for _, uri := range uris {
+		id, ok := strings.CutPrefix(uri, offerResourcePrefix)
+		if !ok {
+			continue
+		}
+		ids[id] = true
+		if i, ok := prev.byID[id]; ok && w.keys[id] == "" {
+			w.keys[id] = offerKey(prev.gpus[i])
+		}
+	}
+	for id := range w.keys {
+		if !ids[id] {
+			delete(w.keys, id)
+		}
+	}
+}
+
+// matcher returns the test a change has to pass to update uri, or nil when nothing can.
+func (w *resourceWatch) matcher(uri string, prev *snapshot) func(OfferChange) bool {
+	if uri == latestScanResource {
+		return func(OfferChange) bool { return true }
+	}
+	if id, ok := strings.CutPrefix(uri, offerResourcePrefix); ok {
+		w.mu.Lock()
+		key := w.keys[id]
+		w.mu.Unlock()
+		if key == "" {
+			return nil
+		}
+		return func(c OfferChange) bool { return c.Key == key }
+	}
+	if name, ok := strings.CutPrefix(uri, modelResourcePrefix); ok {
+		m, ok := gpumodel.Lookup(name)
+		if !ok {
+			return nil
+		}
+		return func(c OfferChange) bool { return gpumodel.Canonical(c.Offer.Name) == m.Name }
+	}
+	if source, ok := strings.CutPrefix(uri, providerResourcePrefix); ok {
+		return func(c OfferChange) bool { return strings.EqualFold(c.Source, source) }
+	}
+	if strings.HasPrefix(uri, filterResource) {
+		q, err := filterQuery(uri)
+		if err != nil {
+			return nil
+		}
+		// A price change can move a listing into or out of the filter: test both sides.
+		return func(c OfferChange) bool {
+			return q.match(c.Offer) || (c.Prev >= 0 && q.match(prev.gpus[c.Prev]))
+		}
+	}
+	return nil
+}
+
+// current is the cheapest listing in the current scan of the subscribed offer id, or errNotFound
+// when id is not followed or its key is gone.
+func (w *resourceWatch) current(ctx context.Context, id string) (GPU, error) {
+	w.mu.Lock()
+	key, ok := w.keys[id]
+	w.mu.Unlock()
+	if !ok {
+		return GPU{}, errNotFound
+	}
+	snap, err := catalogue.Snapshot(ctx)
+	if err != nil {
+		return GPU{}, err
+	}
+	var best *GPU
+	for i, g := range snap.gpus {
+		if offerKey(g) == key && (best == nil || g.TotalCostPH < best.TotalCostPH) {
+			best = &snap.gpus[i]
+		}
+	}
+	if best == nil {
+		return GPU{}, errNotFound
+	}
+	return *best, nil
+}
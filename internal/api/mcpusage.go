@@ -0,0 +1,406 @@
+// internal/api/mcpusage.go
+package api
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcpUsage counts what MCP clients ask for, fed by server hooks: requests by method, tool calls
+// with their latency and argument shapes, resource reads, prompts, errors and session lifetimes.
+// Only argument names and JSON types are kept, never values.
+type mcpUsage struct {
+	mu        sync.Mutex
+	since     time.Time
+	requests  map[string]int // JSON-RPC method -> requests
+	errors    map[string]int // JSON-RPC method -> error responses
+	tools     map[string]*toolUsage
+	resources map[string]int // resource kind -> reads
+	prompts   map[string]int // prompt -> gets
+	clients   map[string]int // authenticated client -> tool calls
+	inFlight  map[*mcp.CallToolRequest]time.Time
+	sessions  map[string]time.Time // session ID -> registered at
+	ended     int
+	lived     time.Duration // total lifetime of the ended sessions
+	longest   time.Duration
+}
+
+type toolUsage struct {
+	calls, errors int
+	total, max    time.Duration
+	recent        []float64 // the last mcpLatencyWindow latencies in ms, a ring
+	next          int
+	shapes        map[string]int
+}
+
+const (
+	// mcpLatencyWindow is how many of a tool's latest calls its percentiles cover.
+	mcpLatencyWindow = 1024
+	// mcpMaxShapes caps the argument shapes kept per tool; the rest are counted as otherShape.
+	mcpMaxShapes = 50
+	otherShape   = "(other)"
+)
+
+// mcpStats is the MCP server's usage since the process started.
+var mcpStats = &mcpUsage{
+	since:     time.Now(),
+	requests:  map[string]int{},
+	errors:    map[string]int{},
+	tools:     map[string]*toolUsage{},
+	resources: map[string]int{},
+	prompts:   map[string]int{},
+	clients:   map[string]int{},
+	inFlight:  map[*mcp.CallToolRequest]time.Time{},
+	sessions:  map[string]time.Time{},
+}
+
+// MCPUsage is the /admin/mcp/usage report, counted since the API started.
+type MCPUsage struct {
+	Since time.Time `json:"since"`
+	// Requests and Errors are by JSON-RPC method, e.g. tools/call.
+	Requests map[string]int `json:"requests"`
+	Errors   map[string]int `json:"errors"`
+	// Tools are the tools called, most called first.
+	Tools []MCPToolUsage `json:"tools"`
+	// Resources are reads by kind: offers, models, providers, scans or gpus.
+	Resources map[string]int `json:"resources"`
+	Prompts   map[string]int `json:"prompts"`
+	// Clients are tool calls by client, when MCP authentication is on.
+	Clients  map[string]int  `json:"clients,omitempty"`
+	Sessions MCPSessionUsage `json:"sessions"`
+}
+
+// MCPToolUsage is one tool's calls. Errors counts both failed calls and error results.
+type MCPToolUsage struct {
+	Name   string  `json:"name"`
+	Calls  int     `json:"calls"`
+	Errors int     `json:"errors"`
+	MeanMs float64 `json:"mean_ms"`
+	// P50Ms and P95Ms cover the latest 1024 calls.
+	P50Ms  float64       `json:"p50_ms"`
+	P95Ms  float64       `json:"p95_ms"`
+	MaxMs  float64       `json:"max_ms"`
+	Shapes []MCPArgShape `json:"shapes"`
+}
+
+// MCPArgShape is a set of arguments a tool was called with, e.g. "limit:number,query:string".
+type MCPArgShape struct {
+	Args  string `json:"args"`
+	Calls int    `json:"calls"`
+}
+
+// MCPSessionUsage covers the sessions holding a stream: streamable HTTP GETs, SSE and stdio.
+type MCPSessionUsage struct {
+	Active      int     `json:"active"`
+	Ended       int     `json:"ended"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// hooks returns the server hooks feeding u.
+func (u *mcpUsage) hooks() *server.Hooks {
+	h := &server.Hooks{}
+	h.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
+		u.mu.Lock()
+		u.requests[string(method)]++
+		u.mu.Unlock()
+	})
+	h.AddOnError(u.onError)
+	h.AddBeforeCallTool(u.beforeCallTool)
+	h.AddAfterCallTool(func(ctx context.Context, id any, req *mcp.CallToolRequest, result *mcp.CallToolResult) {
+		u.endCall(req, result.IsError)
+	})
+	h.AddAfterReadResource(func(ctx context.Context, id any, req *mcp.ReadResourceRequest, result *mcp.ReadResourceResult) {
+		u.mu.Lock()
+		u.resources[resourceKind(req.Params.URI)]++
+		u.mu.Unlock()
+	})
+	h.AddAfterGetPrompt(func(ctx context.Context, id any, req *mcp.GetPromptRequest, result *mcp.GetPromptResult) {
+		u.mu.Lock()
+		u.prompts[req.Params.Name]++
+		u.mu.Unlock()
+	})
+	h.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		u.mu.Lock()
+		u.sessions[session.SessionID()] = time.Now()
+		u.mu.Unlock()
+	})
+	h.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		start, ok := u.sessions[session.SessionID()]
+		if !ok {
+			return
+		}
+		delete(u.sessions, session.SessionID())
+		d := time.Since(start)
+		u.ended++
+		u.lived += d
+		u.longest = max(u.longest, d)
+	})
+	return h
+}
+
+func (u *mcpUsage) beforeCallTool(ctx context.Context, id any, req *mcp.CallToolRequest) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.inFlight[req] = time.Now()
+	if c := mcpClientFrom(ctx); c != nil {
+		u.clients[c.ID]++
+	}
+}
+
+func (u *mcpUsage) onError(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+	u.mu.Lock()
+	u.errors[string(method)]++
+	u.mu.Unlock()
+	req, ok := message.(*mcp.CallToolRequest)
+	if !ok {
+		return
+	}
+	if errors.Is(err, server.ErrToolNotFound) {
+		// A call to an unknown tool is the client's mistake, not a tool to track.
+		u.mu.Lock()
+		delete(u.inFlight, req)
+		u.mu.Unlock()
+		return
+	}
+	u.endCall(req, true)
+}
+
+// endCall records a finished call to req's tool.
+func (u *mcpUsage) endCall(req *mcp.CallToolRequest, failed bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	start, ok := u.inFlight[req]
+	if !ok {
+		return
+	}
+	delete(u.inFlight, req)
+	d := time.Since(start)
+	t := u.tools[req.Params.Name]
+	if t == nil {
+		t = &toolUsage{shapes: map[string]int{}}
+		u.tools[req.Params.Name] = t
+	}
+	t.calls++
+	if failed {
+		t.errors++
+	}
+	t.total += d
+	t.max = max(t.max, d)
+	ms := float64(d) / float64(time.Millisecond)
+	if len(t.recent) < mcpLatencyWindow {
+		t.recent = append(t.recent, ms)
+	} else {
+		t.recent[t.next] = ms
+		t.next = (t.next + 1) % mcpLatencyWindow
+	}
+	shape := argShape(req.Params.Arguments)
+	if _, ok := t.shapes[shape]; !ok && len(t.shapes) >= mcpMaxShapes {
+		shape = otherShape
+	}
+	t.shapes[shape]++
+}
+
+// argShape is the sorted names and JSON types of a call's arguments, e.g.
+// "limit:number,query:string", without any values.
+func argShape(args any) string {
+	m, _ := args.(map[string]any)
+	parts := make([]string, 0, len(m))
+	for name, v := range m {
+		kind := "null"
+		switch v.(type) {
+		case string:
+			kind = "string"
+		case float64, int, int64, json.Number:
+			kind = "number"
+		case bool:
+			kind = "bool"
+		case []any:
+			kind = "array"
+		case map[string]any:
+			kind = "object"
+		}
+		parts = append(parts, name+":"+kind)
+	}
+	slices.Sort(parts)
+	if len(parts) == 0 {
+		return "(none)"
+	}
+	return strings.Join(parts, ",")
+}
+
+// resourceKind is the kind of gpufindr:// resource uri names, e.g. offers, or other.
+func resourceKind(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "gpufindr" {
+		return "other"
+	}
+	switch u.Host {
+	case "offers", "models", "providers", "scans", "gpus":
+		return u.Host
+	}
+	return "other"
+}
+
+// report snapshots u.
+func (u *mcpUsage) report() MCPUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	out := MCPUsage{
+		Since:     u.since,
+		Requests:  maps.Clone(u.requests),
+		Errors:    maps.Clone(u.errors),
+		Tools:     []MCPToolUsage{},
+		Resources: maps.Clone(u.resources),
+		Prompts:   maps.Clone(u.prompts),
+		Sessions:  MCPSessionUsage{Active: len(u.sessions), Ended: u.ended, MaxSeconds: u.longest.Seconds()},
+	}
+	if len(u.clients) > 0 {
+		out.Clients = maps.Clone(u.clients)
+	}
+	if u.ended > 0 {
+		out.Sessions.MeanSeconds = u.lived.Seconds() / float64(u.ended)
+	}
+	for name, t := range u.tools {
+		recent := slices.Clone(t.recent)
+		slices.Sort(recent)
+		tu := MCPToolUsage{
+			Name:   name,
+			Calls:  t.calls,
+			Errors: t.errors,
+			MeanMs: float64(t.total) / float64(time.Millisecond) / float64(t.calls),
+			P50Ms:  quantile(recent, 0.5),
+			P95Ms:  quantile(recent, 0.95),
+			MaxMs:  float64(t.max) / float64(time.Millisecond),
+			Shapes: []MCPArgShape{},
+		}
+		for args, n := range t.shapes {
+			tu.Shapes = append(tu.Shapes, MCPArgShape{Args: args, Calls: n})
+		}
+		slices.SortFunc(tu.Shapes, func(a, b MCPArgShape) int { return cmp.Or(b.Calls-a.Calls, strings.Compare(a.Args, b.Args)) })
+		out.Tools = append(out.Tools, tu)
+	}
+	slices.SortFunc(out.Tools, func(a, b MCPToolUsage) int { return cmp.Or(b.Calls-a.Calls, strings.Compare(a.Name, b.Name)) })
+	return out
+}
+
+// promLabel quotes v as a Prometheus label value.
+func promLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+// writeMetrics renders u in the Prometheus text format.
+func (u *mcpUsage) writeMetrics(w http.ResponseWriter) {
+	rep := u.report()
+	var b strings.Builder
+	family := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	byLabel := func(name, label string, m map[string]int) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s{%s=%s} %d\n", name, label, promLabel(k), m[k])
+		}
+	}
+
+	family("gpufindr_mcp_requests_total", "counter", "MCP requests by JSON-RPC method.")
+	byLabel("gpufindr_mcp_requests_total", "method", rep.Requests)
+	family("gpufindr_mcp_errors_total", "counter", "MCP error responses by JSON-RPC method.")
+	byLabel("gpufindr_mcp_errors_total", "method", rep.Errors)
+	family("gpufindr_mcp_tool_calls_total", "counter", "MCP tool calls by tool.")
+	for _, t := range rep.Tools {
+		fmt.Fprintf(&b, "gpufindr_mcp_tool_calls_total{tool=%s} %d\n", promLabel(t.Name), t.Calls)
+	}
+	family("gpufindr_mcp_tool_errors_total", "counter", "MCP tool calls that failed or returned an error result.")
+	for _, t := range rep.Tools {
+		fmt.Fprintf(&b, "gpufindr_mcp_tool_errors_total{tool=%s} %d\n", promLabel(t.Name), t.Errors)
+	}
+	family("gpufindr_mcp_tool_duration_seconds", "summary", "MCP tool call latency over the latest calls.")
+	for _, t := range rep.Tools {
+		fmt.Fprintf(&b, "gpufindr_mcp_tool_duration_seconds{tool=%s,quantile=\"0.5\"} %g\n", promLabel(t.Name), t.P50Ms/1000)
+		fmt.Fprintf(&b, "gpufindr_mcp_tool_duration_seconds{tool=%s,quantile=\"0.95\"} %g\n", promLabel(t.Name), t.P95Ms/1000)
+		fmt.Fprintf(&b, "gpufindr_mcp_tool_duration_seconds_sum{tool=%s} %g\n", promLabel(t.Name), t.MeanMs*float64(t.Calls)/1000)
+		fmt.Fprintf(&b, "gpufindr_mcp_tool_duration_seconds_count{tool=%s} %d\n", promLabel(t.Name), t.Calls)
+	}
+	family("gpufindr_mcp_resource_reads_total", "counter", "MCP resource reads by kind.")
+	byLabel("gpufindr_mcp_resource_reads_total", "kind", rep.Resources)
+	family("gpufindr_mcp_prompt_gets_total", "counter", "MCP prompts fetched by name.")
+	byLabel("gpufindr_mcp_prompt_gets_total", "prompt", rep.Prompts)
+	family("gpufindr_mcp_client_tool_calls_total", "counter", "MCP tool calls by authenticated client.")
+	byLabel("gpufindr_mcp_client_tool_calls_total", "client", rep.Clients)
+	family("gpufindr_mcp_sessions_active", "gauge", "MCP sessions holding a stream.")
+	fmt.Fprintf(&b, "gpufindr_mcp_sessions_active %d\n", rep.Sessions.Active)
+	family("gpufindr_mcp_session_duration_seconds", "summary", "Lifetime of ended MCP sessions.")
+	fmt.Fprintf(&b, "gpufindr_mcp_session_duration_seconds_sum %g\n", rep.Sessions.MeanSeconds*float64(rep.Sessions.Ended))
+	fmt.Fprintf(&b, "gpufindr_mcp_session_duration_seconds_count %d\n", rep.Sessions.Ended)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// adminAuthorized checks r's bearer token against ADMIN_TOKEN, answering 401 itself when it fails.
+func adminAuthorized(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid admin token"))
+		return false
+	}
+	return true
+}
+
+// mcpUsageHandler godoc
+// @Summary     MCP usage report
+// @Description What MCP clients asked for since the API started: requests and errors by method, tool calls
+// @Description with latency, errors and argument shapes (names and JSON types only), resource reads by kind,
+// @Description prompts, tool calls per authenticated client and session lifetimes.
+// @Description Requires Authorization: Bearer $ADMIN_TOKEN; disabled when ADMIN_TOKEN is unset.
+// @Tags        admin
+// @Produce     json
+// @Success     200  {object} MCPUsage
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Router      /admin/mcp/usage [get]
+func mcpUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(mcpStats.report())
+}
+
+// mcpMetricsHandler godoc
+// @Summary     MCP usage metrics
+// @Description The usage report as Prometheus metrics (gpufindr_mcp_*), for scraping.
+// @Description Requires Authorization: Bearer $ADMIN_TOKEN; disabled when ADMIN_TOKEN is unset.
+// @Tags        admin
+// @Produce     plain
+// @Success     200  {string} string
+// @Failure     401  {object} ErrorResponse  "Unauthorized"
+// @Router      /admin/mcp/metrics [get]
+func mcpMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(w, r) {
+		return
+	}
+	mcpStats.writeMetrics(w)
+}
@@ -0,0 +1,171 @@
+// internal/api/mcpusage_test.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// useMCPStats gives the test usage counters of its own.
+func useMCPStats(t *testing.T) *mcpUsage {
+	old := mcpStats
+	t.Cleanup(func() { mcpStats = old })
+	mcpStats = &mcpUsage{
+		since:     time.Now(),
+		requests:  map[string]int{},
+		errors:    map[string]int{},
+		tools:     map[string]*toolUsage{},
+		resources: map[string]int{},
+		prompts:   map[string]int{},
+		clients:   map[string]int{},
+		inFlight:  map[*mcp.CallToolRequest]time.Time{},
+		sessions:  map[string]time.Time{},
+	}
+	return mcpStats
+}
+
+// useMCPTraffic makes a few MCP calls of every kind and returns the counters they fed.
+func useMCPTraffic(t *testing.T) *mcpUsage {
+	t.Helper()
+	u := useMCPStats(t)
+	c := fullMCPClient(t, testOffers())
+	ctx := context.Background()
+	call := func(name string, args map[string]any) {
+		var req mcp.CallToolRequest
+		req.Params.Name, req.Params.Arguments = name, args
+		_, _ = c.CallTool(ctx, req)
+	}
+	call("recommend_gpu", map[string]any{"limit": 3, "priority": priorityCheapest})
+	call("recommend_gpu", map[string]any{"limit": 1, "priority": "greenest"})
+	call("compare_offers", map[string]any{"ids": "offer-00,offer-01"})
+	call("no_such_tool", nil)
+
+	var read mcp.ReadResourceRequest
+	read.Params.URI = modelResourcePrefix + "h100-sxm"
+	if _, err := c.ReadResource(ctx, read); err != nil {
+		t.Fatal(err)
+	}
+	var prompt mcp.GetPromptRequest
+	prompt.Params.Name = "explain_price_difference"
+	prompt.Params.Arguments = map[string]string{"offer_a": "offer-00", "offer_b": "offer-01"}
+	if _, err := c.GetPrompt(ctx, prompt); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestMCPUsageCountsCalls(t *testing.T) {
+	rep := useMCPTraffic(t).report()
+
+	if rep.Requests["tools/call"] != 4 || rep.Requests["resources/read"] != 1 || rep.Requests["prompts/get"] != 1 {
+		t.Errorf("requests = %v", rep.Requests)
+	}
+	if rep.Errors["tools/call"] != 1 {
+		t.Errorf("errors = %v, want the unknown tool's", rep.Errors)
+	}
+	if len(rep.Tools) != 2 {
+		t.Fatalf("tools = %+v, want recommend_gpu and compare_offers", rep.Tools)
+	}
+	rec := rep.Tools[0]
+	if rec.Name != "recommend_gpu" || rec.Calls != 2 || rec.Errors != 1 || rec.MaxMs < rec.P50Ms {
+		t.Errorf("recommend_gpu = %+v", rec)
+	}
+	if len(rec.Shapes) != 1 || rec.Shapes[0] != (MCPArgShape{Args: "limit:number,priority:string", Calls: 2}) {
+		t.Errorf("recommend_gpu shapes = %+v", rec.Shapes)
+	}
+	if cmp := rep.Tools[1]; cmp.Name != "compare_offers" || cmp.Calls != 1 || cmp.Errors != 0 {
+		t.Errorf("compare_offers = %+v", cmp)
+	}
+	if rep.Resources["models"] != 1 || rep.Prompts["explain_price_difference"] != 1 {
+		t.Errorf("resources %v, prompts %v", rep.Resources, rep.Prompts)
+	}
+}
+
+func TestMCPUsageNeedsAdminToken(t *testing.T) {
+	useMCPStats(t)
+	get := func(h http.HandlerFunc, token string) int {
+		req := httptest.NewRequest("GET", "/admin/mcp/usage", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w.Code
+	}
+	for _, h := range []http.HandlerFunc{mcpUsageHandler, mcpMetricsHandler} {
+		t.Setenv("ADMIN_TOKEN", "")
+		if code := get(h, ""); code != http.StatusUnauthorized {
+			t.Errorf("ADMIN_TOKEN unset: %d", code)
+		}
+		t.Setenv("ADMIN_TOKEN", "s3cret")
+		for token, want := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "s3cret": http.StatusOK} {
+			if code := get(h, token); code != want {
+				t.Errorf("token %q: %d, want %d", token, code, want)
+			}
+		}
+	}
+}
+
+var promSample = regexp.MustCompile(`^gpufindr_mcp_[a-z_]+(\{[a-z_]+="[^"]*"(,[a-z_]+="[^"]*")*\})? -?[0-9.e+-]+$`)
+
+func TestMCPMetricsFormat(t *testing.T) {
+	useMCPTraffic(t)
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	req := httptest.NewRequest("GET", "/admin/mcp/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	mcpMetricsHandler(w, req)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q", ct)
+	}
+
+	typed := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n") {
+		if f := strings.Fields(line); len(f) == 4 && f[0] == "#" && f[1] == "TYPE" {
+			typed[f[2]] = f[3]
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if !promSample.MatchString(line) {
+			t.Errorf("malformed sample %q", line)
+			continue
+		}
+		name, _, _ := strings.Cut(strings.Fields(line)[0], "{")
+		family := strings.TrimSuffix(strings.TrimSuffix(name, "_sum"), "_count")
+		if typed[family] == "" {
+			t.Errorf("%s has no TYPE before it", name)
+		}
+	}
+	for _, want := range []string{
+		`gpufindr_mcp_requests_total{method="tools/call"} 4`,
+		`gpufindr_mcp_errors_total{method="tools/call"} 1`,
+		`gpufindr_mcp_tool_calls_total{tool="recommend_gpu"} 2`,
+		`gpufindr_mcp_tool_errors_total{tool="recommend_gpu"} 1`,
+		`gpufindr_mcp_tool_duration_seconds_count{tool="compare_offers"} 1`,
+		`gpufindr_mcp_resource_reads_total{kind="models"} 1`,
+		`gpufindr_mcp_prompt_gets_total{prompt="explain_price_difference"} 1`,
+		`gpufindr_mcp_sessions_active 0`,
+	} {
+		if !strings.Contains(w.Body.String(), want+"\n") {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if typed["gpufindr_mcp_tool_duration_seconds"] != "summary" || typed["gpufindr_mcp_sessions_active"] != "gauge" {
+		t.Errorf("types = %v", typed)
+	}
+}
+
+func TestPromLabelEscapes(t *testing.T) {
+	if got := promLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("promLabel = %s", got)
+	}
+}
@@ -0,0 +1,376 @@
+// internal/api/memfit.go
+package api
+
+import (
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const mib = 1 << 20
+
+// overheadFraction covers CUDA context, allocator fragmentation and framework buffers.
+const overheadFraction = 0.10
+
+// ModelPreset describes the shape of a known transformer.
+type ModelPreset struct {
+	Name    string  `json:"name"`
+	ParamsB float64 `json:"params_b"` // billions of parameters
+	Layers  int     `json:"layers"`
+	Hidden  int     `json:"hidden"`
+	Heads   int     `json:"heads"`
+	KVHeads int     `json:"kv_heads"`
+	HeadDim int     `json:"head_dim"`
+}
+
+var modelPresets = map[string]ModelPreset{
+	"llama-2-7b":    {"llama-2-7b", 6.74, 32, 4096, 32, 32, 128},
+	"llama-2-13b":   {"llama-2-13b", 13.0, 40, 5120, 40, 40, 128},
+	"llama-2-70b":   {"llama-2-70b", 69.0, 80, 8192, 64, 8, 128},
+	"llama-3-8b":    {"llama-3-8b", 8.03, 32, 4096, 32, 8, 128},
+	"llama-3-70b":   {"llama-3-70b", 70.6, 80, 8192, 64, 8, 128},
+	"llama-3-405b":  {"llama-3-405b", 405.9, 126, 16384, 128, 8, 128},
+	"mistral-7b":    {"mistral-7b", 7.24, 32, 4096, 32, 8, 128},
+	"mixtral-8x7b":  {"mixtral-8x7b", 46.7, 32, 4096, 32, 8, 128},
+	"mixtral-8x22b": {"mixtral-8x22b", 141.0, 56, 6144, 48, 8, 128},
+	"qwen-2.5-7b":   {"qwen-2.5-7b", 7.62, 28, 3584, 28, 4, 128},
+	"qwen-2.5-72b":  {"qwen-2.5-72b", 72.7, 80, 8192, 64, 8, 128},
+	"gemma-2-9b":    {"gemma-2-9b", 9.24, 42, 3584, 16, 8, 256},
+	"gemma-2-27b":   {"gemma-2-27b", 27.2, 46, 4608, 32, 16, 128},
+	"phi-3-mini":    {"phi-3-mini", 3.82, 32, 3072, 32, 32, 96},
+}
+
+// modelAliases maps loose spellings onto a preset key.
+var modelAliases = map[string]string{
+	"llama-7b":       "llama-2-7b",
+	"llama-13b":      "llama-2-13b",
+	"llama-8b":       "llama-3-8b",
+	"llama-3.1-8b":   "llama-3-8b",
+	"llama-70b":      "llama-3-70b",
+	"llama-3.1-70b":  "llama-3-70b",
+	"llama-3.3-70b":  "llama-3-70b",
+	"llama-405b":     "llama-3-405b",
+	"llama-3.1-405b": "llama-3-405b",
+	"mistral":        "mistral-7b",
+	"mixtral":        "mixtral-8x7b",
+	"qwen2.5-7b":     "qwen-2.5-7b",
+	"qwen2.5-72b":    "qwen-2.5-72b",
+	"phi-3":          "phi-3-mini",
+}
+
+// bytes per element for each supported precision
+var precisionBytes = map[string]float64{
+	"fp32": 4,
+	"fp16": 2,
+	"bf16": 2,
+	"fp8":  1,
+	"int8": 1,
+	"int4": 0.5,
+}
+
+const (
+	modeInference = "inference"
+	modeTraining  = "training"
+)
+
+// MemoryFitRequest is the input to the VRAM calculator. Either Model or ParamsB must be set;
+// explicit dims override the preset.
+type MemoryFitRequest struct {
+	Model         string  `json:"model,omitempty"`
+	ParamsB       float64 `json:"params_b,omitempty"`
+	Layers        int     `json:"layers,omitempty"`
+	Hidden        int     `json:"hidden,omitempty"`
+	Heads         int     `json:"heads,omitempty"`
+	KVHeads       int     `json:"kv_heads,omitempty"`
+	Precision     string  `json:"precision"`
+	KVPrecision   string  `json:"kv_precision"`
+	Batch         int     `json:"batch"`
+	Context       int     `json:"context"`
+	Mode          string  `json:"mode"`
+	Checkpointing bool    `json:"checkpointing,omitempty"`
+}
+
+// MemoryEstimate is the VRAM breakdown, all sizes in MiB to line up with vram_mb.
+type MemoryEstimate struct {
+	Model         string  `json:"model,omitempty"`
+	Mode          string  `json:"mode"`
+	Precision     string  `json:"precision"`
+	KVPrecision   string  `json:"kv_precision"`
+	Params        float64 `json:"params"`
+	Batch         int     `json:"batch"`
+	Context       int     `json:"context"`
+	WeightsMB     float64 `json:"weights_mb"`
+	KVCacheMB     float64 `json:"kv_cache_mb"`
+	GradientsMB   float64 `json:"gradients_mb"`
+	OptimizerMB   float64 `json:"optimizer_mb"`
+	ActivationsMB float64 `json:"activations_mb"`
+	OverheadMB    float64 `json:"overhead_mb"`
+	TotalMB       float64 `json:"total_mb"`
+	// Estimated is set when layer/hidden dims were guessed from the parameter count.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// Fit pairs an offer with how much room it leaves over the estimate.
+type Fit struct {
+	GPU
+	TotalVramMB int     `json:"total_vram_mb"`
+	HeadroomMB  float64 `json:"headroom_mb"`
+}
+
+func normalizeModelName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer(" ", "-", "_", "-").Replace(s)
+	s = strings.TrimPrefix(s, "meta-")
+	return s
+}
+
+func lookupPreset(name string) (ModelPreset, bool) {
+	n := normalizeModelName(name)
+	if a, ok := modelAliases[n]; ok {
+		n = a
+	}
+	p, ok := modelPresets[n]
+	return p, ok
+}
+
+// presetNames returns the sorted preset keys, used in error messages and tool descriptions.
+func presetNames() []string {
+	out := make([]string, 0, len(modelPresets))
+	for k := range modelPresets {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// estimateMemory computes the VRAM a model needs for the requested workload.
+func estimateMemory(req MemoryFitRequest) (MemoryEstimate, error) {
+	var shape ModelPreset
+	if req.Model != "" {
+		p, ok := lookupPreset(req.Model)
+		if !ok {
+			return MemoryEstimate{}, badParam("model", "unknown model %q (known: %s)", req.Model, strings.Join(presetNames(), ", "))
+		}
+		shape = p
+	}
+	if req.ParamsB > 0 {
+		shape.ParamsB = req.ParamsB
+	}
+	if req.Layers > 0 {
+		shape.Layers = req.Layers
+	}
+	if req.Hidden > 0 {
+		shape.Hidden = req.Hidden
+		shape.HeadDim = 0
+	}
+	if req.Heads > 0 {
+		shape.Heads = req.Heads
+		shape.HeadDim = 0
+	}
+	if req.KVHeads > 0 {
+		shape.KVHeads = req.KVHeads
+	}
+	if shape.ParamsB <= 0 {
+		return MemoryEstimate{}, badParam("model", "model or params_b is required")
+	}
+	// Attention splits the hidden size evenly across heads, and query heads evenly across KV heads.
+	if shape.Heads > 0 && shape.Hidden > 0 && shape.Hidden%shape.Heads != 0 {
+		param := "heads"
+		if req.Heads <= 0 {
+			param = "hidden"
+		}
+		return MemoryEstimate{}, badParam(param, "%d heads do not divide a hidden size of %d", shape.Heads, shape.Hidden)
+	}
+	if shape.Heads > 0 && shape.KVHeads > 0 && shape.Heads%shape.KVHeads != 0 {
+		return MemoryEstimate{}, badParam("kv_heads", "%d KV heads do not divide %d heads", shape.KVHeads, shape.Heads)
+	}
+
+	precision := strings.ToLower(req.Precision)
+	if precision == "" {
+		precision = "fp16"
+	}
+	wBytes, ok := precisionBytes[precision]
+	if !ok {
+		return MemoryEstimate{}, badParam("precision", "unknown precision %q", req.Precision)
+	}
+	kvPrecision := strings.ToLower(req.KVPrecision)
+	if kvPrecision == "" {
+		// quantised weights usually keep a 16-bit cache
+		kvPrecision = precision
+		if wBytes < 1 || precision == "int8" {
+			kvPrecision = "fp16"
+		}
+	}
+	kvBytes, ok := precisionBytes[kvPrecision]
+	if !ok {
+		return MemoryEstimate{}, badParam("kv_precision", "unknown precision %q", req.KVPrecision)
+	}
+
+	mode := strings.ToLower(req.Mode)
+	if mode == "" {
+		mode = modeInference
+	}
+	if mode != modeInference && mode != modeTraining {
+		return MemoryEstimate{}, badParam("mode", "must be %s or %s", modeInference, modeTraining)
+	}
+	batch := req.Batch
+	if batch <= 0 {
+		batch = 1
+	}
+	ctx := req.Context
+	if ctx <= 0 {
+		ctx = 4096
+	}
+
+	params := shape.ParamsB * 1e9
+	est := MemoryEstimate{
+		Model:       shape.Name,
+		Mode:        mode,
+		Precision:   precision,
+		KVPrecision: kvPrecision,
+		Params:      params,
+		Batch:       batch,
+		Context:     ctx,
+	}
+
+	// Unknown dims: dense transformers have params ~= 12*L*h^2 and h/L ~= 100.
+	if shape.Layers <= 0 || shape.Hidden <= 0 {
+		est.Estimated = true
+		h := math.Cbrt(params * 100 / 12)
+		if shape.Hidden <= 0 {
+			shape.Hidden = int(h)
+			if shape.Heads > 0 {
+				// round up to whole heads
+				shape.Hidden = (shape.Hidden + shape.Heads - 1) / shape.Heads * shape.Heads
+			}
+		}
+		if shape.Layers <= 0 {
+			shape.Layers = maxInt(1, int(params/(12*float64(shape.Hidden)*float64(shape.Hidden))))
+		}
+	}
+	kvWidth := float64(shape.Hidden) // multi-head attention unless told otherwise
+	if shape.Heads > 0 && shape.KVHeads > 0 {
+		headDim := shape.HeadDim
+		if headDim <= 0 {
+			headDim = shape.Hidden / shape.Heads
+		}
+		kvWidth = float64(shape.KVHeads * headDim)
+	}
+
+	tokens := float64(batch) * float64(ctx)
+	layers := float64(shape.Layers)
+	hidden := float64(shape.Hidden)
+
+	est.WeightsMB = params * wBytes / mib
+	switch mode {
+	case modeInference:
+		// K and V per layer per token
+		est.KVCacheMB = 2 * layers * kvWidth * tokens * kvBytes / mib
+		// transient activations for the layer being evaluated during prefill
+		est.ActivationsMB = 4 * tokens * hidden * 2 / mib
+	case modeTraining:
+		est.GradientsMB = params * wBytes / mib
+		// Adam m and v in fp32, plus fp32 master weights when training in reduced precision
+		optBytes := 8.0
+		if wBytes < 4 {
+			optBytes = 12
+		}
+		est.OptimizerMB = params * optBytes / mib
+		// Korthikanti et al. without the attention-score term (flash attention):
+		// 34*s*b*h bytes per layer, or just the layer inputs with full recomputation.
+		perLayer := 34.0
+		if req.Checkpointing {
+			perLayer = 2
+		}
+		est.ActivationsMB = layers * perLayer * tokens * hidden / mib
+	}
+	sub := est.WeightsMB + est.KVCacheMB + est.GradientsMB + est.OptimizerMB + est.ActivationsMB
+	est.OverheadMB = sub * overheadFraction
+	est.TotalMB = sub + est.OverheadMB
+	return est, nil
+}
+
+// fits reports whether the combined VRAM of the offer holds the estimate.
+func (e MemoryEstimate) fits(g GPU) bool {
+	return float64(g.Vram*maxInt(g.NumGPUs, 1)) >= e.TotalMB
+}
+
+// fitOffers keeps the offers that hold the estimate, preserving their order.
+func fitOffers(e MemoryEstimate, gpus []GPU) []Fit {
+	out := make([]Fit, 0, len(gpus))
+	for _, g := range gpus {
+		if !e.fits(g) {
+			continue
+		}
+		total := g.Vram * maxInt(g.NumGPUs, 1)
+		out = append(out, Fit{GPU: g, TotalVramMB: total, HeadroomMB: float64(total) - e.TotalMB})
+	}
+	return out
+}
+
+// memoryFitParams are the query parameters parseMemoryFit reads.
+var memoryFitParams = []string{
+	"fit_model", "fit_params", "fit_layers", "fit_hidden", "fit_heads", "fit_kv_heads",
+	"fit_precision", "fit_kv_precision", "fit_batch", "fit_context", "fit_mode", "fit_checkpointing",
+}
+
+// parseMemoryFit reads the fit_* query params. It returns nil when no fit was requested.
+func parseMemoryFit(q url.Values) (*MemoryFitRequest, error) {
+	if q.Get("fit_model") == "" && q.Get("fit_params") == "" {
+		for _, k := range memoryFitParams {
+			if q.Get(k) != "" {
+				return nil, badParam(k, "needs fit_model or fit_params")
+			}
+		}
+		return nil, nil
+	}
+	req := &MemoryFitRequest{
+		Model:       q.Get("fit_model"),
+		Precision:   q.Get("fit_precision"),
+		KVPrecision: q.Get("fit_kv_precision"),
+		Mode:        q.Get("fit_mode"),
+	}
+	var err error
+	if s := q.Get("fit_params"); s != "" {
+		if req.ParamsB, err = strconv.ParseFloat(s, 64); err != nil || req.ParamsB <= 0 {
+			return nil, badParam("fit_params", "must be a positive number of billions")
+		}
+	}
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"fit_layers", &req.Layers},
+		{"fit_hidden", &req.Hidden},
+		{"fit_heads", &req.Heads},
+		{"fit_kv_heads", &req.KVHeads},
+		{"fit_batch", &req.Batch},
+		{"fit_context", &req.Context},
+	}
+	for _, f := range ints {
+		s := q.Get(f.key)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return nil, badParam(f.key, "must be a positive integer")
+		}
+		*f.dst = n
+	}
+	if s := q.Get("fit_checkpointing"); s != "" {
+		if req.Checkpointing, err = strconv.ParseBool(s); err != nil {
+			return nil, badParam("fit_checkpointing", "must be true or false")
+		}
+	}
+	return req, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
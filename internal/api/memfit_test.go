@@ -0,0 +1,127 @@
+// internal/api/memfit_test.go
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEstimateMemory(t *testing.T) {
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.01 }
+
+	// llama-2-7b has full multi-head attention: K and V are 4096 wide in each of 32 layers.
+	est, err := estimateMemory(MemoryFitRequest{Model: "Llama 2 7B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Model != "llama-2-7b" || est.Precision != "fp16" || est.KVPrecision != "fp16" || est.Batch != 1 || est.Context != 4096 || est.Estimated {
+		t.Errorf("defaults = %+v", est)
+	}
+	if !near(est.WeightsMB, 6.74e9*2/mib) || !near(est.KVCacheMB, 2048) || !near(est.ActivationsMB, 128) || est.GradientsMB != 0 {
+		t.Errorf("llama-2-7b = %+v", est)
+	}
+	if !near(est.TotalMB, (est.WeightsMB+est.KVCacheMB+est.ActivationsMB)*(1+overheadFraction)) {
+		t.Errorf("total %v does not add up", est.TotalMB)
+	}
+
+	// Grouped-query attention keeps 8 of llama-3-8b's 32 heads, a quarter of the cache.
+	gqa, _ := estimateMemory(MemoryFitRequest{Model: "llama-3-8b"})
+	if !near(gqa.KVCacheMB, 512) {
+		t.Errorf("llama-3-8b KV cache = %v MiB, want 512", gqa.KVCacheMB)
+	}
+	// int4 weights keep a 16-bit cache.
+	if q, _ := estimateMemory(MemoryFitRequest{Model: "llama-3-8b", Precision: "int4"}); q.KVPrecision != "fp16" || !near(q.WeightsMB, gqa.WeightsMB/4) {
+		t.Errorf("int4 = %+v", q)
+	}
+
+	train, _ := estimateMemory(MemoryFitRequest{Model: "llama-3-8b", Mode: "training", Precision: "bf16"})
+	if !near(train.GradientsMB, train.WeightsMB) || !near(train.OptimizerMB, 8.03e9*12/mib) || train.KVCacheMB != 0 {
+		t.Errorf("training = %+v", train)
+	}
+	ckpt, _ := estimateMemory(MemoryFitRequest{Model: "llama-3-8b", Mode: "training", Precision: "bf16", Checkpointing: true})
+	if !near(ckpt.ActivationsMB*17, train.ActivationsMB) {
+		t.Errorf("checkpointing keeps %v of %v MiB of activations, want 1/17", ckpt.ActivationsMB, train.ActivationsMB)
+	}
+
+	// Without dims the shape is guessed, and a guessed hidden size still splits into whole heads.
+	guess, err := estimateMemory(MemoryFitRequest{ParamsB: 7, Heads: 30})
+	if err != nil || !guess.Estimated {
+		t.Errorf("7B by parameter count = %+v, %v", guess, err)
+	}
+}
+
+func TestEstimateMemoryRejects(t *testing.T) {
+	for _, tt := range []struct {
+		req   MemoryFitRequest
+		param string
+	}{
+		{MemoryFitRequest{}, "model"},
+		{MemoryFitRequest{Model: "gpt-5"}, "model"},
+		{MemoryFitRequest{ParamsB: 7, Precision: "fp4"}, "precision"},
+		{MemoryFitRequest{ParamsB: 7, KVPrecision: "int2"}, "kv_precision"},
+		{MemoryFitRequest{ParamsB: 7, Mode: "serving"}, "mode"},
+		{MemoryFitRequest{ParamsB: 7, Hidden: 64, Heads: 128}, "heads"},
+		{MemoryFitRequest{Model: "llama-3-8b", Heads: 30}, "heads"},
+		{MemoryFitRequest{Model: "llama-3-8b", Hidden: 5000}, "hidden"},
+		{MemoryFitRequest{Model: "llama-3-8b", KVHeads: 5}, "kv_heads"},
+	} {
+		_, err := estimateMemory(tt.req)
+		var pe *paramError
+		if !errors.As(err, &pe) || pe.param != tt.param {
+			t.Errorf("%+v: error %v, want one on %s", tt.req, err, tt.param)
+		}
+	}
+}
+
+func TestMemoryFitsAcrossGPUs(t *testing.T) {
+	est := MemoryEstimate{TotalMB: 40000}
+	for _, tt := range []struct {
+		vram, gpus int
+		want       bool
+	}{
+		{40960, 1, true},
+		{24576, 1, false},
+		{24576, 2, true},
+		{40000, 0, true}, // an unknown GPU count is one GPU
+		{16384, 2, false},
+	} {
+		if got := est.fits(GPU{Vram: tt.vram, NumGPUs: tt.gpus}); got != tt.want {
+			t.Errorf("%d MiB x %d: fits = %v, want %v", tt.vram, tt.gpus, got, tt.want)
+		}
+	}
+
+	fits := fitOffers(est, []GPU{{Id: "small", Vram: 24576, NumGPUs: 1}, {Id: "pair", Vram: 24576, NumGPUs: 2}})
+	if len(fits) != 1 || fits[0].Id != "pair" || fits[0].TotalVramMB != 49152 || fits[0].HeadroomMB != 9152 {
+		t.Errorf("fits = %+v", fits)
+	}
+}
+
+func TestGetGPUsThatFit(t *testing.T) {
+	// A pair of 4090s holds llama-2-13b where one does not.
+	gpus := append(testOffers(), GPU{Id: "offer-4090x2", Name: "RTX 4090", Source: "vast", Vram: 24576, NumGPUs: 2, TotalCostPH: 0.70})
+	useCatalogue(t, gpus)
+
+	w, page := getGPUs(t, "fit_model=llama-2-13b")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var ids []string
+	for _, g := range page {
+		ids = append(ids, g.Id)
+	}
+	if got := strings.Join(ids, ","); got != "offer-4090x2,offer-10,offer-11,offer-02,offer-03,offer-00,offer-01" {
+		t.Errorf("fits cheapest first: %s", got)
+	}
+	if w.Header().Get("X-Required-Vram-Mb") == "" {
+		t.Error("no X-Required-Vram-Mb")
+	}
+
+	for _, q := range []string{"fit_heads=32", "fit_precision=int4&fit_batch=8", "fit_model=llama-3-8b&fit_heads=30", "fit_params=-1"} {
+		if w, _ := getGPUs(t, q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", q, w.Code)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// internal/api/models.go
+package api
+
+import "github.com/shaymanor/gpuscanner/internal/gpumodel"
+
+// nameMatcher returns a predicate for a user-supplied GPU name. The term matches every model
+// whose name has it as whole words ("h100" matches SXM, NVL and PCIe, "rtx" every RTX card,
+// but "a10" not the A100 nor "l4" the L40S); failing that, the model it spells ("H100 SXM5
+// 80GB" is the H100 SXM). Offers whose raw name has the term as whole words match either way.
+func nameMatcher(term string) func(GPU) bool {
+	models := map[string]bool{}
+	for _, m := range gpumodel.Models {
+		if gpumodel.HasWord(m.Name, term) {
+			models[m.Name] = true
+		}
+	}
+	if len(models) == 0 {
+		if c := gpumodel.Canonical(term); c != "" {
+			models[c] = true
+		}
+	}
+	return func(g GPU) bool {
+		if models[gpumodel.Canonical(g.Name)] {
+			return true
+		}
+		return gpumodel.HasWord(g.Name, term)
+	}
+}
@@ -0,0 +1,35 @@
+// internal/api/models_test.go
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNameMatcher(t *testing.T) {
+	names := []string{"A10", "A10G", "A100 SXM4 80GB", "A100 PCIe", "RTX A1000", "L4", "L40", "L40S", "H100 SXM5", "H100 NVL"}
+	tests := []struct {
+		term string
+		want []string
+	}{
+		{"a10", []string{"A10", "A10G"}},
+		{"a100", []string{"A100 SXM4 80GB", "A100 PCIe"}},
+		{"l4", []string{"L4"}},
+		{"l40", []string{"L40", "L40S"}},
+		{"h100", []string{"H100 SXM5", "H100 NVL"}},
+		{"H100 SXM5 80GB", []string{"H100 SXM5"}},
+		{"a1000", []string{"RTX A1000"}},
+	}
+	for _, tt := range tests {
+		match := nameMatcher(tt.term)
+		var got []string
+		for _, n := range names {
+			if match(GPU{Name: n}) {
+				got = append(got, n)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("nameMatcher(%q) matched %v, want %v", tt.term, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// internal/api/params.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxLimit is the most rows any list endpoint returns in one page.
+const maxLimit = 1000
+
+// ErrorResponse is the body of every non-2xx JSON response
+// swagger:model ErrorResponse
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes what went wrong. Param names the offending query parameter, if any.
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+}
+
+// paramError is a client error tied to one request parameter.
+type paramError struct {
+	param string
+	msg   string
+}
+
+func (e *paramError) Error() string {
+	if e.param == "" {
+		return e.msg
+	}
+	return e.param + ": " + e.msg
+}
+
+func badParam(param, format string, args ...any) *paramError {
+	return &paramError{param: param, msg: fmt.Sprintf(format, args...)}
+}
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          "invalid_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusNotFound:            "not_found",
+	http.StatusGone:                "cursor_expired",
+	http.StatusInternalServerError: "internal_error",
+	http.StatusBadGateway:          "upstream_error",
+}
+
+// writeError sends err as an ErrorResponse.
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := ErrorResponse{Error: APIError{Code: errorCodes[status], Message: err.Error()}}
+	var pe *paramError
+	if errors.As(err, &pe) {
+		body.Error.Param = pe.param
+		body.Error.Message = pe.msg
+	}
+	if body.Error.Code == "" {
+		body.Error.Code = strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// checkParams rejects any parameter allowed does not accept.
+func checkParams(q url.Values, allowed func(string) bool) error {
+	var unknown []string
+	for key := range q {
+		if !allowed(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return badParam(unknown[0], "unknown parameter")
+}
+
+// paramSet is an allow-list for checkParams.
+func paramSet(keys ...string) func(string) bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return func(k string) bool { return set[k] }
+}
+
+// parsePage reads limit and offset, defaulting limit to def and clamping it to max.
+func parsePage(q url.Values, def, max int) (limit, offset int, err error) {
+	limit = def
+	if s := q.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 {
+			return 0, 0, badParam("limit", "must be a positive integer")
+		}
+	}
+	if s := q.Get("offset"); s != "" {
+		if offset, err = strconv.Atoi(s); err != nil || offset < 0 {
+			return 0, 0, badParam("offset", "must be a non-negative integer")
+		}
+	}
+	return min(limit, max), offset, nil
+}
@@ -0,0 +1,109 @@
+// internal/api/params_test.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// apiError decodes the ErrorResponse in w.
+func apiError(t *testing.T, w *httptest.ResponseRecorder) APIError {
+	t.Helper()
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %s: %v", w.Body, err)
+	}
+	return body.Error
+}
+
+func TestGetGPUsParamErrors(t *testing.T) {
+	useCatalogue(t, testOffers())
+	for _, tt := range []struct{ query, param string }{
+		{"colour=red", "colour"},
+		{"limit=0", "limit"},
+		{"limit=ten", "limit"},
+		{"offset=-1", "offset"},
+		{"min_nope=1", "min_nope"},
+		{"min_source=1", "min_source"},
+		{"max_vram_mb=big", "max_vram_mb"},
+		{"max_price=cheap", "max_price"},
+		{"sort=nope.asc", "sort"},
+		{"sort=vram_mb.up", "sort"},
+		{"filter=" + url.QueryEscape("vram_mb>="), "filter"},
+		{"fit_model=gpt-5", "fit_model"},
+		{"fit_model=llama-3-8b&fit_precision=fp4", "fit_precision"},
+	} {
+		w, _ := getGPUs(t, tt.query)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tt.query, w.Code)
+			continue
+		}
+		if e := apiError(t, w); e.Code != "invalid_request" || e.Param != tt.param || e.Message == "" {
+			t.Errorf("%s: error %+v, want one on %s", tt.query, e, tt.param)
+		}
+	}
+
+	// The same validation guards /gpus/count.
+	w := httptest.NewRecorder()
+	countHandler(w, httptest.NewRequest("GET", "/gpus/count?colour=red", nil))
+	if w.Code != http.StatusBadRequest || apiError(t, w).Param != "colour" {
+		t.Errorf("count: status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestGetGPUsAcceptsKnownParams(t *testing.T) {
+	useCatalogue(t, testOffers())
+	for query, n := range map[string]int{
+		"limit=5000":                       12, // clamped, not refused
+		"max_price=0.5&min_flopsd=0":       3,
+		"cols=name,source&cpu_arch=":       12,
+		"min_vram_mb=49152&max_num_gpus=1": 6,
+	} {
+		w, page := getGPUs(t, query)
+		if w.Code != http.StatusOK || len(page) != n {
+			t.Errorf("%s: status %d, %d offers, want %d: %s", query, w.Code, len(page), n, w.Body)
+		}
+	}
+}
+
+func TestBlogParamErrors(t *testing.T) {
+	var upstream url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.URL.Query()
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("SUPABASE_URL", srv.URL)
+	t.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	for query, param := range map[string]string{"page=2": "page", "slug=Hello%20World": "slug", "limit=0": "limit", "offset=x": "offset"} {
+		w := httptest.NewRecorder()
+		blogHandler(w, httptest.NewRequest("GET", "/blog?"+query, nil))
+		if w.Code != http.StatusBadRequest || apiError(t, w).Param != param {
+			t.Errorf("%s: status %d: %s", query, w.Code, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	blogHandler(w, httptest.NewRequest("GET", "/blog?slug=h100-prices", nil))
+	if w.Code != http.StatusOK || upstream.Get("slug") != "eq.h100-prices" || upstream.Get("limit") != "1" {
+		t.Errorf("slug: status %d, upstream query %v", w.Code, upstream)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, http.StatusBadRequest, badParam("limit", "must be a positive integer"))
+	if e := apiError(t, w); e != (APIError{Code: "invalid_request", Message: "must be a positive integer", Param: "limit"}) {
+		t.Errorf("param error = %+v", e)
+	}
+	w = httptest.NewRecorder()
+	writeError(w, http.StatusTooManyRequests, errors.New("slow down"))
+	if e := apiError(t, w); w.Code != http.StatusTooManyRequests || e != (APIError{Code: "too_many_requests", Message: "slow down"}) {
+		t.Errorf("429 = %+v", e)
+	}
+}
@@ -0,0 +1,226 @@
+// internal/api/parquet.go
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// A minimal Parquet writer for exports: one row group, one uncompressed PLAIN data page
+// per column, every column REQUIRED. See https://github.com/apache/parquet-format.
+
+const (
+	parquetDouble    = 5
+	parquetByteArray = 6
+	parquetInt64     = 2
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+)
+
+// parquetColumn is one column of an export, values already in column order.
+type parquetColumn struct {
+	name string
+	typ  int32 // parquetDouble, parquetByteArray or parquetInt64
+	conv int32 // converted type, -1 for none
+
+	doubles []float64
+	int64s  []int64
+	strings []string
+}
+
+func (c *parquetColumn) plain() []byte {
+	var b bytes.Buffer
+	var n [8]byte
+	switch c.typ {
+	case parquetDouble:
+		for _, f := range c.doubles {
+			binary.LittleEndian.PutUint64(n[:], math.Float64bits(f))
+			b.Write(n[:])
+		}
+	case parquetInt64:
+		for _, v := range c.int64s {
+			binary.LittleEndian.PutUint64(n[:], uint64(v))
+			b.Write(n[:])
+		}
+	case parquetByteArray:
+		for _, s := range c.strings {
+			binary.LittleEndian.PutUint32(n[:4], uint32(len(s)))
+			b.Write(n[:4])
+			b.WriteString(s)
+		}
+	}
+	return b.Bytes()
+}
+
+// writeParquet writes numRows rows held in cols as a Parquet file.
+func writeParquet(w io.Writer, numRows int, cols []*parquetColumn) error {
+	var out bytes.Buffer
+	out.WriteString("PAR1")
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(cols))
+	for i, c := range cols {
+		data := c.plain()
+		var hdr thriftWriter
+		hdr.i32(1, 0) // DATA_PAGE
+		hdr.i32(2, int32(len(data)))
+		hdr.i32(3, int32(len(data)))
+		hdr.beginStruct(5)
+		hdr.i32(1, int32(numRows))
+		hdr.i32(2, 0) // PLAIN
+		hdr.i32(3, 3) // RLE, unused: no levels on required columns
+		hdr.i32(4, 3)
+		hdr.endStruct()
+		hdr.stop()
+
+		chunks[i] = chunk{offset: int64(out.Len()), size: int64(hdr.buf.Len() + len(data))}
+		out.Write(hdr.buf.Bytes())
+		out.Write(data)
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.beginList(2, thriftStruct, len(cols)+1)
+	meta.listStruct(func(t *thriftWriter) {
+		t.binary(4, "schema")
+		t.i32(5, int32(len(cols)))
+	})
+	for _, c := range cols {
+		meta.listStruct(func(t *thriftWriter) {
+			t.i32(1, c.typ)
+			t.i32(3, 0) // REQUIRED
+			t.binary(4, c.name)
+			if c.conv >= 0 {
+				t.i32(6, c.conv)
+			}
+		})
+	}
+	meta.i64(3, int64(numRows))
+	meta.beginList(4, thriftStruct, 1)
+	meta.listStruct(func(t *thriftWriter) {
+		t.beginList(1, thriftStruct, len(cols))
+		var total int64
+		for i, c := range cols {
+			total += chunks[i].size
+			t.listStruct(func(t *thriftWriter) {
+				t.i64(2, chunks[i].offset)
+				t.beginStruct(3)
+				t.i32(1, c.typ)
+				t.beginList(2, thriftI32, 1)
+				t.buf.Write(zigzag32(0)) // PLAIN
+				t.beginList(3, thriftBinary, 1)
+				t.listBinary(c.name)
+				t.i32(4, 0) // UNCOMPRESSED
+				t.i64(5, int64(numRows))
+				t.i64(6, chunks[i].size)
+				t.i64(7, chunks[i].size)
+				t.i64(9, chunks[i].offset)
+				t.endStruct()
+			})
+		}
+		t.i64(2, total)
+		t.i64(3, int64(numRows))
+	})
+	meta.binary(6, "gpufindr")
+	meta.stop()
+
+	out.Write(meta.buf.Bytes())
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(meta.buf.Len()))
+	out.Write(n[:])
+	out.WriteString("PAR1")
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// thriftWriter emits the Thrift compact protocol, just enough for Parquet metadata.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // last field id, one per open struct
+	cur  int16
+}
+
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+func zigzag32(v int32) []byte {
+	return binary.AppendUvarint(nil, uint64(uint32((v<<1)^(v>>31))))
+}
+
+func zigzag64(v int64) []byte {
+	return binary.AppendUvarint(nil, uint64((v<<1)^(v>>63)))
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if d := id - t.cur; d > 0 && d <= 15 {
+		t.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.buf.Write(binary.AppendUvarint(nil, uint64(uint16((id<<1)^(id>>15)))))
+	}
+	t.cur = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.buf.Write(zigzag32(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf.Write(zigzag64(v))
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.listBinary(s)
+}
+
+func (t *thriftWriter) listBinary(s string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) beginList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elem)
+	t.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.last = append(t.last, t.cur)
+	t.cur = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.cur = t.last[len(t.last)-1]
+	t.last = t.last[:len(t.last)-1]
+}
+
+// listStruct writes one struct element of a list.
+func (t *thriftWriter) listStruct(fields func(*thriftWriter)) {
+	t.last = append(t.last, t.cur)
+	t.cur = 0
+	fields(t)
+	t.endStruct()
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
@@ -0,0 +1,304 @@
+// internal/api/query.go
+package api
+
+import (
+	"cmp"
+	"errors"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type columnKind int
+
+const (
+	numericColumn columnKind = iota
+	stringColumn
+	timeColumn
+)
+
+// column is a sortable/filterable GPU field, keyed by its JSON name.
+type column struct {
+	name string
+	kind columnKind
+	num  func(GPU) float64
+	str  func(GPU) string
+	at   func(GPU) time.Time
+}
+
+func (c column) compare(a, b GPU) int {
+	switch c.kind {
+	case stringColumn:
+		return cmp.Compare(strings.ToLower(c.str(a)), strings.ToLower(c.str(b)))
+	case timeColumn:
+		return c.at(a).Compare(c.at(b))
+	default:
+		return cmp.Compare(c.num(a), c.num(b))
+	}
+}
+
+func numCol(name string, f func(GPU) float64) column {
+	return column{name: name, kind: numericColumn, num: f}
+}
+
+func strCol(name string, f func(GPU) string) column {
+	return column{name: name, kind: stringColumn, str: f}
+}
+
+// gpuColumns lists every column of the GPU schema.
+var gpuColumns = func() map[string]column {
+	cols := map[string]column{}
+	for _, c := range []column{
+		strCol("id", func(g GPU) string { return g.Id }),
+		strCol("location", func(g GPU) string { return g.Location }),
+		numCol("reliability", func(g GPU) float64 { return g.Reliability }),
+		numCol("duration_hours", func(g GPU) float64 { return g.Duration }),
+		strCol("source", func(g GPU) string { return g.Source }),
+		numCol("score", func(g GPU) float64 { return g.Score }),
+		numCol("score_dollar_ph", func(g GPU) float64 { return g.ScoreDPH }),
+		strCol("url", func(g GPU) string { return g.Url }),
+		strCol("name", func(g GPU) string { return g.Name }),
+		numCol("vram_mb", func(g GPU) float64 { return float64(g.Vram) }),
+		numCol("total_flops", func(g GPU) float64 { return g.TotalFlops }),
+		numCol("gpu_mem_bw_gbps", func(g GPU) float64 { return g.GpuMemoryBandwith }),
+		numCol("num_gpus", func(g GPU) float64 { return float64(g.NumGPUs) }),
+		numCol("cpu_cores", func(g GPU) float64 { return g.CpuCores }),
+		strCol("cpu_name", func(g GPU) string { return g.CpuName }),
+		numCol("cpu_ghz", func(g GPU) float64 { return g.CpuGhz }),
+		strCol("cpu_arch", func(g GPU) string { return g.CpuArch }),
+		numCol("ram_mb", func(g GPU) float64 { return float64(g.Ram) }),
+		numCol("disk_space_gb", func(g GPU) float64 { return g.DiskSpace }),
+		numCol("disk_bw_gbps", func(g GPU) float64 { return g.DiskBW }),
+		strCol("disk_name", func(g GPU) string { return g.DiskName }),
+		numCol("upload_mbps", func(g GPU) float64 { return g.UploadSpeed }),
+		numCol("download_mbps", func(g GPU) float64 { return g.DownloadSpeed }),
+		numCol("total_cost_ph", func(g GPU) float64 { return g.TotalCostPH }),
+		numCol("gpu_cost_ph", func(g GPU) float64 { return g.GpuCostPH }),
+		numCol("disk_cost_ph", func(g GPU) float64 { return g.DiskCostPH }),
+		numCol("upload_cost_ph", func(g GPU) float64 { return g.UploadCostPH }),
+		numCol("download_cost_ph", func(g GPU) float64 { return g.DownloadCostPH }),
+		numCol("flops_per_dollar_ph", func(g GPU) float64 { return g.FlopsPerDollarPH }),
+		{name: "updated_at", kind: timeColumn, at: func(g GPU) time.Time { return g.UpdatedAt }},
+	} {
+		cols[c.name] = c
+	}
+	return cols
+}()
+
+// Query is a parsed /gpus request, evaluated against a snapshot.
+type Query struct {
+	Sources []string // any of, lower-cased
+	Fit     *MemoryEstimate
+	filters []predicate
+
+	Sort   column
+	Desc   bool
+	Limit  int
+	Offset int
+}
+
+// where adds a filter every returned offer has to pass.
+func (q *Query) where(p predicate) {
+	q.filters = append(q.filters, p)
+}
+
+// rangeAliases keeps the original /gpus parameters working on top of min_/max_<column>.
+var rangeAliases = map[string]string{
+	"max_price":  "max_total_cost_ph",
+	"min_flopsd": "min_flops_per_dollar_ph",
+}
+
+// isGPUQueryParam accepts the /gpus parameters; min_/max_<column> are checked against the schema
+// by parseGPUQuery. cols is the search page's column picker, which rides along on its requests.
+var isGPUQueryParam = func() func(string) bool {
+	fixed := paramSet(append([]string{
+		"source", "name", "location", "cpu_arch", "filter", "sort", "limit", "offset", "cursor", "cols",
+		"max_price", "min_flopsd",
+	}, memoryFitParams...)...)
+	return func(key string) bool {
+		return fixed(key) || strings.HasPrefix(key, "min_") || strings.HasPrefix(key, "max_")
+	}
+}()
+
+// parseGPUQuery turns /gpus query params into a Query. Every parameter narrows the result:
+//
+//	source=vast,runpod      any of the providers
+//	name=h100,a100          canonical model or alias, see nameMatcher
+//	location=us,canada      case-insensitive substring, any of
+//	cpu_arch=x86_64         any of, amd64/x86_64 and arm64/aarch64 are folded
+//	min_<col>, max_<col>    inclusive bounds on any numeric column (min_vram_mb, max_num_gpus, ...)
+//	filter=<expr>           boolean expression, see parseFilter
+func parseGPUQuery(q url.Values) (Query, error) {
+	if err := checkParams(q, isGPUQueryParam); err != nil {
+		return Query{}, err
+	}
+	out := Query{}
+	for _, s := range splitList(q.Get("source")) {
+		// A source named twice would scan its bucket twice in candidates.
+		if s = strings.ToLower(s); !slices.Contains(out.Sources, s) {
+			out.Sources = append(out.Sources, s)
+		}
+	}
+	if names := splitList(q.Get("name")); len(names) > 0 {
+		out.where(anyOf(names, func(term string) predicate { return nameMatcher(term) }))
+	}
+	if locs := splitList(q.Get("location")); len(locs) > 0 {
+		out.where(anyOf(locs, func(loc string) predicate {
+			loc = strings.ToLower(loc)
+			return func(g GPU) bool { return strings.Contains(strings.ToLower(g.Location), loc) }
+		}))
+	}
+	if archs := splitList(q.Get("cpu_arch")); len(archs) > 0 {
+		p, _ := condition("cpu_arch", ":", strings.Join(archs, ","))
+		out.where(p)
+	}
+
+	for key := range q {
+		param := key
+		if alias, ok := rangeAliases[key]; ok {
+			param = alias
+		}
+		bound, name, ok := strings.Cut(param, "_")
+		if !ok || (bound != "min" && bound != "max") {
+			continue
+		}
+		col, ok := gpuColumns[name]
+		if !ok || col.kind != numericColumn {
+			return Query{}, badParam(key, "no numeric column %q", name)
+		}
+		f, err := strconv.ParseFloat(q.Get(key), 64)
+		if err != nil {
+			return Query{}, badParam(key, "must be a number")
+		}
+		if bound == "min" {
+			out.where(func(g GPU) bool { return col.num(g) >= f })
+		} else {
+			out.where(func(g GPU) bool { return col.num(g) <= f })
+		}
+	}
+
+	if expr := q.Get("filter"); expr != "" {
+		p, err := parseFilter(expr)
+		if err != nil {
+			return Query{}, badParam("filter", "%v", err)
+		}
+		out.where(p)
+	}
+
+	fit, err := parseMemoryFit(q)
+	if err != nil {
+		return Query{}, err
+	}
+	if fit != nil {
+		est, err := estimateMemory(*fit)
+		if err != nil {
+			var pe *paramError
+			if errors.As(err, &pe) {
+				pe.param = "fit_" + pe.param
+			}
+			return Query{}, err
+		}
+		out.Fit = &est
+	}
+
+	sortSpec := q.Get("sort")
+	if sortSpec == "" {
+		sortSpec = "updated_at.desc"
+		if out.Fit != nil {
+			sortSpec = "total_cost_ph.asc"
+		}
+	}
+	if out.Sort, out.Desc, err = parseSort(sortSpec); err != nil {
+		return Query{}, err
+	}
+	if out.Limit, out.Offset, err = parsePage(q, 200, maxLimit); err != nil {
+		return Query{}, err
+	}
+	return out, nil
+}
+
+// parseSort reads PostgREST-style "column.direction".
+func parseSort(spec string) (column, bool, error) {
+	name, dir, _ := strings.Cut(spec, ".")
+	col, ok := gpuColumns[name]
+	if !ok {
+		return column{}, false, badParam("sort", "unknown column %q", name)
+	}
+	switch dir {
+	case "", "asc":
+		return col, false, nil
+	case "desc":
+		return col, true, nil
+	}
+	return column{}, false, badParam("sort", "direction must be asc or desc")
+}
+
+func (q Query) match(g GPU) bool {
+	if len(q.Sources) > 0 && !slices.Contains(q.Sources, strings.ToLower(g.Source)) {
+		return false
+	}
+	for _, p := range q.filters {
+		if !p(g) {
+			return false
+		}
+	}
+	if q.Fit != nil && !q.Fit.fits(g) {
+		return false
+	}
+	return true
+}
+
+// run evaluates q and returns the requested page plus the number of matching rows. Rows that
+// tie on the sort column are ordered by id, so pages of one snapshot never overlap.
+func (s *snapshot) run(q Query) ([]GPU, int) {
+	var hits []GPU
+
+	// Narrow with an equality index when one applies, otherwise walk the sorted index.
+	if cand, ok := s.candidates(q); ok {
+		for _, i := range cand {
+			if q.match(s.gpus[i]) {
+				hits = append(hits, s.gpus[i])
+			}
+		}
+		sort.Slice(hits, func(a, b int) bool {
+			c := q.Sort.compare(hits[a], hits[b])
+			if c == 0 {
+				c = cmp.Compare(hits[a].Id, hits[b].Id)
+			}
+			if q.Desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	} else {
+		order := s.sortedBy(q.Sort)
+		for k := range order {
+			i := order[k]
+			if q.Desc {
+				i = order[len(order)-1-k]
+			}
+			if q.match(s.gpus[i]) {
+				hits = append(hits, s.gpus[i])
+			}
+		}
+	}
+
+	start := min(q.Offset, len(hits))
+	end := min(q.Offset+q.Limit, len(hits))
+	return hits[start:end], len(hits)
+}
+
+// candidates returns the rows in the source buckets q is limited to.
+func (s *snapshot) candidates(q Query) ([]int, bool) {
+	if len(q.Sources) == 0 {
+		return nil, false
+	}
+	var out []int
+	for _, src := range q.Sources {
+		out = append(out, s.bySource[src]...)
+	}
+	return out, true
+}
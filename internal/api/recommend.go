@@ -0,0 +1,134 @@
+// internal/api/recommend.go
+package api
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// Recommendation priorities.
+const (
+	priorityValue    = "value"    // most FP32 TFLOPs per dollar-hour
+	priorityCheapest = "cheapest" // lowest total_cost_ph
+	priorityFastest  = "fastest"  // most total FP32
+)
+
+var recommendPriorities = []string{priorityValue, priorityCheapest, priorityFastest}
+
+// RecommendRequest is what recommend_gpu is asked. The filters are a /gpus Query; Workload,
+// when set, also has to fit in the offer's VRAM.
+type RecommendRequest struct {
+	Query    Query
+	Workload *MemoryEstimate
+	MinVRAM  float64 // GB across the offer's GPUs
+	Priority string
+	Hours    float64 // optional: price the run
+	Limit    int
+}
+
+// Recommendation ranks the offers matching a RecommendRequest.
+type Recommendation struct {
+	Priority string          `json:"priority"`
+	Workload *MemoryEstimate `json:"workload,omitempty"`
+	// Considered is how many offers matched before one listing per offer key was kept.
+	Considered int                `json:"considered"`
+	Picks      []RecommendedOffer `json:"picks"`
+}
+
+// RecommendedOffer is one ranked pick and why.
+type RecommendedOffer struct {
+	Rank      int      `json:"rank"`
+	Offer     GPU      `json:"offer"`
+	Rationale []string `json:"rationale"`
+	// CostUSD prices the requested hours; absent when none were given.
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// recommend filters s by r, keeps the cheapest listing of each offer key, ranks what is left by
+// r.Priority and explains every pick against the rest of the market.
+func recommend(s *snapshot, r RecommendRequest) (Recommendation, error) {
+	if !slices.Contains(recommendPriorities, r.Priority) {
+		return Recommendation{}, badParam("priority", "must be one of %s", strings.Join(recommendPriorities, ", "))
+	}
+	q := r.Query
+	q.Fit = r.Workload
+	if r.MinVRAM > 0 {
+		q.where(func(g GPU) bool { return float64(g.Vram*max(g.NumGPUs, 1)) >= r.MinVRAM*1024 })
+	}
+	q.Sort, q.Desc = gpuColumns["total_cost_ph"], false
+	q.Offset, q.Limit = 0, len(s.gpus)
+	rows, matched := s.run(q)
+
+	// Listings of the same machine in the same place are interchangeable: keep the cheapest.
+	seen := map[string]bool{}
+	var candidates []GPU
+	for _, g := range rows {
+		if k := offerKey(g); !seen[k] && g.TotalCostPH > 0 {
+			seen[k] = true
+			candidates = append(candidates, g)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b GPU) int {
+		switch r.Priority {
+		case priorityFastest:
+			return cmp.Or(cmp.Compare(b.TotalFlops, a.TotalFlops), cmp.Compare(a.TotalCostPH, b.TotalCostPH))
+		case priorityValue:
+			return cmp.Or(cmp.Compare(tflopsPerDollar(b), tflopsPerDollar(a)), cmp.Compare(a.TotalCostPH, b.TotalCostPH))
+		}
+		return 0 // already cheapest first
+	})
+
+	out := Recommendation{Priority: r.Priority, Workload: r.Workload, Considered: matched, Picks: []RecommendedOffer{}}
+	medians := map[string]float64{}
+	for _, m := range s.stats().Models {
+		medians[m.Key] = m.MedianCostPH
+	}
+	for i, g := range candidates[:min(len(candidates), r.Limit)] {
+		pick := RecommendedOffer{Rank: i + 1, Offer: g, Rationale: []string{rankReason(r.Priority, i, g)}}
+		if r.Workload != nil {
+			headroom := float64(g.Vram*max(g.NumGPUs, 1)) - r.Workload.TotalMB
+			pick.Rationale = append(pick.Rationale, fmt.Sprintf("holds the %.1f GB the workload needs with %.1f GB to spare",
+				r.Workload.TotalMB/1024, headroom/1024))
+		}
+		if m := gpumodel.Canonical(g.Name); m != "" && medians[m] > 0 {
+			d := 100 * (g.TotalCostPH - medians[m]) / medians[m]
+			switch {
+			case d <= -1:
+				pick.Rationale = append(pick.Rationale, fmt.Sprintf("%.0f%% below the %s median of $%.2f/h", -d, m, medians[m]))
+			case d >= 1:
+				pick.Rationale = append(pick.Rationale, fmt.Sprintf("%.0f%% above the %s median of $%.2f/h", d, m, medians[m]))
+			default:
+				pick.Rationale = append(pick.Rationale, fmt.Sprintf("at the %s median of $%.2f/h", m, medians[m]))
+			}
+		}
+		if g.Reliability > 0 {
+			pick.Rationale = append(pick.Rationale, fmt.Sprintf("reliability %.2f", g.Reliability))
+		}
+		if r.Hours > 0 {
+			pick.CostUSD = g.TotalCostPH * r.Hours
+			pick.Rationale = append(pick.Rationale, fmt.Sprintf("$%.2f for %g hours", pick.CostUSD, r.Hours))
+		}
+		out.Picks = append(out.Picks, pick)
+	}
+	return out, nil
+}
+
+func rankReason(priority string, i int, g GPU) string {
+	place := "best"
+	if i > 0 {
+		place = fmt.Sprintf("#%d", i+1)
+	}
+	switch priority {
+	case priorityFastest:
+		return fmt.Sprintf("%s for throughput: %.1f FP32 TFLOPs at $%.2f/h", place, g.TotalFlops/1e12, g.TotalCostPH)
+	case priorityValue:
+		return fmt.Sprintf("%s for value: %.1f TFLOPs per $/h at $%.2f/h", place, tflopsPerDollar(g), g.TotalCostPH)
+	}
+	return fmt.Sprintf("%s on price: $%.2f/h for %d GPU(s)", place, g.TotalCostPH, max(g.NumGPUs, 1))
+}
+
+func tflopsPerDollar(g GPU) float64 { return ratio(g.TotalFlops/1e12, g.TotalCostPH) }
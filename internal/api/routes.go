@@ -0,0 +1,72 @@
+// internal/api/routes.go
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+)
+
+func sseMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Routes mounts the REST API on r.
+func Routes(r chi.Router) {
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"Content-Length", "Link", "X-Total-Count", "X-Next-Cursor", "X-Snapshot-Version", "X-Required-Vram-Mb", "Content-Disposition"},
+		AllowCredentials: false,
+		MaxAge:           300,
+	}))
+
+	log.Println("Setting up Health handler...")
+
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	log.Println("Setting up gpus handler...")
+
+	r.Get("/gpus", getHandler)
+	r.Get("/gpus/count", countHandler)
+	r.Get("/gpus/export", exportHandler)
+	r.Get("/gpus/featured", featuredHandler)
+	r.With(sseMiddleware).Get("/gpus/stream", streamHandler)
+	r.Get("/gpus/stream/ws", streamWSHandler)
+	r.Get("/gpus/{id}", gpuHandler)
+	r.Get("/gpus/{id}/history", offerHistoryHandler)
+	r.Get("/models/{model}/history", modelHistoryHandler)
+	r.Get("/stats", statsHandler)
+	r.Get("/scans", scansHandler)
+	r.Get("/scans/{id}/diff", scanDiffHandler)
+	r.Get("/compare", compareHandler)
+	r.Get("/compare/models", compareModelsHandler)
+	r.Get("/blog", blogHandler)
+	r.Get("/feeds/blog.{format}", blogFeedHandler)
+	r.Get("/feeds/deals.{format}", dealsFeedHandler)
+	r.Get("/feeds/models/{model}/deals.{format}", modelDealsFeedHandler)
+	r.Get("/feeds/providers/{source}/deals.{format}", providerDealsFeedHandler)
+	r.Post("/searches", createSearchHandler)
+	r.Get("/searches/{id}", getSearchHandler)
+	r.Delete("/searches/{id}", deleteSearchHandler)
+	r.Get("/searches/{id}/deliveries", deliveriesHandler)
+	r.Post("/searches/{id}/test", testSearchHandler)
+	r.Get("/unsubscribe", unsubscribeHandler)
+	r.Post("/unsubscribe", unsubscribeConfirmHandler)
+	r.Get("/confirm", confirmHandler)
+	r.Post("/confirm", confirmPostHandler)
+	r.Post("/catalogue/refresh", refreshHandler)
+	r.Get("/admin/mcp/usage", mcpUsageHandler)
+	r.Get("/admin/mcp/metrics", mcpMetricsHandler)
+}
@@ -0,0 +1,161 @@
+// internal/api/scans.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// ScanSummary is one scanner run and how many changes of each type it found.
+// swagger:model ScanSummary
+type ScanSummary struct {
+	ID        string    `json:"scan_id"`
+	ScannedAt time.Time `json:"scanned_at"`
+	// PreviousScannedAt is the scan the diff is against; absent for the first one recorded.
+	PreviousScannedAt *time.Time `json:"previous_scanned_at,omitempty"`
+	Offers            int        `json:"offers"`
+	Added             int        `json:"added"`
+	Removed           int        `json:"removed"`
+	PriceUp           int        `json:"price_up"`
+	PriceDown         int        `json:"price_down"`
+	Availability      int        `json:"availability"`
+}
+
+// ScanEvent is one row of a scan's diff.
+// swagger:model ScanEvent
+type ScanEvent struct {
+	ScanID string `json:"scan_id"`
+	scandiff.Event
+	ScannedAt time.Time `json:"scanned_at"`
+	Model     string    `json:"model"`
+	// NewModel marks an added offer of a model no listing had in the previous scan.
+	NewModel bool `json:"new_model"`
+	// OfferID is the key's cheapest listing in this scan, or in the previous one for removed.
+	OfferID string `json:"offer_id"`
+}
+
+// ScanDiff is the response schema for /scans/{id}/diff
+// swagger:model ScanDiff
+type ScanDiff struct {
+	Scan   ScanSummary `json:"scan"`
+	Events []ScanEvent `json:"events"`
+}
+
+var scanEventTypes = []string{scandiff.Added, scandiff.Removed, scandiff.PriceUp, scandiff.PriceDown, scandiff.Availability}
+
+// scansHandler godoc
+// @Summary     Recent scans
+// @Description Scanner runs, newest first, with the number of changes of each type against the run before.
+// @Tags        scans
+// @Produce     json
+// @Param       limit  query  int  false  "Max scans"  default(24) minimum(1) maximum(500)
+// @Success     200    {array}  ScanSummary
+// @Failure     400    {object} ErrorResponse  "Bad request"
+// @Failure     502    {object} ErrorResponse  "Upstream error"
+// @Router      /scans [get]
+func scansHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, paramSet("limit")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, _, err := parsePage(q, 24, 500)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	scans, err := catalogue.store.LoadScans(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(append([]ScanSummary{}, scans...))
+}
+
+// scanDiffHandler godoc
+// @Summary     What changed in a scan
+// @Description Offers added, removed, price_up / price_down (delta in $/h and percent) and availability (listing
+// @Description count) changes of a scan against the previous one. Offers are matched on source|name|num_gpus|location
+// @Description since ids change every scan; prices are the cheapest listing of that key.
+// @Tags        scans
+// @Produce     json
+// @Param       id      path   string  true   "Scan id, or latest"
+// @Param       type    query  string  false  "Comma-separated: added, removed, price_up, price_down, availability"
+// @Param       source  query  string  false  "Comma-separated sources"
+// @Param       model   query  string  false  "GPU model, e.g. h100"
+// @Success     200     {object} ScanDiff
+// @Failure     400     {object} ErrorResponse  "Bad request"
+// @Failure     404     {object} ErrorResponse  "Unknown scan"
+// @Failure     502     {object} ErrorResponse  "Upstream error"
+// @Router      /scans/{id}/diff [get]
+func scanDiffHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, paramSet("type", "source", "model")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	types := splitList(strings.ToLower(q.Get("type")))
+	for _, t := range types {
+		if !slices.Contains(scanEventTypes, t) {
+			writeError(w, http.StatusBadRequest, badParam("type", "unknown event type %q", t))
+			return
+		}
+	}
+	var model string
+	if m := q.Get("model"); m != "" {
+		found, ok := gpumodel.Lookup(m)
+		if !ok {
+			writeError(w, http.StatusBadRequest, badParam("model", "unknown GPU model %q", m))
+			return
+		}
+		model = found.Name
+	}
+	sources := splitList(strings.ToLower(q.Get("source")))
+
+	id := chi.URLParam(r, "id")
+	if id == "latest" {
+		scans, err := catalogue.store.LoadScans(r.Context(), 1)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		if len(scans) == 0 {
+			writeError(w, http.StatusNotFound, errors.New("no scans recorded yet"))
+			return
+		}
+		id = scans[0].ID
+	} else if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no scan %q", id))
+		return
+	}
+	scan, events, err := catalogue.store.LoadScanDiff(r.Context(), id)
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no scan %q", id))
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	out := ScanDiff{Scan: scan, Events: []ScanEvent{}}
+	for _, e := range events {
+		if (len(types) == 0 || slices.Contains(types, e.Type)) &&
+			(len(sources) == 0 || slices.Contains(sources, strings.ToLower(e.Source))) &&
+			(model == "" || e.Model == model) {
+			out.Events = append(out.Events, e)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,191 @@
+// internal/api/stats.go
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/shaymanor/gpuscanner/internal/gpumodel"
+)
+
+// MarketStats is the response schema for /stats
+// swagger:model MarketStats
+type MarketStats struct {
+	Version   string    `json:"version"`
+	ScannedAt time.Time `json:"scanned_at"`
+	// PreviousScannedAt is the scan the deltas are against; absent until the API has seen two scans.
+	PreviousScannedAt *time.Time `json:"previous_scanned_at,omitempty"`
+
+	Total   GroupStats   `json:"total"`
+	Models  []GroupStats `json:"models"`
+	Sources []GroupStats `json:"sources"`
+	Regions []GroupStats `json:"regions"`
+}
+
+// GroupStats aggregates the offers of one model, source or region. Prices are total_cost_ph.
+type GroupStats struct {
+	Key          string  `json:"key"`
+	Offers       int     `json:"offers"`
+	GPUs         int     `json:"gpus"`
+	MinCostPH    float64 `json:"min_cost_ph"`
+	MedianCostPH float64 `json:"median_cost_ph"`
+	MaxCostPH    float64 `json:"max_cost_ph"`
+
+	BestFlopsPerDollarPH float64 `json:"best_flops_per_dollar_ph"`
+	BestFlopsPerDollarID string  `json:"best_flops_per_dollar_id"`
+	BestScorePerDollarPH float64 `json:"best_score_dollar_ph"`
+	BestScorePerDollarID string  `json:"best_score_dollar_id"`
+
+	// Delta is the change since the previous scan; absent when the group is new or there is none.
+	Delta *StatsDelta `json:"delta,omitempty"`
+}
+
+// StatsDelta is current minus previous.
+type StatsDelta struct {
+	Offers       int     `json:"offers"`
+	GPUs         int     `json:"gpus"`
+	MinCostPH    float64 `json:"min_cost_ph"`
+	MedianCostPH float64 `json:"median_cost_ph"`
+}
+
+// otherModel groups offers whose GPU is not in the model catalogue.
+const otherModel = "Other"
+
+// stats returns the aggregates of s, computed once per snapshot.
+func (s *snapshot) stats() *MarketStats {
+	s.statsOnce.Do(func() {
+		st := &MarketStats{Version: s.version, ScannedAt: s.scannedAt}
+		st.Total = groupStats("all", s.gpus)
+		st.Models = groupBy(s.gpus, func(g GPU) string {
+			if m := gpumodel.Canonical(g.Name); m != "" {
+				return m
+			}
+			return otherModel
+		})
+		st.Sources = groupBy(s.gpus, func(g GPU) string { return g.Source })
+		st.Regions = groupBy(s.gpus, func(g GPU) string { return g.Location })
+		s.statsCache = st
+	})
+	return s.statsCache
+}
+
+func groupBy(gpus []GPU, key func(GPU) string) []GroupStats {
+	groups := map[string][]GPU{}
+	for _, g := range gpus {
+		k := key(g)
+		groups[k] = append(groups[k], g)
+	}
+	out := make([]GroupStats, 0, len(groups))
+	for k, list := range groups {
+		out = append(out, groupStats(k, list))
+	}
+	// Busiest first.
+	slices.SortFunc(out, func(a, b GroupStats) int {
+		if c := cmp.Compare(b.Offers, a.Offers); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return out
+}
+
+func groupStats(key string, gpus []GPU) GroupStats {
+	st := GroupStats{Key: key, Offers: len(gpus)}
+	var prices []float64
+	for _, g := range gpus {
+		st.GPUs += max(g.NumGPUs, 1)
+		if g.TotalCostPH > 0 {
+			prices = append(prices, g.TotalCostPH)
+		}
+		if g.FlopsPerDollarPH > st.BestFlopsPerDollarPH {
+			st.BestFlopsPerDollarPH, st.BestFlopsPerDollarID = g.FlopsPerDollarPH, g.Id
+		}
+		if g.ScoreDPH > st.BestScorePerDollarPH {
+			st.BestScorePerDollarPH, st.BestScorePerDollarID = g.ScoreDPH, g.Id
+		}
+	}
+	sort.Float64s(prices)
+	st.MinCostPH = quantile(prices, 0)
+	st.MedianCostPH = quantile(prices, 0.5)
+	st.MaxCostPH = quantile(prices, 1)
+	return st
+}
+
+// withDeltas copies cur and fills in the change since prev.
+func withDeltas(cur, prev *MarketStats) MarketStats {
+	out := *cur
+	if prev == nil {
+		return out
+	}
+	at := prev.ScannedAt
+	out.PreviousScannedAt = &at
+	out.Total = delta(cur.Total, prev.Total)
+	out.Models = deltas(cur.Models, prev.Models)
+	out.Sources = deltas(cur.Sources, prev.Sources)
+	out.Regions = deltas(cur.Regions, prev.Regions)
+	return out
+}
+
+func deltas(cur, prev []GroupStats) []GroupStats {
+	before := make(map[string]GroupStats, len(prev))
+	for _, g := range prev {
+		before[g.Key] = g
+	}
+	out := make([]GroupStats, len(cur))
+	for i, g := range cur {
+		out[i] = g
+		if p, ok := before[g.Key]; ok {
+			out[i] = delta(g, p)
+		}
+	}
+	return out
+}
+
+func delta(cur, prev GroupStats) GroupStats {
+	cur.Delta = &StatsDelta{
+		Offers:       cur.Offers - prev.Offers,
+		GPUs:         cur.GPUs - prev.GPUs,
+		MinCostPH:    cur.MinCostPH - prev.MinCostPH,
+		MedianCostPH: cur.MedianCostPH - prev.MedianCostPH,
+	}
+	return cur
+}
+
+// statsHandler godoc
+// @Summary     Market statistics
+// @Description Offer counts, GPUs available, min/median/max total_cost_ph and the best flops-per-dollar and
+// @Description score-per-dollar offers, per canonical GPU model, per source and per region, with the change
+// @Description since the previous scan. Computed once per scan.
+// @Tags        gpus
+// @Produce     json
+// @Success     200  {object} MarketStats
+// @Failure     400  {object} ErrorResponse  "Bad request"
+// @Failure     502  {object} ErrorResponse  "Upstream error"
+// @Router      /stats [get]
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := checkParams(r.URL.Query(), paramSet()); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	snap, err := catalogue.Snapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(marketStats(snap))
+}
+
+// marketStats is the stats of s with the change since the scan before it.
+func marketStats(s *snapshot) MarketStats {
+	var prev *MarketStats
+	if p := catalogue.Before(s); p != nil {
+		prev = p.stats()
+	}
+	return withDeltas(s.stats(), prev)
+}
@@ -0,0 +1,38 @@
+// internal/api/stats_test.go
+package api
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMarketStatsDeltasAgainstScanBefore(t *testing.T) {
+	gpus := testOffers()
+	path := useCatalogue(t, gpus)
+	first, _ := catalogue.Snapshot(context.Background())
+
+	// Drop an offer each scan so every scan's total differs from the one before.
+	refresh := func(scan int) {
+		t.Helper()
+		writeOffers(t, path, rescan(gpus[:len(gpus)-scan], scan, nil))
+		if err := catalogue.Refresh(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refresh(1)
+	second, _ := catalogue.Snapshot(context.Background())
+	refresh(2)
+
+	if st := marketStats(first); st.Total.Delta != nil || st.PreviousScannedAt != nil {
+		t.Errorf("first scan has a delta: %+v", st.Total.Delta)
+	}
+	// second is no longer current; its baseline is still first, not itself.
+	st := marketStats(second)
+	if st.Total.Delta == nil || st.Total.Delta.Offers != -1 {
+		t.Errorf("second scan delta = %+v, want -1 offer", st.Total.Delta)
+	}
+	cur, _ := catalogue.Snapshot(context.Background())
+	if st := marketStats(cur); st.Total.Delta == nil || st.Total.Delta.Offers != -1 || st.Total.Offers != len(gpus)-2 {
+		t.Errorf("current scan = %d offers, delta %+v", st.Total.Offers, st.Total.Delta)
+	}
+}
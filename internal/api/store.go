@@ -0,0 +1,386 @@
+// internal/api/store.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotFound is returned by stores when a row does not exist.
+var errNotFound = errors.New("not found")
+
+// Store is where the catalogue lives. The API keeps a copy in memory and only
+// goes back to the store on refresh or cache miss.
+type Store interface {
+	// LoadGPUs returns every offer in the catalogue.
+	LoadGPUs(ctx context.Context) ([]GPU, error)
+	// GetGPU returns a single offer, or errNotFound.
+	GetGPU(ctx context.Context, id string) (GPU, error)
+	// LoadHistory summarises past observations matching f into buckets of the given size, oldest first.
+	LoadHistory(ctx context.Context, f HistoryFilter, bucket time.Duration) ([]HistoryPoint, error)
+	// LoadScans returns the latest recorded scans, newest first.
+	LoadScans(ctx context.Context, limit int) ([]ScanSummary, error)
+	// LoadScanDiff returns a scan's changes since the one before it, or errNotFound.
+	LoadScanDiff(ctx context.Context, scanID string) (ScanSummary, []ScanEvent, error)
+	// LoadDeals returns the diff events matching f, newest first.
+	LoadDeals(ctx context.Context, f DealFilter) ([]ScanEvent, error)
+}
+
+// NewStoreFromEnv picks a local JSON file when CATALOGUE_FILE is set, Supabase otherwise.
+func NewStoreFromEnv() Store {
+	if path := os.Getenv("CATALOGUE_FILE"); path != "" {
+		return fileStore{path: path}
+	}
+	anonKey = mustEnv("SUPABASE_ANON_KEY")
+	return supabaseStore{}
+}
+
+// supabaseStore reads the gpus table over PostgREST.
+type supabaseStore struct{}
+
+// supabasePage is the page size used when walking the whole table; PostgREST caps responses at 1000 rows.
+const supabasePage = 1000
+
+func (supabaseStore) LoadGPUs(ctx context.Context) ([]GPU, error) {
+	var all []GPU
+	for off := 0; ; off += supabasePage {
+		v := url.Values{}
+		v.Set("select", "*")
+		v.Set("order", "id.asc")
+		v.Set("limit", strconv.Itoa(supabasePage))
+		v.Set("offset", strconv.Itoa(off))
+		page, err := decodeGPUs(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < supabasePage {
+			return all, nil
+		}
+	}
+}
+
+func (supabaseStore) GetGPU(ctx context.Context, id string) (GPU, error) {
+	v := url.Values{}
+	v.Set("select", "*")
+	v.Set("id", "eq."+id)
+	v.Set("limit", "1")
+	list, err := decodeGPUs(ctx, v)
+	if err != nil {
+		return GPU{}, err
+	}
+	if len(list) == 0 {
+		return GPU{}, errNotFound
+	}
+	return list[0], nil
+}
+
+// LoadHistory aggregates in the database (gpu_history_buckets in sql/gpu_history.sql), so a 90-day
+// range comes back as at most maxHistoryPoints rows rather than every sighting.
+func (supabaseStore) LoadHistory(ctx context.Context, f HistoryFilter, bucket time.Duration) ([]HistoryPoint, error) {
+	v := url.Values{}
+	v.Set("p_from", f.From.UTC().Format(time.RFC3339))
+	v.Set("p_to", f.To.UTC().Format(time.RFC3339))
+	v.Set("p_bucket_seconds", strconv.FormatFloat(bucket.Seconds(), 'f', -1, 64))
+	for param, val := range map[string]string{"p_model": f.Model, "p_source": f.Source, "p_location": f.Location, "p_region": f.Region, "p_name": f.Name} {
+		if val != "" {
+			v.Set(param, val)
+		}
+	}
+	if f.NumGPUs > 0 {
+		v.Set("p_num_gpus", strconv.Itoa(f.NumGPUs))
+	}
+
+	var all []HistoryPoint
+	for off := 0; ; off += supabasePage {
+		v.Set("limit", strconv.Itoa(supabasePage))
+		v.Set("offset", strconv.Itoa(off))
+		resp, err := supabaseGet(ctx, "rpc/gpu_history_buckets", v, "")
+		if err != nil {
+			return nil, err
+		}
+		var page []HistoryPoint
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < supabasePage {
+			return all, nil
+		}
+	}
+}
+
+func (supabaseStore) LoadScans(ctx context.Context, limit int) ([]ScanSummary, error) {
+	v := url.Values{}
+	v.Set("select", "*")
+	v.Set("order", "scanned_at.desc")
+	v.Set("limit", strconv.Itoa(limit))
+	resp, err := supabaseGet(ctx, "scans", v, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var list []ScanSummary
+	return list, json.NewDecoder(resp.Body).Decode(&list)
+}
+
+func (s supabaseStore) LoadScanDiff(ctx context.Context, scanID string) (ScanSummary, []ScanEvent, error) {
+	v := url.Values{}
+	v.Set("select", "*")
+	v.Set("scan_id", "eq."+scanID)
+	resp, err := supabaseGet(ctx, "scans", v, "")
+	if err != nil {
+		return ScanSummary{}, nil, err
+	}
+	var scans []ScanSummary
+	err = json.NewDecoder(resp.Body).Decode(&scans)
+	resp.Body.Close()
+	if err != nil {
+		return ScanSummary{}, nil, err
+	}
+	if len(scans) == 0 {
+		return ScanSummary{}, nil, errNotFound
+	}
+
+	var all []ScanEvent
+	v.Set("order", "key.asc,type.asc")
+	for off := 0; ; off += supabasePage {
+		v.Set("limit", strconv.Itoa(supabasePage))
+		v.Set("offset", strconv.Itoa(off))
+		resp, err := supabaseGet(ctx, "scan_diffs", v, "")
+		if err != nil {
+			return ScanSummary{}, nil, err
+		}
+		var page []ScanEvent
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return ScanSummary{}, nil, err
+		}
+		all = append(all, page...)
+		if len(page) < supabasePage {
+			return scans[0], all, nil
+		}
+	}
+}
+
+func (supabaseStore) LoadDeals(ctx context.Context, f DealFilter) ([]ScanEvent, error) {
+	v := url.Values{}
+	v.Set("select", "*")
+	v.Set("or", fmt.Sprintf("(and(type.eq.price_down,delta_pct.lte.%g),new_model.is.true)", -f.MinDropPct))
+	v.Set("scanned_at", "gte."+f.Since.UTC().Format(time.RFC3339))
+	if f.Model != "" {
+		v.Set("model", "eq."+f.Model)
+	}
+	if f.Source != "" {
+		v.Set("source", "eq."+f.Source)
+	}
+	v.Set("order", "scanned_at.desc,delta_pct.asc")
+	v.Set("limit", strconv.Itoa(f.Limit))
+	resp, err := supabaseGet(ctx, "scan_diffs", v, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var list []ScanEvent
+	return list, json.NewDecoder(resp.Body).Decode(&list)
+}
+
+// supabaseGet runs a PostgREST GET against table. Non-2xx responses are turned into errors.
+func supabaseGet(ctx context.Context, table string, v url.Values, prefer string) (*http.Response, error) {
+	endpoint := supabaseURL + "/rest/v1/" + table + "?" + v.Encode()
+	req, _ := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	req.Header.Set("apikey", anonKey)
+	req.Header.Set("Authorization", "Bearer "+anonKey)
+	req.Header.Set("Accept", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+
+	resp, err := httpc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", resp.Status, string(b))
+	}
+	return resp, nil
+}
+
+// decodeGPUs fetches and decodes GPU rows matching v.
+func decodeGPUs(ctx context.Context, v url.Values) ([]GPU, error) {
+	resp, err := supabaseGet(ctx, "gpus", v, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list []GPU
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// NewFileStore serves the catalogue from a JSON array of offers at path, e.g. a dump of /gpus.
+func NewFileStore(path string) Store {
+	return fileStore{path: path}
+}
+
+// fileStore serves a JSON array of offers from disk, e.g. a dump of /gpus.
+// It is re-read on every load so the file can be swapped while the API runs.
+type fileStore struct {
+	path string
+}
+
+func (s fileStore) LoadGPUs(ctx context.Context) ([]GPU, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var list []GPU
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return list, nil
+}
+
+func (s fileStore) GetGPU(ctx context.Context, id string) (GPU, error) {
+	list, err := s.LoadGPUs(ctx)
+	if err != nil {
+		return GPU{}, err
+	}
+	for _, g := range list {
+		if g.Id == id {
+			return g, nil
+		}
+	}
+	return GPU{}, errNotFound
+}
+
+// LoadHistory treats the file as a single scan taken at its newest updated_at.
+func (s fileStore) LoadHistory(ctx context.Context, f HistoryFilter, bucket time.Duration) ([]HistoryPoint, error) {
+	list, err := s.LoadGPUs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var at time.Time
+	for _, g := range list {
+		if g.UpdatedAt.After(at) {
+			at = g.UpdatedAt
+		}
+	}
+	var rows []HistoryRow
+	for _, g := range list {
+		r := historyRowOf(g, at)
+		if f.match(r) {
+			rows = append(rows, r)
+		}
+	}
+	return bucketHistory(rows, bucket), nil
+}
+
+// LoadScans reports nothing: a file is a single scan with nothing to diff against.
+func (s fileStore) LoadScans(ctx context.Context, limit int) ([]ScanSummary, error) {
+	return nil, nil
+}
+
+func (s fileStore) LoadScanDiff(ctx context.Context, scanID string) (ScanSummary, []ScanEvent, error) {
+	return ScanSummary{}, nil, errNotFound
+}
+
+func (s fileStore) LoadDeals(ctx context.Context, f DealFilter) ([]ScanEvent, error) {
+	return nil, nil
+}
+
+// apiStore reads the catalogue from another gpufindr API, paging /gpus with X-Next-Cursor so every
+// page comes from the same scan. That API only serves history and deals aggregated, so, like
+// fileStore, apiStore has none.
+type apiStore struct {
+	base string
+}
+
+// NewAPIStore reads the catalogue from the gpufindr API at base, e.g. https://gpufindr.com.
+func NewAPIStore(base string) Store {
+	return apiStore{base: strings.TrimRight(base, "/")}
+}
+
+func (s apiStore) LoadGPUs(ctx context.Context) ([]GPU, error) {
+	var all []GPU
+	v := url.Values{}
+	v.Set("limit", strconv.Itoa(maxLimit))
+	for {
+		var page []GPU
+		next, err := s.get(ctx, "/gpus?"+v.Encode(), &page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if next == "" {
+			return all, nil
+		}
+		v.Set("cursor", next)
+	}
+}
+
+func (s apiStore) GetGPU(ctx context.Context, id string) (GPU, error) {
+	var g GPU
+	_, err := s.get(ctx, "/gpus/"+url.PathEscape(id), &g)
+	return g, err
+}
+
+func (s apiStore) LoadHistory(ctx context.Context, f HistoryFilter, bucket time.Duration) ([]HistoryPoint, error) {
+	return nil, nil
+}
+
+func (s apiStore) LoadScans(ctx context.Context, limit int) ([]ScanSummary, error) {
+	var list []ScanSummary
+	_, err := s.get(ctx, "/scans?limit="+strconv.Itoa(min(limit, 500)), &list)
+	return list, err
+}
+
+func (s apiStore) LoadScanDiff(ctx context.Context, scanID string) (ScanSummary, []ScanEvent, error) {
+	var d ScanDiff
+	_, err := s.get(ctx, "/scans/"+url.PathEscape(scanID)+"/diff", &d)
+	return d.Scan, d.Events, err
+}
+
+func (s apiStore) LoadDeals(ctx context.Context, f DealFilter) ([]ScanEvent, error) {
+	return nil, nil
+}
+
+// get decodes the JSON at path into v and returns the response's X-Next-Cursor. A 404 is
+// errNotFound; other non-2xx responses are turned into errors.
+func (s apiStore) get(ctx context.Context, path string, v any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.base+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errNotFound
+	}
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%s: %s", resp.Status, string(b))
+	}
+	return resp.Header.Get("X-Next-Cursor"), json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,97 @@
+// internal/api/store_test.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shaymanor/gpuscanner/internal/scandiff"
+)
+
+// upstreamAPI serves the REST API over the catalogue gpus and counts the requests to each path.
+func upstreamAPI(t *testing.T, gpus []GPU) (string, map[string]*atomic.Int32) {
+	t.Helper()
+	useCatalogue(t, gpus)
+	r := chi.NewRouter()
+	Routes(r)
+	hits := map[string]*atomic.Int32{"/gpus": {}, "/gpus/offer-03": {}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if n, ok := hits[req.URL.Path]; ok {
+			n.Add(1)
+		}
+		r.ServeHTTP(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, hits
+}
+
+func TestAPIStoreGetGPU(t *testing.T) {
+	base, hits := upstreamAPI(t, testOffers())
+	st := NewAPIStore(base + "/")
+
+	g, err := st.GetGPU(context.Background(), "offer-03")
+	if err != nil || g.Id != "offer-03" || g.Name != testOffers()[3].Name {
+		t.Fatalf("offer-03 = %+v, %v", g, err)
+	}
+	if _, err := st.GetGPU(context.Background(), "offer-missing"); !errors.Is(err, errNotFound) {
+		t.Errorf("offer-missing: %v, want errNotFound", err)
+	}
+	if hits["/gpus/offer-03"].Load() != 1 || hits["/gpus"].Load() != 0 {
+		t.Errorf("fetched /gpus/offer-03 %d times and /gpus %d times, want once and never",
+			hits["/gpus/offer-03"].Load(), hits["/gpus"].Load())
+	}
+}
+
+func TestAPIStoreLoadGPUs(t *testing.T) {
+	// More offers than one page of maxLimit, so the store has to follow X-Next-Cursor.
+	var gpus []GPU
+	for i := 0; i < 125; i++ {
+		for _, g := range testOffers() {
+			g.Id = fmt.Sprintf("%s-%03d", g.Id, i)
+			gpus = append(gpus, g)
+		}
+	}
+	base, hits := upstreamAPI(t, gpus)
+
+	got, err := NewAPIStore(base).LoadGPUs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, g := range got {
+		seen[g.Id] = true
+	}
+	if len(got) != len(gpus) || len(seen) != len(gpus) {
+		t.Errorf("loaded %d offers, %d distinct, want %d", len(got), len(seen), len(gpus))
+	}
+	if n := hits["/gpus"].Load(); n != 2 {
+		t.Errorf("fetched /gpus %d times, want 2", n)
+	}
+}
+
+func TestAPIStoreScans(t *testing.T) {
+	gpus := testOffers()
+	base, _ := upstreamAPI(t, gpus)
+	useScanStore(t, gpus, []ScanEvent{event(scandiff.PriceDown, gpus[0], 2.49)}, false)
+	st := NewAPIStore(base)
+
+	scans, err := st.LoadScans(context.Background(), 5)
+	if err != nil || len(scans) != 1 || scans[0].ID != "scan-2" {
+		t.Fatalf("scans = %+v, %v", scans, err)
+	}
+	// The upstream only takes UUIDs and latest; scanStore's scan-2 is reachable as latest.
+	scan, events, err := st.LoadScanDiff(context.Background(), "latest")
+	if err != nil || !scan.ScannedAt.Equal(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)) || len(events) != 1 || events[0].Key != offerKey(gpus[0]) {
+		t.Errorf("latest = %+v, %+v, %v", scan, events, err)
+	}
+	if _, _, err := st.LoadScanDiff(context.Background(), "0b6f3a52-9c1e-4d7a-8f21-5e4c3b2a1d09"); !errors.Is(err, errNotFound) {
+		t.Errorf("unknown scan: %v, want errNotFound", err)
+	}
+}
@@ -0,0 +1,230 @@
+// internal/api/stream.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// feedKept is how many changes the feed keeps for Last-Event-ID resume.
+const feedKept = 50000
+
+// streamHeartbeat keeps idle connections open through proxies.
+const streamHeartbeat = 25 * time.Second
+
+// ChangeFeed fans the changes of every new scan out to /gpus/stream clients.
+type ChangeFeed struct {
+	mu   sync.Mutex
+	log  []OfferChange // oldest first
+	subs map[chan []OfferChange]struct{}
+}
+
+// changes is the process-wide feed, fed by catalogue.OnScan.
+var changes = &ChangeFeed{subs: map[chan []OfferChange]struct{}{}}
+
+// onScan records the diff against the previous scan and pushes it to subscribers. A subscriber
+// that has fallen a whole buffer behind is dropped; it reconnects and resumes from its last id.
+func (f *ChangeFeed) onScan(cur, prev *snapshot) {
+	if prev == nil {
+		return
+	}
+	batch := diffSnapshots(prev, cur)
+	if len(batch) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, batch...)
+	if len(f.log) > feedKept {
+		f.log = slices.Clone(f.log[len(f.log)-feedKept:])
+	}
+	for ch := range f.subs {
+		select {
+		case ch <- batch:
+		default:
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns a channel of future batches and the changes after lastID. ok is false when
+// lastID is set but no longer in the log, and the client has to start over.
+func (f *ChangeFeed) subscribe(lastID string) (ch chan []OfferChange, backlog []OfferChange, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch = make(chan []OfferChange, 16)
+	f.subs[ch] = struct{}{}
+	if lastID == "" {
+		return ch, nil, true
+	}
+	i := slices.IndexFunc(f.log, func(c OfferChange) bool { return c.ID == lastID })
+	if i < 0 {
+		return ch, nil, false
+	}
+	return ch, slices.Clone(f.log[i+1:]), true
+}
+
+func (f *ChangeFeed) unsubscribe(ch chan []OfferChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[ch]; ok {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+// parseStreamQuery reads the /gpus filters of a stream request. Sorting and paging do not apply.
+func parseStreamQuery(q url.Values) (Query, error) {
+	if err := checkParams(q, func(k string) bool { return k == "last_event_id" || isGPUQueryParam(k) }); err != nil {
+		return Query{}, err
+	}
+	f := url.Values{}
+	for k, v := range q {
+		if k != "last_event_id" && k != "sort" && !slices.Contains(pageParams, k) {
+			f[k] = v
+		}
+	}
+	return parseGPUQuery(f)
+}
+
+// streamChanges subscribes from lastID and calls send with every change whose offer matches q
+// before or after it, so a client hears of a price rise that drops a row out of its filter,
+// and with a reset event when resuming is impossible, until send fails, done closes or the feed
+// drops the subscriber. heartbeat is called when nothing was sent for streamHeartbeat.
+func streamChanges(q Query, lastID string, done <-chan struct{}, send func(event string, c *OfferChange) error, heartbeat func() error) {
+	ch, backlog, ok := changes.subscribe(lastID)
+	defer changes.unsubscribe(ch)
+	if !ok {
+		if send("reset", nil) != nil {
+			return
+		}
+	}
+	emit := func(batch []OfferChange) error {
+		for i := range batch {
+			if c := &batch[i]; q.match(c.Offer) || (c.prev != nil && q.match(*c.prev)) {
+				if err := send(batch[i].Type, &batch[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if emit(backlog) != nil {
+		return
+	}
+	t := time.NewTicker(streamHeartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case batch, open := <-ch:
+			if !open || emit(batch) != nil {
+				return
+			}
+		case <-t.C:
+			if heartbeat() != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamHandler godoc
+// @Summary     Stream offer changes (SSE)
+// @Description Server-Sent Events pushed after each scan: added, removed, price_up, price_down and availability,
+// @Description with an OfferChange as data (see /scans/{id}/diff). Takes the /gpus filters (sort and paging are
+// @Description ignored). Reconnects resume after the Last-Event-ID header or last_event_id parameter; when that is
+// @Description too old a reset event tells the client to reload /gpus. Comments are sent as heartbeats.
+// @Tags        gpus
+// @Produce     text/event-stream
+// @Param       last_event_id  query  string  false  "Resume after this change id (EventSource sends Last-Event-ID itself)"
+// @Param       source         query  string  false  "Comma-separated sources"
+// @Param       name           query  string  false  "GPU models"
+// @Param       location       query  string  false  "Location substrings"
+// @Param       max_price      query  number  false  "Max total_cost_ph"
+// @Success     200  {object} OfferChange
+// @Failure     400  {object} ErrorResponse  "Bad request"
+// @Router      /gpus/stream [get]
+func streamHandler(w http.ResponseWriter, r *http.Request) {
+	q, err := parseStreamQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if rc.Flush() != nil {
+		return
+	}
+	send := func(event string, c *OfferChange) error {
+		if c == nil {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", event)
+		} else {
+			b, _ := json.Marshal(c)
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", c.ID, event, b)
+		}
+		return rc.Flush()
+	}
+	heartbeat := func() error {
+		fmt.Fprint(w, ": ping\n\n")
+		return rc.Flush()
+	}
+	streamChanges(q, lastID, r.Context().Done(), send, heartbeat)
+}
+
+// wsMessage is what /gpus/stream/ws sends: a change, a reset or a heartbeat.
+type wsMessage struct {
+	Event  string       `json:"event"`
+	Change *OfferChange `json:"change,omitempty"`
+}
+
+// streamWSHandler godoc
+// @Summary     Stream offer changes (WebSocket)
+// @Description The /gpus/stream feed over WebSocket. Each text message is {"event": ..., "change": OfferChange};
+// @Description events are added, removed, price_up, price_down, availability, reset and heartbeat. Resume with last_event_id.
+// @Description Messages from the client are ignored.
+// @Tags        gpus
+// @Param       last_event_id  query  string  false  "Resume after this change id"
+// @Success     101
+// @Failure     400  {object} ErrorResponse  "Bad request"
+// @Router      /gpus/stream/ws [get]
+func streamWSHandler(w http.ResponseWriter, r *http.Request) {
+	q, err := parseStreamQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	lastID := r.URL.Query().Get("last_event_id")
+	websocket.Server{
+		// Public, read-only data: accept any origin, like the CORS policy.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			done := make(chan struct{})
+			go func() {
+				_, _ = io.Copy(io.Discard, ws) // returns when the client goes away
+				close(done)
+			}()
+			send := func(event string, c *OfferChange) error {
+				return websocket.JSON.Send(ws, wsMessage{Event: event, Change: c})
+			}
+			streamChanges(q, lastID, done, send, func() error { return send("heartbeat", nil) })
+		},
+	}.ServeHTTP(w, r)
+}
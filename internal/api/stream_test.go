@@ -0,0 +1,192 @@
+// internal/api/stream_test.go
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// useFeed gives the test a change feed of its own.
+func useFeed(t *testing.T) {
+	old := changes
+	t.Cleanup(func() { changes = old })
+	changes = &ChangeFeed{subs: map[chan []OfferChange]struct{}{}}
+}
+
+// waitSubscribers waits until n clients are on the feed.
+func waitSubscribers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		changes.mu.Lock()
+		got := len(changes.subs)
+		changes.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d subscribers, want %d", got, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// feedScan pushes a scan in which, against max_price=1: the lambda A10 is removed, the runpod L4
+// rises out of the filter, the vast RTX 4090 gets cheaper and the runpod H100 rises outside it.
+func feedScan() {
+	prev := newSnapshot(rescan(testOffers(), 1, nil))
+	gpus := rescan(testOffers(), 2, func(gpus []GPU) {
+		gpus[0].TotalCostPH = 30
+		gpus[6].TotalCostPH = 0.30
+		gpus[8].TotalCostPH = 1.50
+	})
+	cur := newSnapshot(append(gpus[:4:4], gpus[5:]...))
+	changes.onScan(cur, prev)
+}
+
+var wantStream = []struct{ event, offer string }{
+	{"removed", "offer-04-1"},
+	{"price_up", "offer-08-2"},
+	{"price_down", "offer-06-2"},
+}
+
+// sseEvent is one event read off a /gpus/stream response.
+type sseEvent struct {
+	id, event string
+	change    OfferChange
+}
+
+// openSSE connects to /gpus/stream with query and lastID and returns a reader of its events.
+func openSSE(t *testing.T, query, lastID string) func() sseEvent {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(streamHandler))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(srv.Close)
+	t.Cleanup(cancel) // runs first, so the handler returns before Close waits on it
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/gpus/stream?"+query, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	sc := bufio.NewScanner(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var ev sseEvent
+		for sc.Scan() {
+			k, v, _ := strings.Cut(sc.Text(), ": ")
+			switch k {
+			case "id":
+				ev.id = v
+			case "event":
+				ev.event = v
+			case "data":
+				if err := json.Unmarshal([]byte(v), &ev.change); err != nil {
+					t.Fatalf("data %s: %v", v, err)
+				}
+			case "":
+				if ev.event != "" {
+					return ev
+				}
+			}
+		}
+		t.Fatalf("stream ended: %v", sc.Err())
+		return ev
+	}
+}
+
+func TestStreamSSE(t *testing.T) {
+	useFeed(t)
+	next := openSSE(t, "max_price=1", "")
+	waitSubscribers(t, 1)
+	feedScan()
+
+	var ids []string
+	for _, want := range wantStream {
+		ev := next()
+		if ev.event != want.event || ev.change.Type != want.event || ev.change.Offer.Id != want.offer || ev.id != ev.change.ID {
+			t.Errorf("got %s %s (id %s), want %s %s", ev.event, ev.change.Offer.Id, ev.id, want.event, want.offer)
+		}
+		ids = append(ids, ev.id)
+	}
+
+	// A reconnect after the first change gets the rest.
+	resumed := openSSE(t, "max_price=1", ids[0])
+	for _, want := range wantStream[1:] {
+		if ev := resumed(); ev.event != want.event || ev.change.Offer.Id != want.offer {
+			t.Errorf("resumed: got %s %s, want %s %s", ev.event, ev.change.Offer.Id, want.event, want.offer)
+		}
+	}
+
+	// A reconnect after a change the feed no longer holds gets a reset.
+	if ev := openSSE(t, "max_price=1", "gone.0")(); ev.event != "reset" {
+		t.Errorf("unknown Last-Event-ID: got %s, want reset", ev.event)
+	}
+}
+
+func TestStreamRejectsBadFilters(t *testing.T) {
+	w := httptest.NewRecorder()
+	streamHandler(w, httptest.NewRequest("GET", "/gpus/stream?max_price=cheap", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d", w.Code)
+	}
+}
+
+// openWS connects to /gpus/stream/ws with query.
+func openWS(t *testing.T, query string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(streamWSHandler))
+	t.Cleanup(srv.Close)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/gpus/stream/ws?"+query, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return ws
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	useFeed(t)
+	ws := openWS(t, "max_price=1")
+	waitSubscribers(t, 1)
+	feedScan()
+
+	var first string
+	for _, want := range wantStream {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Event != want.event || msg.Change == nil || msg.Change.Offer.Id != want.offer {
+			t.Errorf("got %+v, want %s %s", msg, want.event, want.offer)
+			continue
+		}
+		if first == "" {
+			first = msg.Change.ID
+		}
+	}
+
+	resumed := openWS(t, "max_price=1&last_event_id="+first)
+	var msg wsMessage
+	if err := websocket.JSON.Receive(resumed, &msg); err != nil || msg.Change == nil || msg.Change.Offer.Id != wantStream[1].offer {
+		t.Errorf("resumed: got %+v (%v), want %s", msg, err, wantStream[1].offer)
+	}
+
+	if err := websocket.JSON.Receive(openWS(t, "last_event_id=gone.0"), &msg); err != nil || msg.Event != "reset" {
+		t.Errorf("unknown last_event_id: got %+v (%v), want reset", msg, err)
+	}
+}
@@ -0,0 +1,186 @@
+// internal/api/webhook.go
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// Webhook formats. slack and discord post a chat message; json posts an AlertEvent.
+const (
+	formatJSON    = "json"
+	formatSlack   = "slack"
+	formatDiscord = "discord"
+)
+
+// Webhook is where a saved search sends its alerts.
+type Webhook struct {
+	URL    string `json:"url"`
+	Format string `json:"format"` // json, slack or discord
+}
+
+// AlertEvent is one firing of a saved search: the body of a json webhook, and one section of an email.
+// swagger:model AlertEvent
+type AlertEvent struct {
+	Event     string      `json:"event"` // alert, or test for POST /searches/{id}/test
+	Search    SavedSearch `json:"search"`
+	ScannedAt time.Time   `json:"scanned_at"`
+	// Matches is how many offers fired; Offers holds the cheapest of them.
+	Matches int    `json:"matches"`
+	Offers  []GPU  `json:"offers"`
+	URL     string `json:"url"`
+}
+
+// alertOffersShown caps the offers carried by one notification.
+const alertOffersShown = 10
+
+// webhookClient posts deliveries; receivers get longer than the catalogue's upstream timeout.
+// Webhook URLs come from anyone, so it only dials public addresses, checked after DNS resolution
+// so a name cannot be pointed at an internal one, and it does not follow redirects: a 3xx is
+// a failed delivery.
+var webhookClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		ForceAttemptHTTP2:   true,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// nonPublic are the ranges publicIP rejects on top of the net.IP classifications: "this
+// network", carrier-grade NAT and the IETF protocol block.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+}
+
+// publicIP reports whether ip is routable on the internet: not loopback, private, link-local
+// (which covers the 169.254.169.254 metadata server), multicast or unspecified.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookAllowPrivate lifts the public-address rule for local runs that post to a receiver on
+// the same machine or network (WEBHOOK_ALLOW_PRIVATE=1). Never set it on a shared deployment.
+var webhookAllowPrivate = os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "1"
+
+// dialPublicOnly is webhookClient's net.Dialer Control: it runs on the resolved address of
+// every connection, retries included.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	if webhookAllowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+// webhookBody renders ev in the search's webhook format.
+func webhookBody(ev AlertEvent) ([]byte, error) {
+	switch ev.Search.Webhook.Format {
+	case formatSlack:
+		return json.Marshal(map[string]string{"text": alertText(ev, func(text, u string) string {
+			return "<" + u + "|" + text + ">"
+		})})
+	case formatDiscord:
+		text := alertText(ev, func(text, u string) string { return "[" + text + "](" + u + ")" })
+		if utf8.RuneCountInString(text) > 2000 { // Discord's message limit, in characters
+			text = string([]rune(text)[:1997]) + "..."
+		}
+		return json.Marshal(map[string]string{"content": text})
+	}
+	return json.Marshal(ev)
+}
+
+// alertText is the chat message for ev; link formats a hyperlink in the target's markup.
+func alertText(ev AlertEvent, link func(text, u string) string) string {
+	var b strings.Builder
+	what := "new offers match"
+	if ev.Search.Trigger == triggerBelow {
+		what = fmt.Sprintf("offers under $%.2f/h", ev.Search.MaxTotalCostPH)
+	}
+	if ev.Event == "test" {
+		what = "offers match now (test)"
+	}
+	fmt.Fprintf(&b, "%s: %d %s\n", link(ev.Search.Name, ev.URL), ev.Matches, what)
+	for _, g := range ev.Offers {
+		fmt.Fprintf(&b, "• %dx %s on %s (%s) $%.2f/h", max(g.NumGPUs, 1), g.Name, g.Source, g.Location, g.TotalCostPH)
+		if g.Url != "" {
+			b.WriteString(" " + link("rent", g.Url))
+		}
+		b.WriteString("\n")
+	}
+	if more := ev.Matches - len(ev.Offers); more > 0 {
+		fmt.Fprintf(&b, "and %d more\n", more)
+	}
+	return b.String()
+}
+
+// sign returns the X-Gpufindr-Signature header value: the unix time and the hex HMAC-SHA256 of
+// "<time>.<body>". Receivers recompute it with their secret and reject stale timestamps.
+func sign(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postWebhook sends body to the search's webhook and returns the HTTP status.
+func (a *Alerts) postWebhook(ctx context.Context, s storedSearch, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", s.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gpufindr-alerts/1")
+	req.Header.Set("X-Gpufindr-Search", s.ID)
+	req.Header.Set("X-Gpufindr-Signature", sign(s.Secret, time.Now(), body))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return resp.StatusCode, &deliveryError{msg: resp.Status, retry: retry}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,243 @@
+// internal/api/webhook_test.go
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// receiver is a webhook endpoint answering with the next of statuses (the last one repeats) and
+// keeping what it was sent.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rc := &receiver{statuses: statuses}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		rc.bodies = append(rc.bodies, b)
+		rc.headers = append(rc.headers, r.Header.Clone())
+		status := rc.statuses[min(len(rc.bodies), len(rc.statuses))-1]
+		rc.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) calls() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.bodies)
+}
+
+// testAlerts is an alert engine on a memory store that may post to loopback receivers and
+// retries at once.
+func testAlerts() (*Alerts, *memoryAlerts) {
+	store := newMemoryAlerts()
+	a := newAlerts(store, nil)
+	a.client = &http.Client{Timeout: 5 * time.Second, CheckRedirect: webhookClient.CheckRedirect}
+	a.backoff = []time.Duration{time.Millisecond, time.Millisecond}
+	return a, store
+}
+
+func webhookSearch(u, format string) storedSearch {
+	return storedSearch{
+		SavedSearch: SavedSearch{ID: "search-1", Name: "cheap h100", Query: "name=h100", Trigger: triggerBelow,
+			MaxTotalCostPH: 2, Webhook: &Webhook{URL: u, Format: format}},
+		Secret: "sekret",
+	}
+}
+
+// waitDeliveries polls the delivery log of id until it holds n attempts.
+func waitDeliveries(t *testing.T, store AlertStore, id string, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list, _ := store.Deliveries(context.Background(), id, 100)
+		if len(list) >= n || time.Now().After(deadline) {
+			if len(list) != n {
+				t.Fatalf("delivery log of %s has %d attempts, want %d: %+v", id, len(list), n, list)
+			}
+			return list
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	rc := newReceiver(t, http.StatusOK)
+	a, _ := testAlerts()
+	s := webhookSearch(rc.URL, formatJSON)
+	body := []byte(`{"event":"test"}`)
+	if status, err := a.postWebhook(context.Background(), s, body); err != nil || status != http.StatusOK {
+		t.Fatalf("postWebhook = %d, %v", status, err)
+	}
+
+	h := rc.headers[0]
+	if h.Get("X-Gpufindr-Search") != s.ID || h.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v", h)
+	}
+	var ts, mac string
+	for _, part := range strings.Split(h.Get("X-Gpufindr-Signature"), ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			mac = v
+		}
+	}
+	want := hmac.New(sha256.New, []byte(s.Secret))
+	want.Write([]byte(ts + "."))
+	want.Write(rc.bodies[0])
+	if got, _ := hex.DecodeString(mac); ts == "" || !hmac.Equal(got, want.Sum(nil)) {
+		t.Errorf("signature %q does not verify", h.Get("X-Gpufindr-Signature"))
+	}
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     []string // statuses in the delivery log, oldest first
+	}{
+		{"recovers", []int{503, 429, 200}, []string{deliveryRetrying, deliveryRetrying, deliveryDelivered}},
+		{"runs out of retries", []int{500}, []string{deliveryRetrying, deliveryRetrying, deliveryFailed}},
+		{"does not retry a 4xx", []int{404}, []string{deliveryFailed}},
+		{"does not follow redirects", []int{302}, []string{deliveryFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newReceiver(t, tt.statuses...)
+			a, store := testAlerts()
+			s := webhookSearch(rc.URL, formatJSON)
+			a.deliver(context.Background(), s.ID, "alert", 3, channelWebhook, func(ctx context.Context) (int, error) {
+				return a.postWebhook(ctx, s, []byte(`{}`))
+			})
+
+			log, _ := store.Deliveries(context.Background(), s.ID, 10)
+			if len(log) != len(tt.want) || rc.calls() != len(tt.want) {
+				t.Fatalf("%d attempts logged, %d received, want %d: %+v", len(log), rc.calls(), len(tt.want), log)
+			}
+			for i, d := range log {
+				want := tt.want[len(tt.want)-1-i] // the log is newest first
+				if d.Status != want || d.Attempt != len(log)-i || d.Channel != channelWebhook || d.Offers != 3 {
+					t.Errorf("attempt %d = %+v, want status %s", d.Attempt, d, want)
+				}
+				if d.Status != deliveryDelivered && (d.Error == "" || d.HTTPStatus == 0) {
+					t.Errorf("attempt %d lacks the failure: %+v", d.Attempt, d)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyPostsChatFormats(t *testing.T) {
+	ev := AlertEvent{
+		Event:   "alert",
+		Matches: 3,
+		Offers:  []GPU{{Name: "H100 SXM5", Source: "runpod", Location: "us-east-1", NumGPUs: 8, TotalCostPH: 1.99, Url: "https://rent.example/1"}},
+		URL:     "https://gpufindr.com/search.html?name=h100",
+	}
+	for format, check := range map[string]func(map[string]string) bool{
+		formatSlack: func(m map[string]string) bool {
+			return strings.Contains(m["text"], "<https://gpufindr.com/search.html?name=h100|cheap h100>") &&
+				strings.Contains(m["text"], "<https://rent.example/1|rent>") && strings.Contains(m["text"], "and 2 more")
+		},
+		formatDiscord: func(m map[string]string) bool {
+			return strings.Contains(m["content"], "[cheap h100](https://gpufindr.com/search.html?name=h100)") &&
+				strings.Contains(m["content"], "8x H100 SXM5 on runpod (us-east-1) $1.99/h")
+		},
+	} {
+		rc := newReceiver(t, http.StatusNoContent)
+		a, store := testAlerts()
+		s := webhookSearch(rc.URL, format)
+		ev.Search = s.SavedSearch
+		a.notify(context.Background(), s, ev)
+
+		if d := waitDeliveries(t, store, s.ID, 1)[0]; d.Status != deliveryDelivered || d.HTTPStatus != http.StatusNoContent {
+			t.Errorf("%s: delivery = %+v", format, d)
+		}
+		var m map[string]string
+		if err := json.Unmarshal(rc.bodies[0], &m); err != nil || !check(m) {
+			t.Errorf("%s: body %s (%v)", format, rc.bodies[0], err)
+		}
+	}
+}
+
+func TestDiscordBodyTruncatesOnRunes(t *testing.T) {
+	ev := AlertEvent{Search: SavedSearch{Name: "ü", Webhook: &Webhook{Format: formatDiscord}}, Matches: alertOffersShown}
+	for range alertOffersShown {
+		ev.Offers = append(ev.Offers, GPU{Name: strings.Repeat("é", 300), TotalCostPH: 1})
+	}
+	b, err := webhookBody(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(m["content"]) || utf8.RuneCountInString(m["content"]) != 2000 || !strings.HasSuffix(m["content"], "...") {
+		t.Errorf("content is %d runes, valid %v", utf8.RuneCountInString(m["content"]), utf8.ValidString(m["content"]))
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	rc := newReceiver(t, http.StatusOK)
+	if _, err := webhookClient.Post(rc.URL, "application/json", strings.NewReader("{}")); err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Errorf("post to %s: %v, want a refusal", rc.URL, err)
+	}
+	if rc.calls() != 0 {
+		t.Error("the loopback receiver was reached")
+	}
+
+	for addr, want := range map[string]bool{
+		"93.184.216.34:443":     true,
+		"[2606:4700::1111]:443": true,
+		"127.0.0.1:80":          false,
+		"10.1.2.3:80":           false,
+		"192.168.0.10:8080":     false,
+		"169.254.169.254:80":    false,
+		"100.100.100.200:80":    false,
+		"0.0.0.0:80":            false,
+		"[::1]:443":             false,
+		"[fd00:ec2::254]:80":    false,
+		"[::ffff:10.0.0.1]:80":  false,
+	} {
+		if err := dialPublicOnly("tcp", addr, nil); (err == nil) != want {
+			t.Errorf("dialPublicOnly(%s) = %v, want allowed %v", addr, err, want)
+		}
+	}
+}
+
+func TestSearchRequestRejectsPrivateWebhooks(t *testing.T) {
+	for _, u := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest", "http://[::1]/", "ftp://example.com/"} {
+		req := SearchRequest{Name: "x", Trigger: triggerNew, Webhook: &Webhook{URL: u}}
+		if _, err := req.validate(false); err == nil {
+			t.Errorf("webhook %s was accepted", u)
+		}
+	}
+	req := SearchRequest{Name: "x", Trigger: triggerNew, Webhook: &Webhook{URL: "https://hooks.example.com/x"}}
+	if _, err := req.validate(false); err != nil {
+		t.Errorf("public webhook rejected: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package gpumodel folds the many ways providers spell a GPU onto canonical models.
+// It is shared by the scanner, which tags offers, and the API, which matches on them.
+package gpumodel
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Model is a canonical GPU model. Providers spell the same card many ways
+// ("NVIDIA H100 80GB HBM3", "H100_SXM5", "h100-sxm"); offers are folded onto one of these.
+type Model struct {
+	Name    string   `json:"name"`
+	Slug    string   `json:"slug"`
+	Aliases []string `json:"aliases"`
+	Spec    Spec     `json:"spec"`
+}
+
+// Spec is a model's datasheet, per GPU. FP32 and bandwidth follow the scanner's gpuSpecs so
+// they line up with the total_flops and gpu_mem_bw_gbps of offers.
+type Spec struct {
+	Architecture string  `json:"architecture"`
+	VramGB       float64 `json:"vram_gb"`
+	FP32TFLOPS   float64 `json:"fp32_tflops"`
+	MemBWGBps    float64 `json:"mem_bw_gbps"`
+}
+
+// Models maps an offer onto the model whose alias is the longest one found in the offer name on
+// word boundaries (see HasWord), so "L40S" is the L40S rather than the L40 and "RTX A1000" is
+// none of the A100 or A10. Ties go to the earlier model.
+var Models = []Model{
+	{"GB200", "gb200", []string{"gb200"}, Spec{"Blackwell", 186, 5760, 8000}},
+	{"B200", "b200", []string{"b200"}, Spec{"Blackwell", 180, 2200, 8000}},
+	{"GH200", "gh200", []string{"gh200"}, Spec{"Hopper", 96, 67, 4000}},
+	{"H200", "h200", []string{"h200"}, Spec{"Hopper", 141, 67, 4800}},
+	{"H100 SXM", "h100-sxm", []string{"h100sxm", "h100hbm3"}, Spec{"Hopper", 80, 67, 3350}},
+	{"H100 NVL", "h100-nvl", []string{"h100nvl"}, Spec{"Hopper", 94, 51, 2000}},
+	{"H100 PCIe", "h100-pcie", []string{"h100pcie", "h100"}, Spec{"Hopper", 80, 51, 2000}},
+	{"A100 SXM", "a100-sxm", []string{"a100sxm"}, Spec{"Ampere", 80, 19.5, 2039}},
+	{"A100 PCIe", "a100-pcie", []string{"a100pcie", "a100"}, Spec{"Ampere", 80, 19.5, 1935}},
+	{"MI300X", "mi300x", []string{"mi300x"}, Spec{"CDNA 3", 192, 163.4, 5300}},
+	{"MI250X", "mi250x", []string{"mi250x"}, Spec{"CDNA 2", 128, 95.7, 3200}},
+	{"MI250", "mi250", []string{"mi250"}, Spec{"CDNA 2", 128, 90.5, 3200}},
+	{"L40S", "l40s", []string{"l40s"}, Spec{"Ada Lovelace", 48, 91.6, 864}},
+	{"L40", "l40", []string{"l40"}, Spec{"Ada Lovelace", 48, 90.5, 864}},
+	{"RTX PRO 6000", "rtx-pro-6000", []string{"rtxpro6000", "pro6000"}, Spec{"Blackwell", 96, 126, 1792}},
+	{"RTX 6000 Ada", "rtx-6000-ada", []string{"rtx6000ada", "6000ada"}, Spec{"Ada Lovelace", 48, 91.1, 960}},
+	{"RTX 5000 Ada", "rtx-5000-ada", []string{"rtx5000ada", "5000ada"}, Spec{"Ada Lovelace", 32, 65.3, 640}},
+	{"RTX 4000 Ada", "rtx-4000-ada", []string{"rtx4000ada", "4000ada"}, Spec{"Ada Lovelace", 20, 26.7, 360}},
+	{"RTX 2000 Ada", "rtx-2000-ada", []string{"rtx2000ada", "2000ada"}, Spec{"Ada Lovelace", 16, 12, 288}},
+	{"RTX A6000", "rtx-a6000", []string{"a6000"}, Spec{"Ampere", 48, 38.7, 768}},
+	{"RTX A5000", "rtx-a5000", []string{"a5000"}, Spec{"Ampere", 24, 27.8, 768}},
+	{"RTX A4500", "rtx-a4500", []string{"a4500"}, Spec{"Ampere", 20, 23.7, 640}},
+	{"RTX A4000", "rtx-a4000", []string{"a4000"}, Spec{"Ampere", 16, 19.2, 448}},
+	{"RTX A2000", "rtx-a2000", []string{"a2000"}, Spec{"Ampere", 12, 8, 288}},
+	{"A40", "a40", []string{"a40"}, Spec{"Ampere", 48, 37.4, 696}},
+	{"A30", "a30", []string{"a30"}, Spec{"Ampere", 24, 10.3, 933}},
+	{"A10", "a10", []string{"a10"}, Spec{"Ampere", 24, 31.2, 600}},
+	{"RTX 5090", "rtx-5090", []string{"5090"}, Spec{"Blackwell", 32, 104.8, 1792}},
+	{"RTX 5080", "rtx-5080", []string{"5080"}, Spec{"Blackwell", 16, 56.3, 960}},
+	{"RTX 4090", "rtx-4090", []string{"4090"}, Spec{"Ada Lovelace", 24, 82.6, 1008}},
+	{"RTX 4080", "rtx-4080", []string{"4080"}, Spec{"Ada Lovelace", 16, 48.7, 716.8}},
+	{"RTX 4070 Ti", "rtx-4070-ti", []string{"4070ti"}, Spec{"Ada Lovelace", 12, 40.1, 504}},
+	{"RTX 4070", "rtx-4070", []string{"4070"}, Spec{"Ada Lovelace", 12, 29.1, 504}},
+	{"RTX 3090 Ti", "rtx-3090-ti", []string{"3090ti"}, Spec{"Ampere", 24, 40, 1008}},
+	{"RTX 3090", "rtx-3090", []string{"3090"}, Spec{"Ampere", 24, 35.6, 936}},
+	{"RTX 3080 Ti", "rtx-3080-ti", []string{"3080ti"}, Spec{"Ampere", 12, 34.1, 912}},
+	{"RTX 3080", "rtx-3080", []string{"3080"}, Spec{"Ampere", 10, 29.8, 760}},
+	{"RTX 3070", "rtx-3070", []string{"3070"}, Spec{"Ampere", 8, 20.3, 448}},
+	{"RTX 3060", "rtx-3060", []string{"3060"}, Spec{"Ampere", 12, 12.7, 360}},
+	{"V100", "v100", []string{"v100"}, Spec{"Volta", 16, 14, 900}},
+	{"L4", "l4", []string{"l4"}, Spec{"Ada Lovelace", 24, 30.3, 300}},
+	{"T4", "t4", []string{"t4"}, Spec{"Turing", 16, 8.1, 300}},
+}
+
+// Squash lower-cases s and drops everything but letters and digits.
+func Squash(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// words squashes s like Squash and marks where its words start: breaks[i] is true when a word
+// starts or ends at sq[i], i.e. after a separator or at a letter/digit change, and at both ends.
+func words(s string) (sq string, breaks []bool) {
+	var b strings.Builder
+	breaks = []bool{true}
+	sep, wasDigit := false, false
+	for _, r := range strings.ToLower(s) {
+		letter, digit := r >= 'a' && r <= 'z', r >= '0' && r <= '9'
+		if !letter && !digit {
+			sep = true
+			continue
+		}
+		if n := b.Len(); n > 0 {
+			breaks[n] = sep || wasDigit != digit
+		}
+		b.WriteRune(r)
+		breaks = append(breaks, false)
+		sep, wasDigit = false, digit
+	}
+	breaks[len(breaks)-1] = true
+	return b.String(), breaks
+}
+
+// wordAt reports whether a squashed term occurs in sq starting and ending on word breaks.
+func wordAt(sq string, breaks []bool, term string) bool {
+	if term == "" {
+		return false
+	}
+	for i := 0; i+len(term) <= len(sq); i++ {
+		if breaks[i] && breaks[i+len(term)] && sq[i:i+len(term)] == term {
+			return true
+		}
+	}
+	return false
+}
+
+// HasWord reports whether term, squashed, occurs in name starting and ending on a word break:
+// a separator or a change between letters and digits. "a100" is in "NVIDIA A100-SXM4" and
+// "H100 80GB" has "h100", but "A1000" has neither "a100" nor "a10", nor "L40S" "l4".
+func HasWord(name, term string) bool {
+	sq, breaks := words(name)
+	return wordAt(sq, breaks, Squash(term))
+}
+
+var cache sync.Map // offer name -> canonical model name
+
+// Canonical maps a provider's GPU name onto a Model name, or "" when unknown.
+func Canonical(name string) string {
+	if m, ok := cache.Load(name); ok {
+		return m.(string)
+	}
+	sq, breaks := words(name)
+	model, best := "", 0
+	for _, m := range Models {
+		for _, a := range m.Aliases {
+			if len(a) > best && wordAt(sq, breaks, a) {
+				model, best = m.Name, len(a)
+			}
+		}
+	}
+	cache.Store(name, model)
+	return model
+}
+
+// Lookup finds a model by name, slug or alias, ignoring case and punctuation.
+func Lookup(s string) (Model, bool) {
+	sq := Squash(s)
+	for _, m := range Models {
+		if sq == Squash(m.Name) || sq == Squash(m.Slug) {
+			return m, true
+		}
+	}
+	for _, m := range Models {
+		for _, a := range m.Aliases {
+			if sq == a {
+				return m, true
+			}
+		}
+	}
+	return Model{}, false
+}
+
+// Candidates lists the models s may mean: the one it names by name or slug, or else every model
+// it is an alias of or a word of the name of, so "h100" is all three H100s where Lookup settles
+// on the PCIe.
+func Candidates(s string) []Model {
+	sq := Squash(s)
+	if sq == "" {
+		return nil
+	}
+	for _, m := range Models {
+		if sq == Squash(m.Name) || sq == Squash(m.Slug) {
+			return []Model{m}
+		}
+	}
+	var out []Model
+	for _, m := range Models {
+		if slices.Contains(m.Aliases, sq) || HasWord(m.Name, sq) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+// internal/gpumodel/gpumodel_test.go
+package gpumodel
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	for name, want := range map[string]string{
+		"NVIDIA H100 HBM3":       "H100 SXM",
+		"H100_SXM5":              "H100 SXM",
+		"H100 NVL":               "H100 NVL",
+		"h100-pcie":              "H100 PCIe",
+		"NVIDIA A100-SXM4-80GB":  "A100 SXM",
+		"A100 80GB PCIe":         "A100 PCIe",
+		"RTX A1000":              "",
+		"A10G":                   "A10",
+		"RTX A4000":              "RTX A4000",
+		"L40S":                   "L40S",
+		"L40":                    "L40",
+		"NVIDIA L4":              "L4",
+		"GB200 NVL72":            "GB200",
+		"GH200 480GB":            "GH200",
+		"HGX B200":               "B200",
+		"MI250X":                 "MI250X",
+		"RTX 4070 Ti":            "RTX 4070 Ti",
+		"RTX 6000 Ada":           "RTX 6000 Ada",
+		"RTX PRO 6000 Blackwell": "RTX PRO 6000",
+		"Tesla T4":               "T4",
+		"Quadro P5000":           "",
+	} {
+		if got := Canonical(name); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestHasWord(t *testing.T) {
+	tests := []struct {
+		name, term string
+		want       bool
+	}{
+		{"A100 SXM", "a100", true},
+		{"A100 SXM", "a10", false},
+		{"A10G", "a10", true},
+		{"L40S", "l4", false},
+		{"L40S", "l40", true},
+		{"RTX 4090", "rtx", true},
+		{"RTX 4090", "rtx 40", false},
+		{"RTX 6000 Ada", "6000 ada", true},
+		{"GB200", "b200", false},
+		{"L4", "", false},
+	}
+	for _, tt := range tests {
+		if got := HasWord(tt.name, tt.term); got != tt.want {
+			t.Errorf("HasWord(%q, %q) = %v, want %v", tt.name, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	for s, want := range map[string][]string{
+		"h100":      {"H100 SXM", "H100 NVL", "H100 PCIe"},
+		"h100-sxm":  {"H100 SXM"},
+		"H100 PCIe": {"H100 PCIe"},
+		"l40":       {"L40"},
+		"4090":      {"RTX 4090"},
+		"nothing":   nil,
+		"":          nil,
+	} {
+		got := Candidates(s)
+		if len(got) != len(want) {
+			t.Errorf("Candidates(%q) = %v, want %v", s, got, want)
+			continue
+		}
+		for i, m := range got {
+			if m.Name != want[i] {
+				t.Errorf("Candidates(%q)[%d] = %q, want %q", s, i, m.Name, want[i])
+			}
+		}
+	}
+}
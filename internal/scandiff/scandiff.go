@@ -0,0 +1,150 @@
+// Package scandiff compares two scans of the catalogue. Offer ids are minted per scan, so offers
+// are matched on a stable key instead. Shared by the scanner, which records each run's diff, and
+// the API, which streams and serves it.
+package scandiff
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Event types.
+const (
+	Added        = "added"        // a key with no listing before
+	Removed      = "removed"      // a key with no listing now
+	PriceUp      = "price_up"     // the cheapest listing of a key got dearer
+	PriceDown    = "price_down"   // the cheapest listing of a key got cheaper
+	Availability = "availability" // a key's number of listings changed
+)
+
+// Offer is what the diff needs to know about a listing.
+type Offer struct {
+	Source      string
+	Name        string
+	Location    string
+	NumGPUs     int
+	TotalCostPH float64
+}
+
+// Key identifies an offer across scans: the same machine shape from the same provider in the
+// same place. Several listings can share one.
+func (o Offer) Key() string {
+	return strings.Join([]string{o.Source, o.Name, strconv.Itoa(o.NumGPUs), o.Location}, "|")
+}
+
+// Event is one change to a key between two scans.
+type Event struct {
+	Type     string `json:"type"`
+	Key      string `json:"key"`
+	Source   string `json:"source"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	NumGPUs  int    `json:"num_gpus"`
+
+	// Prices are the key's cheapest total_cost_ph; Delta is CostPH - PrevCostPH and DeltaPct
+	// that relative to PrevCostPH, in percent.
+	PrevCostPH float64 `json:"prev_cost_ph"`
+	CostPH     float64 `json:"cost_ph"`
+	Delta      float64 `json:"delta"`
+	DeltaPct   float64 `json:"delta_pct"`
+
+	PrevListings int `json:"prev_listings"`
+	Listings     int `json:"listings"`
+
+	// Prev and Cur index the key's cheapest listing in the slices given to Diff, -1 when the
+	// key is absent from that side.
+	Prev int `json:"-"`
+	Cur  int `json:"-"`
+}
+
+// priceEpsilon absorbs float noise in provider prices.
+const priceEpsilon = 1e-6
+
+type group struct {
+	cheapest int // index of the cheapest listing
+	listings int
+}
+
+func groups(offers []Offer) map[string]*group {
+	out := map[string]*group{}
+	for i, o := range offers {
+		k := o.Key()
+		g, ok := out[k]
+		if !ok {
+			out[k] = &group{cheapest: i, listings: 1}
+			continue
+		}
+		g.listings++
+		if c := offers[g.cheapest].TotalCostPH; o.TotalCostPH < c {
+			g.cheapest = i
+		}
+	}
+	return out
+}
+
+// Diff returns the changes from prev to cur, ordered by key. A key whose price and listing
+// count both moved gets two events.
+func Diff(prev, cur []Offer) []Event {
+	before, after := groups(prev), groups(cur)
+	keys := make([]string, 0, len(after)+len(before))
+	for k := range after {
+		keys = append(keys, k)
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var out []Event
+	for _, k := range keys {
+		b, a := before[k], after[k]
+		ev := Event{Key: k, Prev: -1, Cur: -1}
+		var o Offer
+		if b != nil {
+			o = prev[b.cheapest]
+			ev.Prev, ev.PrevCostPH, ev.PrevListings = b.cheapest, o.TotalCostPH, b.listings
+		}
+		if a != nil {
+			o = cur[a.cheapest]
+			ev.Cur, ev.CostPH, ev.Listings = a.cheapest, o.TotalCostPH, a.listings
+		}
+		ev.Source, ev.Name, ev.Location, ev.NumGPUs = o.Source, o.Name, o.Location, o.NumGPUs
+
+		switch {
+		case b == nil:
+			ev.Type = Added
+			out = append(out, ev)
+			continue
+		case a == nil:
+			ev.Type = Removed
+			out = append(out, ev)
+			continue
+		}
+		if d := ev.CostPH - ev.PrevCostPH; math.Abs(d) > priceEpsilon && ev.PrevCostPH > 0 && ev.CostPH > 0 {
+			p := ev
+			p.Type, p.Delta, p.DeltaPct = PriceDown, d, 100*d/ev.PrevCostPH
+			if d > 0 {
+				p.Type = PriceUp
+			}
+			out = append(out, p)
+		}
+		if ev.Listings != ev.PrevListings {
+			ev.Type = Availability
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// Counts tallies events by type.
+func Counts(events []Event) map[string]int {
+	out := map[string]int{}
+	for _, e := range events {
+		out[e.Type]++
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+// internal/scandiff/scandiff_test.go
+package scandiff
+
+import (
+	"math"
+	"testing"
+)
+
+func offer(source string, price float64) Offer {
+	return Offer{Source: source, Name: "H100 SXM5", Location: "us-east-1", NumGPUs: 8, TotalCostPH: price}
+}
+
+func TestDiff(t *testing.T) {
+	type change struct {
+		typ       string
+		prev, cur float64
+		listings  [2]int // before, after
+	}
+	tests := []struct {
+		name      string
+		prev, cur []Offer
+		want      []change
+	}{
+		{"added", nil, []Offer{offer("runpod", 20)}, []change{{Added, 0, 20, [2]int{0, 1}}}},
+		{"removed", []Offer{offer("runpod", 20)}, nil, []change{{Removed, 20, 0, [2]int{1, 0}}}},
+		{"unchanged", []Offer{offer("runpod", 20)}, []Offer{offer("runpod", 20)}, nil},
+		{"price up", []Offer{offer("runpod", 20)}, []Offer{offer("runpod", 22)}, []change{{PriceUp, 20, 22, [2]int{1, 1}}}},
+		{"price down", []Offer{offer("runpod", 20)}, []Offer{offer("runpod", 15)}, []change{{PriceDown, 20, 15, [2]int{1, 1}}}},
+		// 1+priceEpsilon-1 falls a hair under priceEpsilon in float64, so the boundary is a no-op.
+		{"at epsilon", []Offer{offer("runpod", 1)}, []Offer{offer("runpod", 1+priceEpsilon)}, nil},
+		{"past epsilon up", []Offer{offer("runpod", 1)}, []Offer{offer("runpod", 1+2*priceEpsilon)}, []change{{PriceUp, 1, 1 + 2*priceEpsilon, [2]int{1, 1}}}},
+		{"past epsilon down", []Offer{offer("runpod", 1)}, []Offer{offer("runpod", 1-2*priceEpsilon)}, []change{{PriceDown, 1, 1 - 2*priceEpsilon, [2]int{1, 1}}}},
+		{"from zero price", []Offer{offer("runpod", 0)}, []Offer{offer("runpod", 20)}, nil},
+		{"to zero price", []Offer{offer("runpod", 20)}, []Offer{offer("runpod", 0)}, nil},
+		{"more listings", []Offer{offer("runpod", 20)}, []Offer{offer("runpod", 20), offer("runpod", 21)}, []change{{Availability, 20, 20, [2]int{1, 2}}}},
+		{"fewer listings", []Offer{offer("runpod", 20), offer("runpod", 20)}, []Offer{offer("runpod", 20)}, []change{{Availability, 20, 20, [2]int{2, 1}}}},
+		{"price and count", []Offer{offer("runpod", 20)}, []Offer{offer("runpod", 25), offer("runpod", 18)},
+			[]change{{PriceDown, 20, 18, [2]int{1, 2}}, {Availability, 20, 18, [2]int{1, 2}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.prev, tt.cur)
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				e := got[i]
+				if e.Type != w.typ || e.PrevCostPH != w.prev || e.CostPH != w.cur ||
+					e.PrevListings != w.listings[0] || e.Listings != w.listings[1] {
+					t.Errorf("event %d = %+v, want %+v", i, e, w)
+				}
+				if e.Key != offer("runpod", 0).Key() || e.Source != "runpod" || e.NumGPUs != 8 {
+					t.Errorf("event %d describes %q", i, e.Key)
+				}
+				if w.typ == PriceUp || w.typ == PriceDown {
+					if d := w.cur - w.prev; math.Abs(e.Delta-d) > 1e-12 || math.Abs(e.DeltaPct-100*d/w.prev) > 1e-9 {
+						t.Errorf("event %d delta %v (%v%%), want %v", i, e.Delta, e.DeltaPct, d)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDiffIndexesCheapestListing(t *testing.T) {
+	prev := []Offer{offer("runpod", 22), offer("runpod", 20), offer("lambda", 30)}
+	cur := []Offer{offer("vast", 10), offer("runpod", 21), offer("runpod", 19)}
+	got := Diff(prev, cur)
+	want := []struct {
+		typ       string
+		prev, cur int
+	}{
+		{Removed, 2, -1},  // lambda
+		{PriceDown, 1, 2}, // runpod
+		{Added, -1, 0},    // vast
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Type != w.typ || got[i].Prev != w.prev || got[i].Cur != w.cur {
+			t.Errorf("event %d = %s prev %d cur %d, want %+v", i, got[i].Type, got[i].Prev, got[i].Cur, w)
+		}
+	}
+}
+
+func TestCounts(t *testing.T) {
+	prev := []Offer{offer("runpod", 20), offer("lambda", 30)}
+	cur := []Offer{offer("runpod", 25), offer("runpod", 18), offer("vast", 10)}
+	got := Counts(Diff(prev, cur))
+	want := map[string]int{Added: 1, Removed: 1, PriceDown: 1, Availability: 1}
+	if len(got) != len(want) {
+		t.Fatalf("counts = %v, want %v", got, want)
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("counts[%s] = %d, want %d", k, got[k], n)
+		}
+	}
+	if len(Counts(nil)) != 0 {
+		t.Error("counts of no events are not empty")
+	}
+}
@@ -0,0 +1,50 @@
+-- saved_searches and alert_deliveries back the API's /searches endpoints. Only the API writes them,
+-- with the service key; row level security is on without policies so the anon key sees nothing.
+create table if not exists saved_searches (
+    id                uuid             primary key,
+    name              text             not null,
+    query             text             not null default '', -- /gpus query string
+    trigger           text             not null check (trigger in ('below', 'new')),
+    max_total_cost_ph double precision not null default 0,
+    webhook           jsonb,                                 -- {"url": ..., "format": "json|slack|discord"}
+    email             jsonb,                                 -- {"address": ..., "digest": "none|hourly|daily", "confirmed": bool}
+    secret            text             not null,             -- HMAC key for X-Gpufindr-Signature and unsubscribe links
+    token_hash        text             not null,             -- sha256 of the management token
+    created_at        timestamptz      not null default now()
+);
+
+create table if not exists alert_deliveries (
+    id          bigserial   primary key,
+    search_id   uuid        not null references saved_searches (id) on delete cascade,
+    at          timestamptz not null,
+    event       text        not null,
+    channel     text        not null default 'webhook',     -- webhook or email
+    attempt     integer     not null,
+    status      text        not null,
+    http_status integer,                                    -- SMTP reply code for email
+    error       text,
+    offers      integer     not null default 0
+);
+
+-- alert_scans records the scans whose alerts were sent, so a scan announced twice, or to two
+-- instances, is only evaluated once.
+create table if not exists alert_scans (
+    scan_id    uuid        primary key,
+    claimed_at timestamptz not null default now()
+);
+
+-- alert_digests queues the alerts of digest searches until their hour or day is over.
+create table if not exists alert_digests (
+    id        bigserial   primary key,
+    search_id uuid        not null references saved_searches (id) on delete cascade,
+    due       timestamptz not null,
+    event     jsonb       not null -- AlertEvent
+);
+
+create index if not exists alert_digests_due_idx on alert_digests (due);
+create index if not exists alert_deliveries_search_idx on alert_deliveries (search_id, at desc);
+
+alter table saved_searches enable row level security;
+alter table alert_deliveries enable row level security;
+alter table alert_scans enable row level security;
+alter table alert_digests enable row level security;
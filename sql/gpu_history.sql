@@ -0,0 +1,91 @@
+-- gpu_history keeps every offer of every scan so price trends survive the hourly replace
+-- of gpus. Written by cmd/scan, read by the API's /gpus/{id}/history and /models/{model}/history.
+create table if not exists gpu_history (
+    scan_id       uuid             not null,
+    scanned_at    timestamptz      not null,
+    offer_id      uuid             not null,
+    source        text             not null,
+    location      text             not null default '',
+    name          text             not null default '',
+    model         text             not null default '', -- canonical model, see internal/gpumodel
+    num_gpus      integer          not null default 0,
+    vram_mb       integer          not null default 0,
+    total_cost_ph double precision not null default 0,
+    primary key (scan_id, offer_id)
+);
+
+create index if not exists gpu_history_model_idx on gpu_history (model, scanned_at);
+create index if not exists gpu_history_offer_idx on gpu_history (source, name, location, num_gpus, scanned_at);
+create index if not exists gpu_history_scanned_idx on gpu_history (scanned_at);
+
+alter table gpu_history enable row level security;
+create policy "gpu_history is public" on gpu_history for select using (true);
+
+-- gpu_history_buckets summarises the rows matching a history filter into buckets of
+-- p_bucket_seconds aligned to the epoch, the same aggregation as the API's bucketHistory: prices
+-- over every sighting in the bucket, offer/GPU counts from the bucket's latest scan. Empty text
+-- filters and p_num_gpus 0 match everything; p_region is a case-insensitive substring of location.
+create or replace function gpu_history_buckets(
+    p_from           timestamptz,
+    p_to             timestamptz,
+    p_bucket_seconds double precision,
+    p_model          text    default '',
+    p_source         text    default '',
+    p_location       text    default '',
+    p_region         text    default '',
+    p_name           text    default '',
+    p_num_gpus       integer default 0
+) returns table (
+    start          timestamptz,
+    scans          integer,
+    min_cost_ph    double precision,
+    median_cost_ph double precision,
+    p90_cost_ph    double precision,
+    offers         integer,
+    gpus           integer,
+    by_source      jsonb,
+    by_region      jsonb
+) language sql stable as $$
+    with sightings as (
+        select h.scanned_at, h.source, h.location, h.num_gpus, h.total_cost_ph,
+               to_timestamp(floor(extract(epoch from h.scanned_at) / p_bucket_seconds) * p_bucket_seconds) as bucket
+        from gpu_history h
+        where h.scanned_at >= p_from and h.scanned_at < p_to
+          and (p_model = '' or h.model = p_model)
+          and (p_source = '' or h.source = p_source)
+          and (p_location = '' or h.location = p_location)
+          and (p_region = '' or h.location ilike '%' || p_region || '%')
+          and (p_name = '' or h.name = p_name)
+          and (p_num_gpus = 0 or h.num_gpus = p_num_gpus)
+    ),
+    latest as (
+        select s.* from sightings s
+        where s.scanned_at = (select max(l.scanned_at) from sightings l where l.bucket = s.bucket)
+    ),
+    prices as (
+        select bucket,
+               count(distinct scanned_at)::integer as scans,
+               coalesce(min(total_cost_ph) filter (where total_cost_ph > 0), 0) as min_cost_ph,
+               coalesce(percentile_cont(0.5) within group (order by total_cost_ph) filter (where total_cost_ph > 0), 0) as median_cost_ph,
+               coalesce(percentile_cont(0.9) within group (order by total_cost_ph) filter (where total_cost_ph > 0), 0) as p90_cost_ph
+        from sightings group by bucket
+    ),
+    counts as (
+        select bucket, count(*)::integer as offers, sum(greatest(num_gpus, 1))::integer as gpus
+        from latest group by bucket
+    ),
+    sources as (
+        select bucket, jsonb_object_agg(source, n) as by_source
+        from (select bucket, source, count(*) as n from latest group by bucket, source) x group by bucket
+    ),
+    regions as (
+        select bucket, jsonb_object_agg(location, n) as by_region
+        from (select bucket, location, count(*) as n from latest group by bucket, location) x group by bucket
+    )
+    select p.bucket, p.scans, p.min_cost_ph, p.median_cost_ph, p.p90_cost_ph, c.offers, c.gpus, s.by_source, r.by_region
+    from prices p
+    join counts c using (bucket)
+    join sources s using (bucket)
+    join regions r using (bucket)
+    order by p.bucket
+$$;
@@ -0,0 +1,43 @@
+-- scans and scan_diffs record what changed in every scanner run against the catalogue it replaced.
+-- Written by cmd/scan (see internal/scandiff), read by the API's /scans and /scans/{id}/diff.
+create table if not exists scans (
+    scan_id             uuid        primary key, -- same id as the scan's gpu_history rows
+    scanned_at          timestamptz not null,
+    previous_scanned_at timestamptz,
+    offers              integer     not null default 0,
+    added               integer     not null default 0,
+    removed             integer     not null default 0,
+    price_up            integer     not null default 0,
+    price_down          integer     not null default 0,
+    availability        integer     not null default 0
+);
+
+create table if not exists scan_diffs (
+    scan_id       uuid             not null references scans (scan_id) on delete cascade,
+    scanned_at    timestamptz      not null, -- the scan's, so the deals feeds read scan_diffs on their own
+    type          text             not null check (type in ('added', 'removed', 'price_up', 'price_down', 'availability')),
+    key           text             not null, -- source|name|num_gpus|location
+    source        text             not null,
+    name          text             not null default '',
+    location      text             not null default '',
+    num_gpus      integer          not null default 0,
+    model         text             not null default '',
+    new_model     boolean          not null default false, -- added, and no listing had the model last scan
+    offer_id      uuid,
+    prev_cost_ph  double precision not null default 0,
+    cost_ph       double precision not null default 0,
+    delta         double precision not null default 0,
+    delta_pct     double precision not null default 0,
+    prev_listings integer          not null default 0,
+    listings      integer          not null default 0,
+    primary key (scan_id, key, type)
+);
+
+create index if not exists scans_scanned_idx on scans (scanned_at desc);
+create index if not exists scan_diffs_model_idx on scan_diffs (model, scan_id);
+create index if not exists scan_diffs_deals_idx on scan_diffs (scanned_at desc) where type = 'price_down' or new_model;
+
+alter table scans enable row level security;
+alter table scan_diffs enable row level security;
+create policy "scans are public" on scans for select using (true);
+create policy "scan_diffs are public" on scan_diffs for select using (true);